@@ -2,6 +2,7 @@ package block_parser
 
 import (
 	"context"
+	"das_sub_account/cache"
 	"das_sub_account/config"
 	"das_sub_account/dao"
 	"das_sub_account/lb"
@@ -34,6 +35,7 @@ type BlockParser struct {
 	Wg                   *sync.WaitGroup
 	Slb                  *lb.LoadBalancing
 	SmtServerUrl         *string
+	RC                   *cache.RedisCache
 }
 
 func (b *BlockParser) Run() error {
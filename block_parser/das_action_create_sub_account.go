@@ -72,6 +72,11 @@ func (b *BlockParser) DasActionCreateSubAccount(req FuncTransactionHandleReq) (r
 			return
 		}
 	}
+	if selfTask.TaskId != "" {
+		b.doStatusEvent(selfTask.TaskId)
+	} else {
+		b.doStatusEvent(taskInfo.TaskId)
+	}
 
 	doNotify(smtRecordList)
 	b.doNotify2(smtRecordList)
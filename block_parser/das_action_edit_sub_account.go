@@ -51,6 +51,11 @@ func (b *BlockParser) DasActionEditSubAccount(req FuncTransactionHandleReq) (res
 			return
 		}
 	}
+	if selfTask.TaskId != "" {
+		b.doStatusEvent(selfTask.TaskId)
+	} else {
+		b.doStatusEvent(taskInfo.TaskId)
+	}
 
 	return
 }
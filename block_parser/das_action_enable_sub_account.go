@@ -43,6 +43,7 @@ func (b *BlockParser) DasActionEnableSubAccount(req FuncTransactionHandleReq) (r
 		resp.Err = fmt.Errorf("CreateTaskByDasActionEnableSubAccount err: %s", err.Error())
 		return
 	}
+	b.doCachePurge(accBuilder.AccountId)
 	return
 }
 
@@ -112,6 +113,11 @@ func (b *BlockParser) DasActionConfigSubAccountOrCustomScript(req FuncTransactio
 		resp.Err = err
 		return
 	}
+	if err := b.doRuleVersion(req, index, parentAccountId, accBuilder.Account); err != nil {
+		resp.Err = fmt.Errorf("doRuleVersion err: %s", err.Error())
+		return
+	}
+	b.doCachePurge(parentAccountId)
 	return
 }
 
@@ -74,6 +74,13 @@ func (b *BlockParser) DasActionUpdateSubAccount(req FuncTransactionHandleReq) (r
 			return
 		}
 	}
+	if selfTask.TaskId != "" {
+		b.doStatusEvent(selfTask.TaskId)
+		b.doWebhookEvent(req, selfTask.TaskId, outpoint, smtRecordList)
+	} else {
+		b.doStatusEvent(taskInfo.TaskId)
+		b.doWebhookEvent(req, taskInfo.TaskId, outpoint, smtRecordList)
+	}
 
 	doNotifyDiscord(smtRecordList)
 	b.doNotifyLark(smtRecordList)
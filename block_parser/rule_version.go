@@ -0,0 +1,100 @@
+package block_parser
+
+import (
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/scorpiotzh/toolib"
+	"math"
+	"strings"
+)
+
+// doRuleVersion save the rule sets of the config tx if the rule hash changed,
+// the rules can't be decoded are only logged
+func (b *BlockParser) doRuleVersion(req FuncTransactionHandleReq, index int, parentAccountId, parentAccount string) error {
+	detail := witness.ConvertSubAccountCellOutputData(req.Tx.OutputsData[index])
+	for _, v := range []struct {
+		ruleType       tables.RuleType
+		actionDataType common.ActionDataType
+		hash           []byte
+	}{
+		{tables.RuleTypePriceRules, common.ActionDataTypeSubAccountPriceRules, detail.PriceRulesHash},
+		{tables.RuleTypePreservedRules, common.ActionDataTypeSubAccountPreservedRules, detail.PreservedRulesHash},
+	} {
+		ruleHash := common.Bytes2Hex(v.hash)
+		latest, err := b.DbDao.GetLatestRuleVersion(parentAccountId, v.ruleType)
+		if err != nil {
+			return fmt.Errorf("GetLatestRuleVersion err: %s", err.Error())
+		} else if latest.Id > 0 && latest.RuleHash == ruleHash {
+			continue
+		}
+
+		entity := witness.NewSubAccountRuleEntity(parentAccount)
+		if err := entity.ParseFromTx(req.Tx, v.actionDataType); err != nil {
+			log.Error("ParseFromTx err:", err.Error(), req.TxHash, v.ruleType)
+			continue
+		}
+		if latest.Id == 0 && len(entity.Rules) == 0 {
+			continue
+		}
+		undecodedNum, err := b.decodeRuleWhitelist(parentAccountId, parentAccount, v.ruleType, entity.Rules)
+		if err != nil {
+			return err
+		}
+		if err := b.DbDao.CreateRuleVersion(&tables.TableRuleVersion{
+			ParentAccountId: parentAccountId,
+			Account:         parentAccount,
+			RuleType:        v.ruleType,
+			TxHash:          req.TxHash,
+			BlockNumber:     req.BlockNumber,
+			BlockTimestamp:  req.BlockTimestamp,
+			RuleHash:        ruleHash,
+			RuleNum:         len(entity.Rules),
+			Rules:           toolib.JsonString(entity.Rules),
+			UndecodedNum:    undecodedNum,
+		}); err != nil {
+			return fmt.Errorf("CreateRuleVersion err: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// decodeRuleWhitelist the same format as price/rule/update, the price in USD and in_list of the sub-account names
+// the account ids not found are left out and counted, the version can't be rolled back then
+func (b *BlockParser) decodeRuleWhitelist(parentAccountId, parentAccount string, ruleType tables.RuleType, rules witness.SubAccountRuleSlice) (undecodedNum int, e error) {
+	for _, v := range rules {
+		if ruleType == tables.RuleTypePriceRules {
+			v.Price /= math.Pow10(6)
+		}
+		if v.Ast.Type != witness.Function ||
+			v.Ast.Name != string(witness.FunctionInList) ||
+			len(v.Ast.Arguments) != 2 ||
+			v.Ast.Arguments[1].Type != witness.Value ||
+			v.Ast.Arguments[1].ValueType != witness.BinaryArray {
+			continue
+		}
+		accIdWhitelist := gconv.Strings(v.Ast.Arguments[1].Value)
+		list, err := b.DbDao.GetRulesBySubAccountIds(parentAccountId, ruleType, accIdWhitelist)
+		if err != nil {
+			e = fmt.Errorf("GetRulesBySubAccountIds err: %s", err.Error())
+			return
+		}
+		mapAccount := make(map[string]string)
+		for _, acc := range list {
+			mapAccount[acc.AccountId] = strings.TrimSuffix(acc.Account, "."+parentAccount)
+		}
+		accWhitelist := make([]string, 0, len(accIdWhitelist))
+		for _, accId := range accIdWhitelist {
+			if name, ok := mapAccount[accId]; ok {
+				accWhitelist = append(accWhitelist, name)
+			} else {
+				log.Warn("decodeRuleWhitelist account not found:", parentAccount, accId)
+				undecodedNum++
+			}
+		}
+		v.Ast.Arguments[1].Value = accWhitelist
+	}
+	return
+}
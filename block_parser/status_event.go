@@ -0,0 +1,35 @@
+package block_parser
+
+import (
+	"das_sub_account/cache"
+)
+
+// doStatusEvent commits the status event of the task committed on chain
+func (b *BlockParser) doStatusEvent(taskId string) {
+	if b.RC == nil || taskId == "" {
+		return
+	}
+	task, err := b.DbDao.GetTaskByTaskId(taskId)
+	if err != nil {
+		log.Error("doStatusEvent GetTaskByTaskId err:", err.Error(), taskId)
+		return
+	} else if task.Id == 0 {
+		return
+	}
+	records, err := b.DbDao.GetSmtRecordListByTaskId(taskId)
+	if err != nil {
+		log.Error("doStatusEvent GetSmtRecordListByTaskId err:", err.Error(), taskId)
+		return
+	}
+	b.RC.CommitStatusEvent(task, records, cache.StatusEventCommitted, "")
+}
+
+// doCachePurge purges the cached responses of the accounts changed without a status event
+func (b *BlockParser) doCachePurge(accountIds ...string) {
+	if b.RC == nil {
+		return
+	}
+	if err := b.RC.PurgeCacheTags(accountIds...); err != nil {
+		log.Error("doCachePurge PurgeCacheTags err:", err.Error(), accountIds)
+	}
+}
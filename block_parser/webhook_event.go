@@ -0,0 +1,44 @@
+package block_parser
+
+import (
+	"das_sub_account/tables"
+	"github.com/dotbitHQ/das-lib/common"
+)
+
+// doWebhookEvent queue the webhook deliveries of the committed sub-account records, failures are only logged
+func (b *BlockParser) doWebhookEvent(req FuncTransactionHandleReq, taskId, outpoint string, smtRecordList []tables.TableSmtRecordInfo) {
+	// records of self task keep the mint type and order id
+	if list, err := b.DbDao.GetSmtRecordListByTaskId(taskId); err != nil {
+		log.Error("GetSmtRecordListByTaskId err:", err.Error(), taskId)
+	} else if len(list) > 0 {
+		smtRecordList = list
+	}
+	for _, v := range smtRecordList {
+		data := tables.WebhookSubAccountData{
+			Account:     v.Account,
+			AccountId:   v.AccountId,
+			TaskId:      taskId,
+			Outpoint:    outpoint,
+			BlockNumber: req.BlockNumber,
+			MintType:    int(v.MintType),
+			OrderId:     v.OrderID,
+		}
+		var event tables.WebhookEvent
+		switch v.SubAction {
+		case common.SubActionCreate:
+			event = tables.WebhookEventSubAccountMinted
+			data.RegisterYears = v.RegisterYears
+		case common.SubActionRenew:
+			event = tables.WebhookEventSubAccountRenewed
+			data.RenewYears = v.RenewYears
+		case common.SubActionEdit:
+			event = tables.WebhookEventSubAccountEdited
+			data.EditKey = v.EditKey
+		default:
+			continue
+		}
+		if err := b.DbDao.CreateWebhookEvent(v.ParentAccountId, event, data); err != nil {
+			log.Error("CreateWebhookEvent err:", err.Error(), event, v.Account)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+func (r *RedisCache) getApiKeyRateKey(keyId string, minute int64) string {
+	return fmt.Sprintf("api_key:rate:%s:%d", keyId, minute)
+}
+
+// IncrApiKeyRate returns the number of requests of the key in the current minute
+func (r *RedisCache) IncrApiKeyRate(keyId string) (int64, error) {
+	if r.Red == nil {
+		return 0, fmt.Errorf("redis is nil")
+	}
+	key := r.getApiKeyRateKey(keyId, time.Now().Unix()/60)
+	num, err := r.Red.Incr(key).Result()
+	if err != nil {
+		return 0, err
+	}
+	if num == 1 {
+		_ = r.Red.Expire(key, time.Minute*2).Err()
+	}
+	return num, nil
+}
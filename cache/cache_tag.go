@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/go-redis/redis"
+	"time"
+)
+
+// toolib.CacheByRedis keeps the update window of a cached response in uek:<key>
+const cacheUpdateKeyPrefix = "uek:"
+
+func (r *RedisCache) getCacheTagKey(accountId string) string {
+	return "cache:tag:" + accountId
+}
+
+// AddCacheTag tags the response cache key by the account ids, the tags expire with the cached data
+func (r *RedisCache) AddCacheTag(cacheKey string, expiration time.Duration, accountIds ...string) error {
+	if r.Red == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	_, err := r.Red.Pipelined(func(p redis.Pipeliner) error {
+		for _, v := range accountIds {
+			if v == "" {
+				continue
+			}
+			tagKey := r.getCacheTagKey(v)
+			p.SAdd(tagKey, cacheKey)
+			p.Expire(tagKey, expiration)
+		}
+		return nil
+	})
+	return err
+}
+
+// PurgeCacheTags deletes the cached responses tagged by the account ids
+func (r *RedisCache) PurgeCacheTags(accountIds ...string) error {
+	if r.Red == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	for _, v := range accountIds {
+		if v == "" {
+			continue
+		}
+		tagKey := r.getCacheTagKey(v)
+		cacheKeys, err := r.Red.SMembers(tagKey).Result()
+		if err != nil {
+			return err
+		}
+		keys := []string{tagKey}
+		for _, key := range cacheKeys {
+			keys = append(keys, key, cacheUpdateKeyPrefix+key)
+		}
+		if err := r.Red.Del(keys...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+)
+
+func (r *RedisCache) getInternalNonceKey(caller, nonce string) string {
+	return fmt.Sprintf("internal:nonce:%s:%s", caller, nonce)
+}
+
+// SetInternalNonce returns false if the nonce of the caller has been used
+func (r *RedisCache) SetInternalNonce(caller, nonce string, expiration time.Duration) (bool, error) {
+	if r.Red == nil {
+		return false, fmt.Errorf("redis is nil")
+	}
+	return r.Red.SetNX(r.getInternalNonceKey(caller, nonce), 1, expiration).Result()
+}
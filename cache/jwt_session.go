@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"fmt"
+	"github.com/go-redis/redis"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (r *RedisCache) getJwtRevokedKey(id string) string {
+	return "jwt:revoked:" + id
+}
+
+func (r *RedisCache) getJwtRevokedBeforeKey(address string) string {
+	return "jwt:revoked_before:" + strings.ToLower(address)
+}
+
+// RevokeJwt revokes a session id or a token id until the token expires
+func (r *RedisCache) RevokeJwt(id string, expiration time.Duration) error {
+	if r.Red == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	if expiration <= 0 {
+		return nil
+	}
+	return r.Red.Set(r.getJwtRevokedKey(id), "1", expiration).Err()
+}
+
+func (r *RedisCache) IsJwtRevoked(ids ...string) (bool, error) {
+	if r.Red == nil {
+		return false, fmt.Errorf("redis is nil")
+	}
+	var keys []string
+	for _, v := range ids {
+		if v != "" {
+			keys = append(keys, r.getJwtRevokedKey(v))
+		}
+	}
+	if len(keys) == 0 {
+		return false, nil
+	}
+	num, err := r.Red.Exists(keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	return num > 0, nil
+}
+
+// SetJwtRevokedBefore revokes all tokens of the address issued before timestamp
+func (r *RedisCache) SetJwtRevokedBefore(address string, timestamp int64, expiration time.Duration) error {
+	if r.Red == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	return r.Red.Set(r.getJwtRevokedBeforeKey(address), timestamp, expiration).Err()
+}
+
+func (r *RedisCache) GetJwtRevokedBefore(address string) (int64, error) {
+	if r.Red == nil {
+		return 0, fmt.Errorf("redis is nil")
+	}
+	str, err := r.Red.Get(r.getJwtRevokedBeforeKey(address)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(str, 10, 64)
+}
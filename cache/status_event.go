@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"das_sub_account/config"
+	"das_sub_account/tables"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/go-redis/redis"
+	"strings"
+	"time"
+)
+
+const statusEventChannel = "status:event"
+
+type StatusEventStatus string
+
+const (
+	StatusEventPending    StatusEventStatus = "pending"
+	StatusEventCommitted  StatusEventStatus = "committed"
+	StatusEventRejected   StatusEventStatus = "rejected"
+	StatusEventRolledBack StatusEventStatus = "rolled_back"
+)
+
+type StatusEvent struct {
+	TaskId          string            `json:"task_id"`
+	ParentAccountId string            `json:"parent_account_id"`
+	Action          string            `json:"action"`
+	Outpoint        string            `json:"outpoint"`
+	BlockNumber     uint64            `json:"block_number"`
+	Status          StatusEventStatus `json:"status"`
+	Reason          string            `json:"reason"`
+	SubAccounts     []string          `json:"sub_accounts"`
+	OrderIds        []string          `json:"order_ids"`
+	Addresses       []string          `json:"addresses"`
+	Timestamp       int64             `json:"timestamp"`
+}
+
+func NewStatusEvent(task tables.TableTaskInfo, records []tables.TableSmtRecordInfo, status StatusEventStatus, reason string) StatusEvent {
+	ev := StatusEvent{
+		TaskId:          task.TaskId,
+		ParentAccountId: task.ParentAccountId,
+		Action:          task.Action,
+		Outpoint:        task.Outpoint,
+		BlockNumber:     task.BlockNumber,
+		Status:          status,
+		Reason:          reason,
+		Timestamp:       time.Now().UnixMilli(),
+	}
+	daf := core.DasAddressFormat{DasNetType: config.Cfg.Server.Net}
+	var mapAddress = make(map[string]struct{})
+	addAddress := func(addr string) {
+		addr = strings.ToLower(addr)
+		if addr == "" {
+			return
+		}
+		if _, ok := mapAddress[addr]; !ok {
+			mapAddress[addr] = struct{}{}
+			ev.Addresses = append(ev.Addresses, addr)
+		}
+	}
+	for _, v := range records {
+		ev.SubAccounts = append(ev.SubAccounts, v.Account)
+		if v.OrderID != "" {
+			ev.OrderIds = append(ev.OrderIds, v.OrderID)
+		}
+		addAddress(v.LoginAddress)
+		addAddress(v.SignAddress)
+		if v.RegisterArgs != "" {
+			if ownerHex, _, err := daf.ArgsToHex(common.Hex2Bytes(v.RegisterArgs)); err == nil {
+				addAddress(ownerHex.AddressHex)
+			}
+		}
+	}
+	return ev
+}
+
+// AccountIds the parent account and the sub-accounts changed by the task
+func (ev StatusEvent) AccountIds() []string {
+	ids := []string{ev.ParentAccountId}
+	for _, v := range ev.SubAccounts {
+		if v != "" {
+			ids = append(ids, common.Bytes2Hex(common.GetAccountIdByAccount(v)))
+		}
+	}
+	return ids
+}
+
+func (r *RedisCache) PublishStatusEvent(ev StatusEvent) error {
+	if r == nil || r.Red == nil {
+		return fmt.Errorf("redis is nil")
+	}
+	bys, err := json.Marshal(ev)
+	if err != nil {
+		return fmt.Errorf("json.Marshal err: %s", err.Error())
+	}
+	return r.Red.Publish(statusEventChannel, string(bys)).Err()
+}
+
+// CommitStatusEvent purges the cached responses of the accounts changed by the task and publishes the event to the status stream subscribers, failures are only logged
+func (r *RedisCache) CommitStatusEvent(task tables.TableTaskInfo, records []tables.TableSmtRecordInfo, status StatusEventStatus, reason string) {
+	if r == nil || r.Red == nil {
+		return
+	}
+	ev := NewStatusEvent(task, records, status, reason)
+	if err := r.PurgeCacheTags(ev.AccountIds()...); err != nil {
+		log.Error("CommitStatusEvent PurgeCacheTags err:", err.Error(), task.TaskId)
+	}
+	if err := r.PublishStatusEvent(ev); err != nil {
+		log.Error("CommitStatusEvent PublishStatusEvent err:", err.Error(), task.TaskId)
+	}
+}
+
+func (r *RedisCache) SubscribeStatusEvent() (*redis.PubSub, error) {
+	if r == nil || r.Red == nil {
+		return nil, fmt.Errorf("redis is nil")
+	}
+	return r.Red.Subscribe(statusEventChannel), nil
+}
@@ -139,9 +139,12 @@ func runServer(ctx *cli.Context) error {
 		Ctx:     ctxServer,
 		Address: config.Cfg.Server.HttpServerAddr,
 		H: &handle.LBHttpHandle{
-			Ctx: ctxServer,
-			RC:  rc,
-			LB:  slb,
+			Ctx:          ctxServer,
+			RC:           rc,
+			LB:           slb,
+			DasCore:      dasCore,
+			DbDao:        dbDao,
+			SmtServerUrl: &smtServer,
 		},
 	}
 	lbHS.Run()
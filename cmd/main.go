@@ -8,6 +8,7 @@ import (
 	"das_sub_account/dao"
 	"das_sub_account/http_server"
 	"das_sub_account/http_server/handle"
+	"das_sub_account/pricefeed"
 	"das_sub_account/task"
 	"das_sub_account/txtool"
 	"das_sub_account/unipay"
@@ -25,6 +26,7 @@ import (
 	"github.com/nervosnetwork/ckb-sdk-go/rpc"
 	"github.com/nervosnetwork/ckb-sdk-go/types"
 	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
 	"github.com/urfave/cli/v2"
 	"os"
 	"sync"
@@ -100,6 +102,9 @@ func runServer(ctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("NewGormDB err: %s", err.Error())
 	}
+	if err := dbDao.SeedSuspensionFromConfig(config.Cfg.SuspendMap); err != nil {
+		return fmt.Errorf("SeedSuspensionFromConfig err: %s", err.Error())
+	}
 	log.Infof("db ok")
 
 	// redis
@@ -351,6 +356,7 @@ func initTimer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Sc
 			Cancel:             cancel,
 			Wg:                 &wgServer,
 			SmtServerUrl:       &smtServer,
+			RC:                 rc,
 		}
 		if err := blockParser.Run(); err != nil {
 			return fmt.Errorf("blockParser.Run() err: %s", err.Error())
@@ -385,6 +391,9 @@ func initTimer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *types.Sc
 	smtTask.RunUpdateSubAccountTaskDistribution()
 	smtTask.RunUpdateSubAccountTask()
 	smtTask.RunRecycleSubAccount()
+	smtTask.RunBulkMintJob()
+	smtTask.RunWebhookDelivery()
+	smtTask.RunPriceQuotePurge()
 	if err := smtTask.RunParentAccountPayment(); err != nil {
 		panic(err)
 	}
@@ -411,7 +420,54 @@ func initApiServer(txBuilderBase *txbuilder.DasTxBuilderBase, serverScript *type
 			ServerScript:  serverScript,
 		},
 	}
+	if config.Cfg.Slb.SvrName == "" {
+		scheduleTask := task.PriceRuleScheduleTask{
+			Ctx:     ctxServer,
+			Wg:      &wgServer,
+			DbDao:   dbDao,
+			DasCore: dasCore,
+			Sender:  hs.H,
+		}
+		scheduleTask.RunPriceRuleSchedule()
+	}
+	if config.Cfg.Das.PriceFeed.Enable {
+		feed, err := initPriceFeed(dbDao)
+		if err != nil {
+			return fmt.Errorf("initPriceFeed err: %s", err.Error())
+		}
+		hs.H.PriceFeed = feed
+	}
 	hs.Run()
 	log.Info("http server ok")
 	return nil
 }
+
+func initPriceFeed(dbDao *dao.DbDao) (*pricefeed.Feed, error) {
+	cfg := config.Cfg.Das.PriceFeed
+	maxDeviation, err := decimal.NewFromString(cfg.MaxDeviation)
+	if err != nil {
+		return nil, fmt.Errorf("max_deviation err: %s", err.Error())
+	}
+	sources := []pricefeed.Source{&pricefeed.DbSource{DbDao: dbDao}}
+	for _, v := range cfg.HttpSources {
+		sources = append(sources, &pricefeed.HttpSource{SourceName: v.Name, Url: v.Url})
+	}
+	staleAfter := time.Duration(cfg.StaleAfter) * time.Second
+	if staleAfter <= 0 {
+		staleAfter = time.Minute * 15
+	}
+	feed := pricefeed.NewFeed(pricefeed.FeedParams{
+		Sources:      sources,
+		StaleAfter:   staleAfter,
+		MaxDeviation: maxDeviation,
+		MinSources:   cfg.MinSources,
+		IgnoreTokens: cfg.IgnoreTokens,
+	})
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	feed.Run(ctxServer, &wgServer, interval)
+	log.Info("price feed ok")
+	return feed, nil
+}
@@ -84,6 +84,7 @@ type CfgServer struct {
 		MaxCreateCount   int    `json:"max_create_count" yaml:"max_create_count"`
 		MaxUpdateCount   int    `json:"max_update_count" yaml:"max_update_count"`
 		MaxRetry         int    `json:"max_retry" yaml:"max_retry"`
+		MaxBulkMintCount int    `json:"max_bulk_mint_count" yaml:"max_bulk_mint_count"`
 		AutoMint         struct {
 			SupportPaymentToken []string          `json:"support_payment_token" yaml:"support_payment_token"`
 			BackgroundColors    map[string]string `json:"background_colors" yaml:"background_colors"`
@@ -92,6 +93,7 @@ type CfgServer struct {
 			PlatformFeeRatio    string            `json:"platform_fee_ratio" yaml:"platform_fee_ratio"`
 			ServiceFeeMin       float64           `json:"service_fee_min" yaml:"service_fee_min"`
 			MinPrice            string            `json:"min_price" yaml:"min_price"`
+			QuoteExpiry         int64             `json:"quote_expiry" yaml:"quote_expiry"` // seconds
 		} `json:"auto_mint" yaml:"auto_mint"`
 		Approval struct {
 			MaxDelayCount uint8 `json:"max_delay_count" yaml:"max_delay_count"`
@@ -102,8 +104,29 @@ type CfgServer struct {
 			PriceMin      float64 `json:"price_min" yaml:"price_min"`
 			PriceMax      float64 `json:"price_max" yaml:"price_max"`
 		} `json:"coupon" yaml:"coupon"`
-		JwtKey string `json:"jwt_key" yaml:"jwt_key"`
-		Dp     struct {
+		JwtKey     string            `json:"jwt_key" yaml:"jwt_key"`
+		JwtKid     string            `json:"jwt_kid" yaml:"jwt_kid"`
+		JwtOldKeys map[string]string `json:"jwt_old_keys" yaml:"jwt_old_keys"` // kid => key, still accepted after rotation
+		// the manager of the parent account delegated to send the scheduled price rules
+		// the service holds the key as a hot key, it can only do what a manager can do, and the owner revokes it by changing the manager
+		// the key is only read by the schedule task for signing, the http handle never gets it
+		PriceRuleSchedule struct {
+			ManagerAddress    string `json:"manager_address" yaml:"manager_address"` // evm address
+			ManagerPrivateKey string `json:"manager_private_key" yaml:"manager_private_key"`
+		} `json:"price_rule_schedule" yaml:"price_rule_schedule"`
+		PriceFeed struct {
+			Enable       bool     `json:"enable" yaml:"enable"`
+			Interval     int64    `json:"interval" yaml:"interval"`       // seconds
+			StaleAfter   int64    `json:"stale_after" yaml:"stale_after"` // seconds
+			MaxDeviation string   `json:"max_deviation" yaml:"max_deviation"`
+			MinSources   int      `json:"min_sources" yaml:"min_sources"`
+			IgnoreTokens []string `json:"ignore_tokens" yaml:"ignore_tokens"` // the tokens of the fixed price
+			HttpSources  []struct {
+				Name string `json:"name" yaml:"name"`
+				Url  string `json:"url" yaml:"url"`
+			} `json:"http_sources" yaml:"http_sources"`
+		} `json:"price_feed" yaml:"price_feed"`
+		Dp struct {
 			TransferWhiteList string `json:"transfer_white_list" yaml:"transfer_white_list"`
 			CapacityWhitelist string `json:"capacity_whitelist" yaml:"capacity_whitelist"`
 			TimeOnline        int64  `json:"time_online" yaml:"time_online"`
@@ -134,12 +157,25 @@ type CfgServer struct {
 			DbNum    int    `json:"db_num" yaml:"db_num"`
 		} `json:"redis" yaml:"redis"`
 	} `json:"cache" yaml:"cache"`
+	// deprecated: parent account id => reason, seeded into t_suspension at startup, use the suspension api instead
 	SuspendMap       map[string]string `json:"suspend_map" yaml:"suspend_map"`
 	UnipayAddressMap map[string]string `json:"unipay_address_map" yaml:"unipay_address_map"`
 	Stripe           struct {
 		PremiumPercentage decimal.Decimal `json:"premium_percentage" yaml:"premium_percentage"`
 		PremiumBase       decimal.Decimal `json:"premium_base" yaml:"premium_base"`
 	} `json:"stripe" yaml:"stripe"`
+	InternalAuth struct {
+		Enable  bool             `json:"enable" yaml:"enable"`
+		MaxSkew int64            `json:"max_skew" yaml:"max_skew"` // seconds
+		Callers []InternalCaller `json:"callers" yaml:"callers"`
+	} `json:"internal_auth" yaml:"internal_auth"`
+}
+
+// InternalCaller the caller of the internal api, allowed to the routes only
+type InternalCaller struct {
+	Name   string   `json:"name" yaml:"name"`
+	Secret string   `json:"secret" yaml:"secret"`
+	Routes []string `json:"routes" yaml:"routes"`
 }
 
 type Server struct {
@@ -1,6 +1,14 @@
 package consts
 
 const (
-	ActionCurrencyUpdate = "Update-Currency"
-	ActionCouponCreate   = "CouponCreate"
+	ActionCurrencyUpdate  = "Update-Currency"
+	ActionCouponCreate    = "CouponCreate"
+	ActionWebhookUpdate   = "Update-Webhook"
+	ActionApiKeyUpdate    = "Update-Api-Key"
+	ActionRoleUpdate      = "Update-Role"
+	ActionRuleSchedule    = "Schedule-Price-Rule"
+	ActionDiscountUpdate  = "Update-Discount"
+	ActionPresaleUpdate   = "Update-Presale"
+	ActionMintLimitUpdate = "Update-Mint-Limit"
+	ActionReferralUpdate  = "Update-Referral"
 )
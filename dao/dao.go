@@ -50,6 +50,21 @@ func NewGormDB(dbMysql, parserMysql config.DbMysql, autoMigrate bool) (*DbDao, e
 			&tables.CouponSetInfo{},
 			&tables.CouponInfo{},
 			&tables.TablePendingInfo{},
+			&tables.TableBulkMintJob{},
+			&tables.TableBulkMintJobItem{},
+			&tables.TableWebhook{},
+			&tables.TableWebhookDelivery{},
+			&tables.TableApiKey{},
+			&tables.TableAccountRole{},
+			&tables.TableAccountRoleLog{},
+			&tables.TableAuditLog{},
+			&tables.TableSuspension{},
+			&tables.TableScheduledRule{},
+			&tables.TablePriceQuote{},
+			&tables.TableRuleVersion{},
+			&tables.TableMintAllowlist{},
+			&tables.TableReferral{},
+			&tables.TableReferralPayment{},
 		); err != nil {
 			return nil, err
 		}
@@ -71,6 +86,22 @@ func (d *DbDao) Transaction(fc func(tx *gorm.DB) error) error {
 	return d.db.Transaction(fc)
 }
 
+func (d *DbDao) Ping() error {
+	db, err := d.db.DB()
+	if err != nil {
+		return err
+	}
+	return db.Ping()
+}
+
+func (d *DbDao) PingParserDb() error {
+	db, err := d.parserDb.DB()
+	if err != nil {
+		return err
+	}
+	return db.Ping()
+}
+
 func NewDbDao(dbMysql, parserMysql config.DbMysql) (*DbDao, error) {
 	db, err := toolib.NewGormDB(dbMysql.Addr, dbMysql.User, dbMysql.Password, dbMysql.DbName, dbMysql.MaxOpenConn, dbMysql.MaxIdleConn)
 	if err != nil {
@@ -220,3 +220,23 @@ func (d *DbDao) GetNeedToRecycleList(timestamp int64, recycleLimit int) (list []
 	err = d.parserDb.Where("expired_at<? AND parent_account_id!=''", timestamp).Limit(recycleLimit).Find(&list).Error
 	return
 }
+
+func (d *DbDao) GetSubAccountNamesByParentAccountId(parentAccountId string, limit int) (list []string, err error) {
+	err = d.parserDb.Model(&tables.TableAccountInfo{}).Where("parent_account_id=?", parentAccountId).
+		Order("id").Limit(limit).Pluck("account", &list).Error
+	return
+}
+
+func (d *DbDao) GetSubAccountNumByOwner(parentAccountId string, chainType common.ChainType, owner string) (num int64, err error) {
+	err = d.parserDb.Model(&tables.TableAccountInfo{}).
+		Where("parent_account_id=? AND owner_chain_type=? AND owner=?", parentAccountId, chainType, owner).
+		Count(&num).Error
+	return
+}
+
+func (d *DbDao) GetSubAccountNamesByOwner(parentAccountId string, chainType common.ChainType, owner string) (list []string, err error) {
+	err = d.parserDb.Model(&tables.TableAccountInfo{}).
+		Where("parent_account_id=? AND owner_chain_type=? AND owner=?", parentAccountId, chainType, owner).
+		Pluck("account", &list).Error
+	return
+}
@@ -0,0 +1,55 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+func (d *DbDao) GetAccountRoleList(parentAccountId string) (list []tables.TableAccountRole, err error) {
+	err = d.db.Where("parent_account_id=?", parentAccountId).Order("id").Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetAccountRoleListByAddress(parentAccountId, address string) (list []tables.TableAccountRole, err error) {
+	err = d.db.Where("parent_account_id=? AND address=?", parentAccountId, strings.ToLower(address)).Find(&list).Error
+	return
+}
+
+// GrantAccountRoles the issuer of the roles already granted is replaced by the current one
+func (d *DbDao) GrantAccountRoles(list []tables.TableAccountRole, logs []tables.TableAccountRoleLog) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.AssignmentColumns([]string{"chain_type", "issuer_chain_type", "issuer"}),
+		}).Create(&list).Error; err != nil {
+			return err
+		}
+		return tx.Create(&logs).Error
+	})
+}
+
+func (d *DbDao) RevokeAccountRoles(parentAccountId, address string, roles []tables.AccountRole, logs []tables.TableAccountRoleLog) error {
+	if len(roles) == 0 {
+		return nil
+	}
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("parent_account_id=? AND address=? AND role IN(?)", parentAccountId, strings.ToLower(address), roles).
+			Delete(&tables.TableAccountRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&logs).Error
+	})
+}
+
+func (d *DbDao) GetAccountRoleLogList(parentAccountId string, limit, offset int) (list []tables.TableAccountRoleLog, total int64, err error) {
+	db := d.db.Model(&tables.TableAccountRoleLog{}).Where("parent_account_id=?", parentAccountId)
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
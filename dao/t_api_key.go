@@ -0,0 +1,44 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"gorm.io/gorm"
+)
+
+func (d *DbDao) GetApiKey(keyId string) (apiKey tables.TableApiKey, err error) {
+	err = d.db.Where("key_id=?", keyId).First(&apiKey).Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+	}
+	return
+}
+
+func (d *DbDao) GetApiKeyListByParentAccountId(parentAccountId string) (list []tables.TableApiKey, err error) {
+	err = d.db.Where("parent_account_id=?", parentAccountId).Order("id DESC").Find(&list).Error
+	return
+}
+
+func (d *DbDao) CountApiKey(parentAccountId string) (count int64, err error) {
+	err = d.db.Model(&tables.TableApiKey{}).
+		Where("parent_account_id=? AND status=?", parentAccountId, tables.ApiKeyStatusNormal).Count(&count).Error
+	return
+}
+
+func (d *DbDao) CreateApiKey(apiKey *tables.TableApiKey) error {
+	return d.db.Create(apiKey).Error
+}
+
+func (d *DbDao) UpdateApiKeyToRevoked(keyId string) error {
+	return d.db.Model(&tables.TableApiKey{}).Where("key_id=?", keyId).
+		Updates(map[string]interface{}{
+			"status": tables.ApiKeyStatusRevoked,
+		}).Error
+}
+
+func (d *DbDao) UpdateApiKeyLastUsed(keyId, ip string, timestamp int64) error {
+	return d.db.Model(&tables.TableApiKey{}).Where("key_id=?", keyId).
+		Updates(map[string]interface{}{
+			"last_used_at": timestamp,
+			"last_used_ip": ip,
+		}).Error
+}
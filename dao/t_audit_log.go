@@ -0,0 +1,39 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"time"
+)
+
+type AuditLogFilter struct {
+	ParentAccountId string
+	Action          tables.AuditAction
+	Actor           string
+	BeginAt         time.Time
+	EndAt           time.Time
+}
+
+func (d *DbDao) CreateAuditLog(info *tables.TableAuditLog) error {
+	return d.db.Create(info).Error
+}
+
+func (d *DbDao) GetAuditLogList(filter AuditLogFilter, limit, offset int) (list []tables.TableAuditLog, total int64, err error) {
+	db := d.db.Model(&tables.TableAuditLog{}).Where("parent_account_id=?", filter.ParentAccountId)
+	if filter.Action != "" {
+		db = db.Where("action=?", filter.Action)
+	}
+	if filter.Actor != "" {
+		db = db.Where("actor=?", filter.Actor)
+	}
+	if !filter.BeginAt.IsZero() {
+		db = db.Where("created_at>=?", filter.BeginAt)
+	}
+	if !filter.EndAt.IsZero() {
+		db = db.Where("created_at<?", filter.EndAt)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
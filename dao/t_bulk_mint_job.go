@@ -0,0 +1,135 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"fmt"
+	"gorm.io/gorm"
+)
+
+func (d *DbDao) CreateBulkMintJob(job *tables.TableBulkMintJob, items []tables.TableBulkMintJobItem) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		if err := tx.CreateInBatches(&items, 500).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (d *DbDao) GetBulkMintJob(jobId string) (job tables.TableBulkMintJob, err error) {
+	err = d.db.Where("job_id=?", jobId).First(&job).Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+	}
+	return
+}
+
+func (d *DbDao) GetBulkMintJobItems(jobId string, limit, offset int) (list []tables.TableBulkMintJobItem, total int64, err error) {
+	db := d.db.Model(&tables.TableBulkMintJobItem{}).Where("job_id=?", jobId)
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("row_index").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetBulkMintJobQueuedItems(jobId string, limit int) (list []tables.TableBulkMintJobItem, err error) {
+	err = d.db.Where("job_id=? AND status=?", jobId, tables.BulkMintItemStatusQueued).
+		Order("row_index").Limit(limit).Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetBulkMintJobItemsByStatus(status tables.BulkMintItemStatus, limit int) (list []tables.TableBulkMintJobItem, err error) {
+	err = d.db.Where("status=?", status).Order("id").Limit(limit).Find(&list).Error
+	return
+}
+
+func (d *DbDao) CountBulkMintJobItems(jobId string) (statusCount map[tables.BulkMintItemStatus]int64, err error) {
+	var list []struct {
+		Status tables.BulkMintItemStatus
+		Num    int64
+	}
+	err = d.db.Model(&tables.TableBulkMintJobItem{}).Select("status, count(*) AS num").
+		Where("job_id=?", jobId).Group("status").Find(&list).Error
+	statusCount = make(map[tables.BulkMintItemStatus]int64)
+	for _, v := range list {
+		statusCount[v.Status] = v.Num
+	}
+	return
+}
+
+func (d *DbDao) CreateMinSignInfoWithBulkMintJob(mintSignInfo tables.TableMintSignInfo, list []tables.TableSmtRecordInfo, jobId string, itemIds []uint64) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&mintSignInfo).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&list).Error; err != nil {
+			return err
+		}
+		// the items signed by another round must not be minted twice
+		res := tx.Model(tables.TableBulkMintJobItem{}).
+			Where("job_id=? AND id IN(?) AND status=?", jobId, itemIds, tables.BulkMintItemStatusQueued).
+			Updates(map[string]interface{}{
+				"status":       tables.BulkMintItemStatusSigned,
+				"mint_sign_id": mintSignInfo.MintSignId,
+			})
+		if res.Error != nil {
+			return res.Error
+		} else if res.RowsAffected != int64(len(itemIds)) {
+			return fmt.Errorf("bulk mint job items changed: %d/%d", res.RowsAffected, len(itemIds))
+		}
+		if err := tx.Model(tables.TableBulkMintJob{}).
+			Where("job_id=? AND status=?", jobId, tables.BulkMintJobStatusQueued).
+			Updates(map[string]interface{}{
+				"status": tables.BulkMintJobStatusMinting,
+			}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (d *DbDao) UpdateBulkMintJobItemStatus(ids []uint64, oldStatus, status tables.BulkMintItemStatus, message string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return d.db.Model(tables.TableBulkMintJobItem{}).
+		Where("id IN(?) AND status=?", ids, oldStatus).
+		Updates(map[string]interface{}{
+			"status":  status,
+			"message": message,
+		}).Error
+}
+
+func (d *DbDao) UpdateBulkMintJobToComplete(jobId string) error {
+	return d.db.Model(tables.TableBulkMintJob{}).
+		Where("job_id=? AND NOT EXISTS(?)", jobId,
+			d.db.Model(tables.TableBulkMintJobItem{}).Select("id").
+				Where("job_id=? AND status IN(?)", jobId,
+					[]tables.BulkMintItemStatus{tables.BulkMintItemStatusQueued, tables.BulkMintItemStatusSigned})).
+		Updates(map[string]interface{}{
+			"status": tables.BulkMintJobStatusComplete,
+		}).Error
+}
+
+func (d *DbDao) GetSmtRecordListByMintSignIds(mintSignIds []string) (list []tables.TableSmtRecordInfo, err error) {
+	if len(mintSignIds) == 0 {
+		return
+	}
+	err = d.db.Where("mint_sign_id IN(?) AND record_type IN(?)", mintSignIds,
+		[]tables.RecordType{tables.RecordTypeDefault, tables.RecordTypeClosed}).Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetTaskListByTaskIds(taskIds []string) (list []tables.TableTaskInfo, err error) {
+	if len(taskIds) == 0 {
+		return
+	}
+	err = d.db.Where("task_id IN(?)", taskIds).Find(&list).Error
+	return
+}
@@ -222,6 +222,13 @@ func (d *DbDao) GetCouponAccount() (num int64, err error) {
 	return
 }
 
+// GetRedeemedCoupon the codes used before used_num is added count once
+func (d *DbDao) GetRedeemedCoupon(cid string) (num int64, err error) {
+	err = d.db.Model(&tables.CouponInfo{}).Select("IFNULL(SUM(IF(used_num>0, used_num, status)), 0)").
+		Where("cid=?", cid).Scan(&num).Error
+	return
+}
+
 func (d *DbDao) GetUsedCoupon(cid string) (num int64, err error) {
 	err = d.db.Model(&tables.CouponInfo{}).Where("cid=? and status=?", cid, tables.CouponStatusUsed).Count(&num).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -0,0 +1,46 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+func (d *DbDao) GetMintAllowlist(parentAccountId, address string) (info tables.TableMintAllowlist, err error) {
+	err = d.db.Where("parent_account_id=? AND address=?", parentAccountId, strings.ToLower(address)).Find(&info).Error
+	return
+}
+
+func (d *DbDao) GetMintAllowlistNum(parentAccountId string) (num int64, err error) {
+	err = d.db.Model(&tables.TableMintAllowlist{}).Where("parent_account_id=?", parentAccountId).Count(&num).Error
+	return
+}
+
+// CreateUserConfigWithPresaleConfig the allowlist of the parent account is replaced by the list
+func (d *DbDao) CreateUserConfigWithPresaleConfig(info tables.UserConfig, presaleConfig tables.PresaleConfig, list []tables.TableMintAllowlist) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Insert{
+			Modifier: "IGNORE",
+		}).Create(&info).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&tables.UserConfig{}).
+			Where("account_id=?", info.AccountId).
+			Updates(map[string]interface{}{
+				"presale_config": &presaleConfig,
+			}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("parent_account_id=?", info.AccountId).
+			Delete(&tables.TableMintAllowlist{}).Error; err != nil {
+			return err
+		}
+		if len(list) > 0 {
+			if err := tx.CreateInBatches(list, 500).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -183,6 +183,16 @@ func (d *DbDao) GetMintOrderInProgressByAccountId(accountId string, actionType t
 	return
 }
 
+func (d *DbDao) GetMintOrderInProgressByAccountIds(accountIds []string, actionType tables.ActionType) (list []tables.OrderInfo, err error) {
+	if len(accountIds) == 0 {
+		return
+	}
+	timestamp := tables.GetEfficientOrderTimestamp()
+	err = d.db.Where("account_id IN(?) AND timestamp>=? AND action_type=? AND pay_status=?",
+		accountIds, timestamp, actionType, tables.PayStatusPaid).Find(&list).Error
+	return
+}
+
 func (d *DbDao) CreateOrderInfo(info, oldOrder tables.OrderInfo, paymentInfo tables.PaymentInfo, setInfo tables.CouponSetInfo) error {
 	return d.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&info).Error; err != nil {
@@ -205,7 +215,8 @@ func (d *DbDao) CreateOrderInfo(info, oldOrder tables.OrderInfo, paymentInfo tab
 	})
 }
 
-func (d *DbDao) CreateOrderInfoWithCoupon(info tables.OrderInfo, paymentInfo tables.PaymentInfo, couponInfo tables.CouponInfo) error {
+// CreateOrderInfoWithCoupon the code is used up after maxRedemption orders
+func (d *DbDao) CreateOrderInfoWithCoupon(info tables.OrderInfo, paymentInfo tables.PaymentInfo, couponInfo tables.CouponInfo, maxRedemption int64) error {
 	return d.db.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Create(&info).Error; err != nil {
 			return err
@@ -216,8 +227,17 @@ func (d *DbDao) CreateOrderInfoWithCoupon(info tables.OrderInfo, paymentInfo tab
 			}
 		}
 		if couponInfo.Id > 0 {
-			couponInfo.Status = tables.CouponStatusUsed
-			if err := tx.Where("status = ?", tables.CouponStatusNormal).Save(&couponInfo).Error; err != nil {
+			tmpTx := tx.Model(&tables.CouponInfo{}).
+				Where("id=? AND status=? AND used_num<?", couponInfo.Id, tables.CouponStatusNormal, maxRedemption).
+				Update("used_num", gorm.Expr("used_num+1"))
+			if tmpTx.Error != nil {
+				return tmpTx.Error
+			} else if tmpTx.RowsAffected == 0 {
+				return errors.New("coupon code has been used")
+			}
+			if err := tx.Model(&tables.CouponInfo{}).
+				Where("id=? AND used_num>=?", couponInfo.Id, maxRedemption).
+				Update("status", tables.CouponStatusUsed).Error; err != nil {
 				return err
 			}
 		}
@@ -272,10 +292,10 @@ func (d *DbDao) GetOrderAmount(accountId string, paid bool) (result map[string]d
 					if v.USDAmount.GreaterThan(couponMinPrice) {
 						amount = amount.Mul(feeRate)
 					} else {
-						// Greater than 0.99$, sub 0.99$ fee
-						subFee := v.USDAmount.Sub(minPriceFee)
+						// Greater than 0.99$, sub 0.99$ fee of every year
+						subFee := v.GetUsdAmountAfterMinFee(minPriceFee)
 						if subFee.GreaterThan(decimal.Zero) {
-							amount = subFee.Mul(decimal.NewFromInt(int64(v.Years))).Mul(decimal.New(1, token.Decimals)).DivRound(token.Price, token.Decimals)
+							amount = subFee.Mul(decimal.New(1, token.Decimals)).DivRound(token.Price, token.Decimals)
 						} else if subFee.Equal(decimal.Zero) {
 							// equal 0.99$, profit is 0
 							amount = decimal.Zero
@@ -306,11 +326,20 @@ func (d *DbDao) GetOrderAmount(accountId string, paid bool) (result map[string]d
 				if err != nil {
 					return nil, err
 				}
-				if v.USDAmount.GreaterThan(couponSetInfo.Price) {
-					amount = v.USDAmount.Sub(couponSetInfo.Price).Mul(decimal.New(1, token.Decimals)).DivRound(token.Price, token.Decimals)
-					amount = amount.Mul(feeRate)
+				if actualUsdPrice := couponSetInfo.GetActualPrice(v.USDAmount, v.Years); actualUsdPrice.GreaterThan(decimal.Zero) {
+					amount = actualUsdPrice.Mul(decimal.New(1, token.Decimals)).DivRound(token.Price, token.Decimals)
+					// the min fee of every year is charged from the price after the coupon
+					fee := amount.Sub(amount.Mul(feeRate))
+					if fee.LessThan(minTokenFee) {
+						fee = decimal.Min(minTokenFee, amount)
+					}
+					amount = amount.Sub(fee)
 				}
 			}
+			// the share of the referrer
+			if v.ReferralCode != "" {
+				amount = amount.Sub(amount.Mul(v.ReferralShare).Floor())
+			}
 		}
 		result[v.TokenId] = result[v.TokenId].Add(amount)
 	}
@@ -408,6 +437,15 @@ func (d *DbDao) GetOrderByCoupon(coupon string) (order tables.OrderInfo, err err
 	return
 }
 
+// GetCouponOrderNumByPayAddress the code is redeemed when the order is created
+func (d *DbDao) GetCouponOrderNumByPayAddress(coupon, payAddress string) (num int64, err error) {
+	err = d.db.Model(&tables.OrderInfo{}).
+		Where("coupon_code=? AND pay_address=? AND action_type IN(?)",
+			coupon, payAddress, []tables.ActionType{tables.ActionTypeMint, tables.ActionTypeRenew}).
+		Count(&num).Error
+	return
+}
+
 func (d *DbDao) GetOrderAmountByAccIdAndTokenId(accountId string, tokenId tables.TokenId) (amount decimal.Decimal, err error) {
 	order := &tables.OrderInfo{}
 	err = d.db.Model(order).Select("sum(amount) as amount").
@@ -419,3 +457,15 @@ func (d *DbDao) GetOrderAmountByAccIdAndTokenId(accountId string, tokenId tables
 	amount = order.Amount
 	return
 }
+
+// GetMintOrderAccountsByPayAddress the sub-accounts of the paid mint orders since paidSince not failed or closed,
+// and of the unpaid orders since unpaidSince
+func (d *DbDao) GetMintOrderAccountsByPayAddress(parentAccountId, payAddress string, paidSince, unpaidSince int64) (list []string, err error) {
+	err = d.db.Model(&tables.OrderInfo{}).
+		Where("parent_account_id=? AND pay_address=? AND action_type=?", parentAccountId, payAddress, tables.ActionTypeMint).
+		Where("(pay_status=? AND order_status IN(?) AND timestamp>=?) OR (pay_status=? AND order_status=? AND timestamp>=?)",
+			tables.PayStatusPaid, []tables.OrderStatus{tables.OrderStatusDefault, tables.OrderStatusSuccess}, paidSince,
+			tables.PayStatusUnpaid, tables.OrderStatusDefault, unpaidSince).
+		Distinct("account").Pluck("account", &list).Error
+	return
+}
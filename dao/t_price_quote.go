@@ -0,0 +1,21 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+)
+
+func (d *DbDao) CreatePriceQuote(info *tables.TablePriceQuote) error {
+	return d.db.Create(info).Error
+}
+
+func (d *DbDao) GetPriceQuote(quoteId string) (info tables.TablePriceQuote, err error) {
+	err = d.db.Where("quote_id=?", quoteId).Find(&info).Error
+	return
+}
+
+// DeleteExpiredPriceQuote delete at most limit quotes expired before, the quotes of the orders are kept for dispute review
+func (d *DbDao) DeleteExpiredPriceQuote(before int64, limit int) (int64, error) {
+	res := d.db.Where("expired_at<? AND NOT EXISTS(SELECT 1 FROM t_order_info o WHERE o.quote_id=t_price_quote.quote_id)", before).
+		Limit(limit).Delete(&tables.TablePriceQuote{})
+	return res.RowsAffected, res.Error
+}
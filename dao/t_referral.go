@@ -0,0 +1,69 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"github.com/shopspring/decimal"
+	"gorm.io/gorm/clause"
+)
+
+func (d *DbDao) GetReferral(parentAccountId, code string) (info tables.TableReferral, err error) {
+	err = d.db.Where("parent_account_id=? AND code=?", parentAccountId, code).Find(&info).Error
+	return
+}
+
+func (d *DbDao) GetReferralList(parentAccountId string) (list []tables.TableReferral, err error) {
+	err = d.db.Where("parent_account_id=?", parentAccountId).Order("id").Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetReferralListByReferrer(referrerId string) (list []tables.TableReferral, err error) {
+	err = d.db.Where("referrer_id=?", referrerId).Order("id").Find(&list).Error
+	return
+}
+
+// UpsertReferrals the codes already created are updated
+func (d *DbDao) UpsertReferrals(list []tables.TableReferral) error {
+	if len(list) == 0 {
+		return nil
+	}
+	return d.db.Clauses(clause.OnConflict{
+		DoUpdates: clause.AssignmentColumns([]string{"referrer", "referrer_id", "share", "status"}),
+	}).Create(&list).Error
+}
+
+type ReferralOrderStat struct {
+	ParentAccountId string            `json:"parent_account_id"`
+	ReferralCode    string            `json:"referral_code"`
+	ReferrerId      string            `json:"referrer_id"` // empty for the orders created before the referrer was kept on the order
+	ActionType      tables.ActionType `json:"action_type"`
+	Num             int64             `json:"num"`
+	UsdAmount       decimal.Decimal   `json:"usd_amount"`
+	ShareUsdAmount  decimal.Decimal   `json:"share_usd_amount"`
+}
+
+// GetReferralOrderStats the successful orders of the referral codes, grouped by the code, the referrer and the action type
+func (d *DbDao) GetReferralOrderStats(referrals []tables.TableReferral) (list []ReferralOrderStat, err error) {
+	if len(referrals) == 0 {
+		return
+	}
+	var codes [][]interface{}
+	for _, v := range referrals {
+		codes = append(codes, []interface{}{v.ParentAccountId, v.Code})
+	}
+	err = d.db.Model(&tables.OrderInfo{}).
+		Select("parent_account_id, referral_code, referrer_id, action_type, COUNT(*) AS num, SUM(usd_amount) AS usd_amount, SUM(usd_amount*referral_share) AS share_usd_amount").
+		Where("(parent_account_id, referral_code) IN ? AND pay_status=? AND order_status=?", codes, tables.PayStatusPaid, tables.OrderStatusSuccess).
+		Group("parent_account_id, referral_code, referrer_id, action_type").
+		Scan(&list).Error
+	return
+}
+
+func (d *DbDao) GetReferralPaymentList(parentAccountId string) (list []tables.TableReferralPayment, err error) {
+	err = d.db.Where("parent_account_id=?", parentAccountId).Order("id").Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetReferralPaymentListByReferrer(referrerId string) (list []tables.TableReferralPayment, err error) {
+	err = d.db.Where("referrer_id=?", referrerId).Order("id DESC").Find(&list).Error
+	return
+}
@@ -0,0 +1,31 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"gorm.io/gorm/clause"
+)
+
+func (d *DbDao) CreateRuleVersion(info *tables.TableRuleVersion) error {
+	return d.db.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(info).Error
+}
+
+func (d *DbDao) GetRuleVersion(id uint64) (info tables.TableRuleVersion, err error) {
+	err = d.db.Where("id=?", id).Find(&info).Error
+	return
+}
+
+func (d *DbDao) GetLatestRuleVersion(parentAccountId string, ruleType tables.RuleType) (info tables.TableRuleVersion, err error) {
+	err = d.db.Where("parent_account_id=? AND rule_type=?", parentAccountId, ruleType).
+		Order("block_number DESC,id DESC").Limit(1).Find(&info).Error
+	return
+}
+
+func (d *DbDao) GetRuleVersionList(parentAccountId string, ruleType tables.RuleType, limit, offset int) (list []tables.TableRuleVersion, total int64, err error) {
+	db := d.db.Model(&tables.TableRuleVersion{}).Where("parent_account_id=? AND rule_type=?", parentAccountId, ruleType)
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Select("id,parent_account_id,account,rule_type,tx_hash,block_number,block_timestamp,rule_hash,rule_num,created_at,updated_at").
+		Order("block_number DESC,id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
@@ -0,0 +1,64 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"time"
+)
+
+func (d *DbDao) CreateScheduledRule(info *tables.TableScheduledRule) error {
+	return d.db.Create(info).Error
+}
+
+func (d *DbDao) GetScheduledRule(id uint64) (info tables.TableScheduledRule, err error) {
+	err = d.db.Where("id=?", id).Find(&info).Error
+	return
+}
+
+func (d *DbDao) GetPendingScheduledRuleList(parentAccountId string) (list []tables.TableScheduledRule, err error) {
+	err = d.db.Where("parent_account_id=? AND status=?", parentAccountId, tables.ScheduledRuleStatusPending).
+		Order("activate_at,id").Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetScheduledRuleList(parentAccountId string, limit, offset int) (list []tables.TableScheduledRule, total int64, err error) {
+	db := d.db.Model(&tables.TableScheduledRule{}).Where("parent_account_id=?", parentAccountId)
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetScheduledRuleListToActivate(limit int) (list []tables.TableScheduledRule, err error) {
+	err = d.db.Where("status=? AND activate_at<=?", tables.ScheduledRuleStatusPending, time.Now().UnixMilli()).
+		Order("activate_at,id").Limit(limit).Find(&list).Error
+	return
+}
+
+// UpdateScheduledRuleStatus only the pending one can be updated, ok is false if it's not pending any more
+func (d *DbDao) UpdateScheduledRuleStatus(id uint64, status tables.ScheduledRuleStatus, txHash, reason string) (ok bool, err error) {
+	res := d.db.Model(&tables.TableScheduledRule{}).
+		Where("id=? AND status=?", id, tables.ScheduledRuleStatusPending).
+		Updates(map[string]interface{}{
+			"status":  status,
+			"tx_hash": txHash,
+			"reason":  reason,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (d *DbDao) GetConfirmingScheduledRuleList() (list []tables.TableScheduledRule, err error) {
+	err = d.db.Where("status=?", tables.ScheduledRuleStatusConfirming).Order("id").Find(&list).Error
+	return
+}
+
+// UpdateConfirmingScheduledRuleStatus only the confirming one can be updated after the tx is committed or rejected
+func (d *DbDao) UpdateConfirmingScheduledRuleStatus(id uint64, status tables.ScheduledRuleStatus, reason string) (ok bool, err error) {
+	res := d.db.Model(&tables.TableScheduledRule{}).
+		Where("id=? AND status=?", id, tables.ScheduledRuleStatusConfirming).
+		Updates(map[string]interface{}{
+			"status": status,
+			"reason": reason,
+		})
+	return res.RowsAffected > 0, res.Error
+}
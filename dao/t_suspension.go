@@ -0,0 +1,93 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"fmt"
+	"time"
+)
+
+// GetActiveSuspension the suspension in effect covering any of the scopes, scope all covers every scope
+func (d *DbDao) GetActiveSuspension(parentAccountId string, scopes ...tables.SuspensionScope) (info tables.TableSuspension, err error) {
+	now := time.Now().UnixMilli()
+	scopes = append(scopes, tables.SuspensionScopeAll)
+	err = d.db.Where("parent_account_id=? AND status=? AND scope IN(?) AND start_at<=? AND (end_at=0 OR end_at>?)",
+		parentAccountId, tables.SuspensionStatusNormal, scopes, now, now).
+		Order("id DESC").Limit(1).Find(&info).Error
+	return
+}
+
+func (d *DbDao) GetSuspension(id uint64) (info tables.TableSuspension, err error) {
+	err = d.db.Where("id=?", id).Find(&info).Error
+	return
+}
+
+func (d *DbDao) CreateSuspension(info *tables.TableSuspension) error {
+	return d.db.Create(info).Error
+}
+
+func (d *DbDao) LiftSuspension(id uint64, operator string) error {
+	return d.db.Model(&tables.TableSuspension{}).
+		Where("id=? AND status=?", id, tables.SuspensionStatusNormal).
+		Updates(map[string]interface{}{
+			"status":    tables.SuspensionStatusLifted,
+			"lifted_by": operator,
+		}).Error
+}
+
+func (d *DbDao) GetSuspensionList(parentAccountId string, onlyActive bool, limit, offset int) (list []tables.TableSuspension, total int64, err error) {
+	db := d.db.Model(&tables.TableSuspension{})
+	if parentAccountId != "" {
+		db = db.Where("parent_account_id=?", parentAccountId)
+	}
+	if onlyActive {
+		now := time.Now().UnixMilli()
+		db = db.Where("status=? AND (end_at=0 OR end_at>?)", tables.SuspensionStatusNormal, now)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
+
+// SeedSuspensionFromConfig the parent accounts of the deprecated suspend_map are suspended with scope all once,
+// the parent account seeded before is skipped even if it was lifted, or there is a suspension in effect already
+func (d *DbDao) SeedSuspensionFromConfig(suspendMap map[string]string) error {
+	for parentAccountId, reason := range suspendMap {
+		if parentAccountId == "" {
+			continue
+		}
+		var seeded int64
+		if err := d.db.Model(&tables.TableSuspension{}).
+			Where("parent_account_id=? AND operator=?", parentAccountId, tables.SuspensionOperatorConfig).
+			Count(&seeded).Error; err != nil {
+			return fmt.Errorf("count seeded suspension err: %s", err.Error())
+		} else if seeded > 0 {
+			continue
+		}
+		suspension, err := d.GetActiveSuspension(parentAccountId)
+		if err != nil {
+			return fmt.Errorf("GetActiveSuspension err: %s", err.Error())
+		} else if suspension.Id > 0 {
+			continue
+		}
+		acc, err := d.GetAccountInfoByAccountId(parentAccountId)
+		if err != nil {
+			return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+		}
+		if reason == "" {
+			reason = "suspended by config"
+		}
+		if err := d.CreateSuspension(&tables.TableSuspension{
+			ParentAccountId: parentAccountId,
+			Account:         acc.Account,
+			Scope:           tables.SuspensionScopeAll,
+			Reason:          reason,
+			StartAt:         time.Now().UnixMilli(),
+			Operator:        tables.SuspensionOperatorConfig,
+		}); err != nil {
+			return fmt.Errorf("CreateSuspension err: %s", err.Error())
+		}
+	}
+	return nil
+}
@@ -77,3 +77,85 @@ func (d *DbDao) GetUserPaymentConfig(accountId string) (paymentConfig tables.Pay
 	}
 	return
 }
+
+func (d *DbDao) CreateUserConfigWithDiscountConfig(info tables.UserConfig, discountConfig tables.DiscountConfig) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Insert{
+			Modifier: "IGNORE",
+		}).Create(&info).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&tables.UserConfig{}).
+			Where("account_id=?", info.AccountId).
+			Updates(map[string]interface{}{
+				"discount_config": &discountConfig,
+			}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (d *DbDao) GetUserDiscountConfig(accountId string) (discountConfig tables.DiscountConfig, err error) {
+	discountConfig.Tiers = make([]tables.DiscountTier, 0)
+
+	userCfg := &tables.UserConfig{}
+	err = d.db.Where("account_id=?", accountId).First(userCfg).Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+		return
+	}
+	if userCfg.DiscountConfig != nil && userCfg.DiscountConfig.Tiers != nil {
+		discountConfig = *userCfg.DiscountConfig
+	}
+	return
+}
+
+func (d *DbDao) GetUserPresaleConfig(accountId string) (presaleConfig tables.PresaleConfig, err error) {
+	presaleConfig.Holders = make([]tables.PresaleHolder, 0)
+
+	userCfg := &tables.UserConfig{}
+	err = d.db.Where("account_id=?", accountId).First(userCfg).Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+		return
+	}
+	if userCfg.PresaleConfig != nil {
+		presaleConfig = *userCfg.PresaleConfig
+	}
+	if presaleConfig.Holders == nil {
+		presaleConfig.Holders = make([]tables.PresaleHolder, 0)
+	}
+	return
+}
+
+func (d *DbDao) CreateUserConfigWithMintLimit(info tables.UserConfig, mintLimit tables.MintLimit) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Insert{
+			Modifier: "IGNORE",
+		}).Create(&info).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&tables.UserConfig{}).
+			Where("account_id=?", info.AccountId).
+			Updates(map[string]interface{}{
+				"mint_limit": &mintLimit,
+			}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+func (d *DbDao) GetUserMintLimit(accountId string) (mintLimit tables.MintLimit, err error) {
+	userCfg := &tables.UserConfig{}
+	err = d.db.Where("account_id=?", accountId).First(userCfg).Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+		return
+	}
+	if userCfg.MintLimit != nil {
+		mintLimit = *userCfg.MintLimit
+	}
+	return
+}
@@ -0,0 +1,134 @@
+package dao
+
+import (
+	"das_sub_account/tables"
+	"fmt"
+	"gorm.io/gorm"
+	"time"
+)
+
+func (d *DbDao) GetWebhook(webhookId string) (webhook tables.TableWebhook, err error) {
+	err = d.db.Where("webhook_id=?", webhookId).First(&webhook).Error
+	if err == gorm.ErrRecordNotFound {
+		err = nil
+	}
+	return
+}
+
+func (d *DbDao) GetWebhookListByParentAccountId(parentAccountId string) (list []tables.TableWebhook, err error) {
+	err = d.db.Where("parent_account_id=?", parentAccountId).Order("id").Find(&list).Error
+	return
+}
+
+func (d *DbDao) GetWebhookListByWebhookIds(webhookIds []string) (list []tables.TableWebhook, err error) {
+	if len(webhookIds) == 0 {
+		return
+	}
+	err = d.db.Where("webhook_id IN(?)", webhookIds).Find(&list).Error
+	return
+}
+
+func (d *DbDao) CountWebhook(parentAccountId string) (count int64, err error) {
+	err = d.db.Model(&tables.TableWebhook{}).Where("parent_account_id=?", parentAccountId).Count(&count).Error
+	return
+}
+
+func (d *DbDao) CreateWebhook(webhook *tables.TableWebhook) error {
+	return d.db.Create(webhook).Error
+}
+
+func (d *DbDao) UpdateWebhook(webhook tables.TableWebhook) error {
+	return d.db.Model(&tables.TableWebhook{}).Where("webhook_id=?", webhook.WebhookId).
+		Updates(map[string]interface{}{
+			"url":    webhook.Url,
+			"secret": webhook.Secret,
+			"events": webhook.Events,
+			"status": webhook.Status,
+		}).Error
+}
+
+func (d *DbDao) DeleteWebhook(webhookId string) error {
+	return d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("webhook_id=?", webhookId).Delete(&tables.TableWebhook{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&tables.TableWebhookDelivery{}).
+			Where("webhook_id=? AND status=?", webhookId, tables.WebhookDeliveryStatusPending).
+			Updates(map[string]interface{}{
+				"status":  tables.WebhookDeliveryStatusFailed,
+				"err_msg": "webhook deleted",
+			}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// CreateWebhookEvent queue one delivery for every enabled webhook of the parent account which subscribed the event
+func (d *DbDao) CreateWebhookEvent(parentAccountId string, event tables.WebhookEvent, data interface{}) error {
+	if parentAccountId == "" {
+		return nil
+	}
+	var list []tables.TableWebhook
+	if err := d.db.Where("parent_account_id=? AND status=? AND FIND_IN_SET(?,events)",
+		parentAccountId, tables.WebhookStatusEnable, event).Find(&list).Error; err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	var deliveryList []tables.TableWebhookDelivery
+	for _, v := range list {
+		delivery, err := tables.NewWebhookDelivery(v, event, data)
+		if err != nil {
+			return fmt.Errorf("NewWebhookDelivery err: %s", err.Error())
+		}
+		deliveryList = append(deliveryList, delivery)
+	}
+	return d.db.Create(&deliveryList).Error
+}
+
+func (d *DbDao) CreateWebhookDelivery(delivery *tables.TableWebhookDelivery) error {
+	return d.db.Create(delivery).Error
+}
+
+func (d *DbDao) GetWebhookDeliveryListToSend(limit int) (list []tables.TableWebhookDelivery, err error) {
+	err = d.db.Where("status=? AND next_time<=?", tables.WebhookDeliveryStatusPending, time.Now().UnixMilli()).
+		Order("next_time").Limit(limit).Find(&list).Error
+	return
+}
+
+// ClaimWebhookDelivery push next_time forward so that other timers skip the delivery while it is being sent,
+// a delivery claimed by a crashed process is picked up again after the lease
+func (d *DbDao) ClaimWebhookDelivery(id uint64, nextTime, lease int64) (bool, error) {
+	res := d.db.Model(&tables.TableWebhookDelivery{}).
+		Where("id=? AND status=? AND next_time=?", id, tables.WebhookDeliveryStatusPending, nextTime).
+		Updates(map[string]interface{}{
+			"next_time": time.Now().UnixMilli() + lease,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+func (d *DbDao) UpdateWebhookDeliveryResult(delivery tables.TableWebhookDelivery) error {
+	return d.db.Model(&tables.TableWebhookDelivery{}).
+		Where("id=? AND status=?", delivery.Id, tables.WebhookDeliveryStatusPending).
+		Updates(map[string]interface{}{
+			"status":        delivery.Status,
+			"retry":         delivery.Retry,
+			"next_time":     delivery.NextTime,
+			"response_code": delivery.ResponseCode,
+			"err_msg":       delivery.ErrMsg,
+		}).Error
+}
+
+func (d *DbDao) GetWebhookDeliveryList(parentAccountId, webhookId string, limit, offset int) (list []tables.TableWebhookDelivery, total int64, err error) {
+	db := d.db.Model(&tables.TableWebhookDelivery{}).Where("parent_account_id=?", parentAccountId)
+	if webhookId != "" {
+		db = db.Where("webhook_id=?", webhookId)
+	}
+	if err = db.Count(&total).Error; err != nil {
+		return
+	}
+	err = db.Order("id DESC").Limit(limit).Offset(offset).Find(&list).Error
+	return
+}
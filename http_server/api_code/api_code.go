@@ -80,6 +80,13 @@ const (
 	ApiCodeApprovalAlreadyExist               ApiCode = 40052
 	ApiCodeAccountApprovalNotExist            ApiCode = 40053
 	ApiCodeAccountApprovalProtected           ApiCode = 40054
+	ApiCodeExceededOwnerLimit                 ApiCode = 40070
+	ApiCodeExceededDailyLimit                 ApiCode = 40071
+	ApiCodeExceededShortNameLimit             ApiCode = 40072
+	ApiCodeReferralCodeInvalid                ApiCode = 40073
+	ApiCodeCouponExceededAddressLimit         ApiCode = 40074
+	ApiCodeCouponNotApplicable                ApiCode = 40075
+	ApiCodeRuleVersionNotRollbackable         ApiCode = 40076
 )
 
 const (
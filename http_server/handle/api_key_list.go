@@ -0,0 +1,93 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+type ReqApiKeyList struct {
+	core.ChainTypeAddress
+	Account string `json:"account" binding:"required"`
+}
+
+type RespApiKeyList struct {
+	List []ApiKeyInfo `json:"list"`
+}
+
+type ApiKeyInfo struct {
+	KeyId      string              `json:"key_id"`
+	Name       string              `json:"name"`
+	Scopes     []string            `json:"scopes"`
+	RateLimit  int                 `json:"rate_limit"`
+	ExpiredAt  int64               `json:"expired_at"`
+	Status     tables.ApiKeyStatus `json:"status"`
+	LastUsedAt int64               `json:"last_used_at"`
+	LastUsedIp string              `json:"last_used_ip"`
+	CreatedAt  int64               `json:"created_at"`
+}
+
+func (h *HttpHandle) ApiKeyList(ctx *gin.Context) {
+	var (
+		funcName               = "ApiKeyList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqApiKeyList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, ctx.Request.Context())
+
+	if err = h.doApiKeyList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doApiKeyList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doApiKeyList(ctx context.Context, req *ReqApiKeyList, apiResp *api_code.ApiResp) error {
+	resp := RespApiKeyList{List: make([]ApiKeyInfo, 0)}
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	list, err := h.DbDao.GetApiKeyListByParentAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query api key")
+		return fmt.Errorf("GetApiKeyListByParentAccountId err: %s", err.Error())
+	}
+	acc, err := h.DbDao.GetAccountInfoByAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query account")
+		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	}
+	for _, v := range list {
+		// the keys issued by a former owner are revoked
+		if !v.IsIssuedByOwner(acc) {
+			v.Status = tables.ApiKeyStatusRevoked
+		}
+		resp.List = append(resp.List, ApiKeyInfo{
+			KeyId:      v.KeyId,
+			Name:       v.Name,
+			Scopes:     v.GetScopes(),
+			RateLimit:  v.RateLimit,
+			ExpiredAt:  v.ExpiredAt,
+			Status:     v.Status,
+			LastUsedAt: v.LastUsedAt,
+			LastUsedIp: v.LastUsedIp,
+			CreatedAt:  v.CreatedAt.UnixMilli(),
+		})
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -0,0 +1,315 @@
+package handle
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"das_sub_account/config"
+	"das_sub_account/consts"
+	"das_sub_account/internal"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	maxApiKeyNum       = 10
+	maxApiKeyRateLimit = 600
+)
+
+type ReqApiKeyUpdate struct {
+	core.ChainTypeAddress
+	Account   string   `json:"account" binding:"required"`
+	KeyId     string   `json:"key_id"` // empty: create
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	RateLimit int      `json:"rate_limit"` // requests per minute
+	ExpiredAt int64    `json:"expired_at"` // 0: never expire
+	Revoke    bool     `json:"revoke"`
+	Timestamp int64    `json:"timestamp" binding:"required"`
+}
+
+type RespApiKeyUpdate struct {
+	SignInfoList
+}
+
+func (r *ReqApiKeyUpdate) GetSignInfo() (signKey, signMsg, reqDataStr string) {
+	reqData, _ := json.Marshal(r)
+	reqDataStr = string(reqData)
+	signKey = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%d", reqDataStr, time.Now().UnixNano()))))
+	signMsg = common.DotBitPrefix + hex.EncodeToString(common.Blake2b(reqData))
+	return
+}
+
+func (h *HttpHandle) ApiKeyUpdate(ctx *gin.Context) {
+	var (
+		funcName               = "ApiKeyUpdate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqApiKeyUpdate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doApiKeyUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doApiKeyUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doApiKeyUpdate(ctx context.Context, req *ReqApiKeyUpdate, apiResp *api_code.ApiResp) error {
+	var resp RespApiKeyUpdate
+	resp.List = make([]SignInfo, 0)
+
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+
+	res, err := req.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return err
+	}
+	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+
+	action := consts.ActionApiKeyUpdate
+	if err := h.check(address, req.Account, action, apiResp); err != nil {
+		return err
+	}
+
+	if time.UnixMilli(req.Timestamp).Add(time.Minute * 10).Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params timestamp invalid")
+		return nil
+	}
+	if err := h.checkApiKeyUpdate(req, address, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	//
+	signKey, signMsg, reqDataStr := req.GetSignInfo()
+
+	// cache
+	if err = h.RC.SetSignTxCache(signKey, reqDataStr); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("SetSignTxCache err: %s", err.Error())
+	}
+
+	//
+	signType := res.DasAlgorithmId
+	if signType == common.DasAlgorithmIdEth712 {
+		signType = common.DasAlgorithmIdEth
+	}
+	resp.Action = action
+	resp.SignKey = signKey
+	resp.List = append(resp.List, SignInfo{
+		SignList: []txbuilder.SignData{{
+			SignType: signType,
+			SignMsg:  signMsg,
+		}},
+	})
+	resp.SignList = []txbuilder.SignData{{
+		SignType: signType,
+		SignMsg:  signMsg,
+	}}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// checkApiKeyUpdate only the owner of the parent account can issue or revoke api keys
+func (h *HttpHandle) checkApiKeyUpdate(req *ReqApiKeyUpdate, address string, apiResp *api_code.ApiResp) error {
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	acc, err := h.DbDao.GetAccountInfoByAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
+		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	}
+	if !strings.EqualFold(acc.Owner, address) {
+		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "only the owner can manage api keys")
+		return nil
+	}
+
+	if req.Revoke {
+		if req.KeyId == "" {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "key_id is empty")
+			return nil
+		}
+		apiKey, err := h.DbDao.GetApiKey(req.KeyId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query api key")
+			return fmt.Errorf("GetApiKey err: %s", err.Error())
+		}
+		if apiKey.Id == 0 || apiKey.ParentAccountId != parentAccountId {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "api key not exist")
+			return nil
+		}
+		return nil
+	}
+	if req.KeyId != "" {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "api key can not be modified, revoke it and create a new one")
+		return nil
+	}
+
+	count, err := h.DbDao.CountApiKey(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to count api key")
+		return fmt.Errorf("CountApiKey err: %s", err.Error())
+	}
+	if count >= maxApiKeyNum {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("more than max api key num %d", maxApiKeyNum))
+		return nil
+	}
+	if len(req.Scopes) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "scopes is empty")
+		return nil
+	}
+	for _, v := range req.Scopes {
+		if !tables.IsApiKeyScope(v) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("scope [%s] not supported", v))
+			return nil
+		}
+	}
+	if req.RateLimit < 0 || req.RateLimit > maxApiKeyRateLimit {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("rate_limit must be between 0 and %d", maxApiKeyRateLimit))
+		return nil
+	}
+	if req.ExpiredAt != 0 && req.ExpiredAt < time.Now().UnixMilli() {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "expired_at invalid")
+		return nil
+	}
+	return nil
+}
+
+func (h *HttpHandle) doActionApiKeyUpdate(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp, resp *RespTransactionSend) error {
+	var data ReqApiKeyUpdate
+	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
+		if err == redis.Nil {
+			apiResp.ApiRespErr(api_code.ApiCodeTxExpired, "sign key not exist(tx expired)")
+		} else {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		}
+		return fmt.Errorf("GetSignTxCache err: %s", err.Error())
+	} else if err = json.Unmarshal([]byte(txStr), &data); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Unmarshal err")
+		return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	res, err := data.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	_, signMsg, _ := data.GetSignInfo()
+	address := ""
+	var signType common.DasAlgorithmId
+	var signature string
+	if len(req.List) != 0 {
+		signType = req.List[0].SignList[0].SignType
+		signature = req.List[0].SignList[0].SignMsg
+	} else {
+		signType = req.SignList[0].SignType
+		signature = req.SignList[0].SignMsg
+	}
+	if signType == common.DasAlgorithmIdWebauthn {
+		address = req.SignAddress
+	} else {
+		address = res.AddressHex
+	}
+	verifyRes, _, err := api_code.VerifySignature(signType, signMsg, signature, address)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "VerifySignature err")
+		return fmt.Errorf("VerifySignature err: %s", err.Error())
+	}
+	if !verifyRes {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
+		return nil
+	}
+
+	// the owner may be changed since the sign info was returned
+	issuer := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+	if err := h.checkApiKeyUpdate(&data, issuer, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	defer func() {
+		if apiResp.ErrNo == api_code.ApiCodeSuccess {
+			if !data.Revoke {
+				data.KeyId = resp.KeyId
+			}
+			h.addAuditLogByAddress(ctx, data.Account, address, newAuditInfo(tables.AuditActionApiKeyUpdate, nil, data), "")
+		}
+	}()
+
+	if data.Revoke {
+		if err := h.DbDao.UpdateApiKeyToRevoked(data.KeyId); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to revoke api key")
+			return fmt.Errorf("UpdateApiKeyToRevoked err: %s", err.Error())
+		}
+		return nil
+	}
+
+	key, keyId, err := newApiKey()
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "failed to create api key")
+		return fmt.Errorf("newApiKey err: %s", err.Error())
+	}
+	rateLimit := data.RateLimit
+	if rateLimit == 0 {
+		rateLimit = defaultApiLimit
+	}
+	apiKey := tables.TableApiKey{
+		KeyId:           keyId,
+		KeyHash:         getApiKeyHash(key),
+		ParentAccountId: common.Bytes2Hex(common.GetAccountIdByAccount(data.Account)),
+		Account:         strings.ToLower(data.Account),
+		Name:            data.Name,
+		Scopes:          strings.Join(data.Scopes, ","),
+		RateLimit:       rateLimit,
+		ExpiredAt:       data.ExpiredAt,
+		Status:          tables.ApiKeyStatusNormal,
+		IssuerChainType: res.ChainType,
+		Issuer:          issuer,
+	}
+	if err := h.DbDao.CreateApiKey(&apiKey); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to create api key")
+		return fmt.Errorf("CreateApiKey err: %s", err.Error())
+	}
+	// the key is only returned once, only its hash is stored
+	resp.ApiKey = key
+	resp.KeyId = keyId
+	return nil
+}
+
+func newApiKey() (key, keyId string, err error) {
+	bys := make([]byte, 40)
+	if _, err = rand.Read(bys); err != nil {
+		return
+	}
+	keyId = hex.EncodeToString(bys[:8])
+	key = apiKeyPrefix + keyId + "_" + hex.EncodeToString(bys[8:])
+	return
+}
@@ -0,0 +1,194 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/dao"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AuditInfo the change of a signed action, cached with the sign info until the action is sent
+type AuditInfo struct {
+	Action tables.AuditAction `json:"action"`
+	Before string             `json:"before"`
+	After  string             `json:"after"`
+}
+
+func newAuditInfo(action tables.AuditAction, before, after interface{}) *AuditInfo {
+	info := AuditInfo{Action: action}
+	if before != nil {
+		info.Before = toolib.JsonString(before)
+	}
+	if after != nil {
+		info.After = toolib.JsonString(after)
+	}
+	return &info
+}
+
+// addAuditLog failures are only logged, the action has already been applied
+func (h *HttpHandle) addAuditLog(ctx context.Context, account, actor, role string, info *AuditInfo, txHash string) {
+	if info == nil {
+		return
+	}
+	requestId, _ := ctx.Value("request_id").(string)
+	auditLog := tables.TableAuditLog{
+		ParentAccountId: common.Bytes2Hex(common.GetAccountIdByAccount(account)),
+		Account:         account,
+		Actor:           strings.ToLower(actor),
+		Role:            role,
+		Action:          info.Action,
+		Before:          info.Before,
+		After:           info.After,
+		RequestId:       requestId,
+		TxHash:          txHash,
+	}
+	if err := h.DbDao.CreateAuditLog(&auditLog); err != nil {
+		log.Error(ctx, "CreateAuditLog err:", err.Error(), toolib.JsonString(auditLog))
+	}
+}
+
+// getAuditRole owner, manager or the roles granted to the address
+func (h *HttpHandle) getAuditRole(account, address string) string {
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	acc, err := h.DbDao.GetAccountInfoByAccountId(accountId)
+	if err != nil {
+		log.Error("getAuditRole GetAccountInfoByAccountId err:", err.Error(), account)
+		return ""
+	}
+	if strings.EqualFold(acc.Owner, address) {
+		return tables.AuditRoleOwner
+	} else if strings.EqualFold(acc.Manager, address) {
+		return tables.AuditRoleManager
+	}
+	list, err := h.DbDao.GetAccountRoleListByAddress(accountId, address)
+	if err != nil {
+		log.Error("getAuditRole GetAccountRoleListByAddress err:", err.Error(), account)
+		return ""
+	}
+	var roles []string
+	for _, v := range list {
+		roles = append(roles, string(v.Role))
+	}
+	return strings.Join(roles, ",")
+}
+
+// addAuditLogByAddress the actor signed the action with the address
+func (h *HttpHandle) addAuditLogByAddress(ctx context.Context, account, address string, info *AuditInfo, txHash string) {
+	if info == nil {
+		return
+	}
+	h.addAuditLog(ctx, account, address, h.getAuditRole(account, address), info, txHash)
+}
+
+// addAuditLogByApiKey the actor is the api key
+func (h *HttpHandle) addAuditLogByApiKey(ctx context.Context, account string, apiKey *tables.TableApiKey, info *AuditInfo) {
+	h.addAuditLog(ctx, account, apiKey.KeyId, tables.AuditRoleApiKey, info, "")
+}
+
+// getAuditRules the current rules of the parent account, nil if not set
+func (h *HttpHandle) getAuditRules(account string, dataType common.ActionDataType) witness.SubAccountRuleSlice {
+	var apiResp api_code.ApiResp
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	rulePreserved, rulePrice, err := h.getSubAccountRules(account, parentAccountId, &apiResp)
+	if err != nil {
+		log.Error("getAuditRules err:", err.Error(), account)
+		return nil
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	if dataType == common.ActionDataTypeSubAccountPreservedRules {
+		return rulePreserved.Rules
+	}
+	return rulePrice.Rules
+}
+
+type auditAutoMint struct {
+	Enable bool `json:"enable"`
+}
+
+func (h *HttpHandle) getAuditAutoMint(account string) *auditAutoMint {
+	subAccCell, err := h.DasCore.GetSubAccountCell(common.Bytes2Hex(common.GetAccountIdByAccount(account)))
+	if err != nil {
+		log.Error("getAuditAutoMint GetSubAccountCell err:", err.Error(), account)
+		return nil
+	}
+	detail := witness.ConvertSubAccountCellOutputData(subAccCell.OutputData)
+	return &auditAutoMint{Enable: detail.AutoDistribution == witness.AutoDistributionEnable}
+}
+
+type ReqAuditLogList struct {
+	core.ChainTypeAddress
+	Pagination
+	Account string             `json:"account" binding:"required"`
+	Action  tables.AuditAction `json:"action"`
+	Actor   string             `json:"actor"`
+	BeginAt int64              `json:"begin_at"`
+	EndAt   int64              `json:"end_at"`
+}
+
+type RespAuditLogList struct {
+	Total int64                  `json:"total"`
+	List  []tables.TableAuditLog `json:"list"`
+}
+
+func (h *HttpHandle) AuditLogList(ctx *gin.Context) {
+	var (
+		funcName               = "AuditLogList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqAuditLogList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doAuditLogList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doAuditLogList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doAuditLogList(ctx context.Context, req *ReqAuditLogList, apiResp *api_code.ApiResp) error {
+	var resp RespAuditLogList
+
+	filter := dao.AuditLogFilter{
+		ParentAccountId: common.Bytes2Hex(common.GetAccountIdByAccount(strings.ToLower(req.Account))),
+		Action:          req.Action,
+		Actor:           strings.ToLower(req.Actor),
+	}
+	if req.BeginAt > 0 {
+		filter.BeginAt = time.UnixMilli(req.BeginAt)
+	}
+	if req.EndAt > 0 {
+		filter.EndAt = time.UnixMilli(req.EndAt)
+	}
+	list, total, err := h.DbDao.GetAuditLogList(filter, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query audit log")
+		return fmt.Errorf("GetAuditLogList err: %s", err.Error())
+	}
+	resp.Total = total
+	resp.List = list
+	if resp.List == nil {
+		resp.List = make([]tables.TableAuditLog, 0)
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
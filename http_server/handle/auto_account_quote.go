@@ -0,0 +1,245 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/config"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/molecule"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"math"
+	"net/http"
+	"strings"
+)
+
+const maxQuoteNum = 50
+
+const (
+	QuoteRuleTypePrice     = "price"
+	QuoteRuleTypePreserved = "preserved"
+)
+
+type ReqAutoAccountQuote struct {
+	SubAccountList []string `json:"sub_account_list" binding:"required"`
+}
+
+type RespAutoAccountQuote struct {
+	List []QuoteAccount `json:"list"`
+}
+
+type QuoteAccount struct {
+	SubAccount  string            `json:"sub_account"`
+	Status      CheckStatus       `json:"status"`
+	Message     string            `json:"message"`
+	RuleType    string            `json:"rule_type"`
+	RuleName    string            `json:"rule_name"`
+	Price       decimal.Decimal   `json:"price"`
+	TokenPrices []QuoteTokenPrice `json:"token_prices"`
+}
+
+type QuoteTokenPrice struct {
+	TokenId  tables.TokenId  `json:"token_id"`
+	Symbol   string          `json:"symbol"`
+	Decimals int32           `json:"decimals"`
+	Amount   decimal.Decimal `json:"amount"`
+}
+
+// quoteParent the rules and the payment tokens shared by the sub-accounts of a parent account
+type quoteParent struct {
+	message       string
+	rulePreserved *witness.SubAccountRuleEntity
+	rulePrice     *witness.SubAccountRuleEntity
+	tokens        []*tables.TTokenPriceInfo
+}
+
+func (h *HttpHandle) AutoAccountQuote(ctx *gin.Context) {
+	var (
+		funcName               = "AutoAccountQuote"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqAutoAccountQuote
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doAutoAccountQuote(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doAutoAccountQuote err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doAutoAccountQuote(ctx context.Context, req *ReqAutoAccountQuote, apiResp *api_code.ApiResp) error {
+	var resp RespAutoAccountQuote
+	if len(req.SubAccountList) == 0 || len(req.SubAccountList) > maxQuoteNum {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("the number of sub-accounts must be between 1 and %d", maxQuoteNum))
+		return nil
+	}
+
+	// check the names
+	var accountIds []string
+	var parentIds = make([]string, len(req.SubAccountList))
+	var mapParent = make(map[string]*quoteParent)
+	for i, v := range req.SubAccountList {
+		tmp := QuoteAccount{SubAccount: strings.ToLower(strings.TrimSpace(v)), TokenPrices: make([]QuoteTokenPrice, 0)}
+		var checkResp api_code.ApiResp
+		parentAccountId := h.checkSubAccountName(ctx, &checkResp, tmp.SubAccount)
+		if checkResp.ErrNo != api_code.ApiCodeSuccess {
+			tmp.Status, tmp.Message = CheckStatusFail, checkResp.ErrMsg
+		} else {
+			parentIds[i] = parentAccountId
+			mapParent[parentAccountId] = nil
+			accountIds = append(accountIds, common.Bytes2Hex(common.GetAccountIdByAccount(tmp.SubAccount)))
+		}
+		resp.List = append(resp.List, tmp)
+	}
+
+	// the parent accounts
+	tokens, err := h.DbDao.FindTokens()
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to search token price")
+		return fmt.Errorf("FindTokens err: %s", err.Error())
+	}
+	for k, v := range tokens {
+		if v.Price, err = h.getFeedPrice(k, v.Price); err != nil {
+			log.Warn("getFeedPrice err:", k, err.Error())
+			delete(tokens, k)
+		}
+	}
+	for parentAccountId := range mapParent {
+		parent, err := h.getQuoteParent(ctx, parentAccountId, tokens, apiResp)
+		if err != nil {
+			return err
+		}
+		mapParent[parentAccountId] = parent
+	}
+
+	// check registered and registering
+	mapTaken, err := h.getTakenAccountIds(accountIds)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query sub-account")
+		return err
+	}
+
+	builder, err := h.DasCore.ConfigCellDataBuilderByTypeArgsList(common.ConfigCellTypeArgsSubAccount)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "Failed to get config info")
+		return fmt.Errorf("ConfigCellDataBuilderByTypeArgsList err: %s", err.Error())
+	}
+	newSubAccountPrice, _ := molecule.Bytes2GoU64(builder.ConfigCellSubAccount.NewSubAccountPrice().RawData())
+	minPrice := decimal.NewFromInt(int64(newSubAccountPrice)).DivRound(decimal.NewFromInt(common.UsdRateBase), 2)
+
+	// rules and prices
+	for i := range resp.List {
+		item := &resp.List[i]
+		if parentIds[i] == "" {
+			continue
+		}
+		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(item.SubAccount))
+		switch mapTaken[accountId] {
+		case CheckStatusRegistered:
+			item.Status, item.Message = CheckStatusRegistered, "registered"
+			continue
+		case CheckStatusRegistering:
+			item.Status, item.Message = CheckStatusRegistering, "registering"
+			continue
+		}
+		parent := mapParent[parentIds[i]]
+		if parent.message != "" {
+			item.Status, item.Message = CheckStatusFail, parent.message
+			continue
+		}
+
+		hit, index, err := parent.rulePreserved.Hit(item.SubAccount)
+		if err != nil {
+			item.Status, item.Message = CheckStatusFail, "Failed to match rules"
+			continue
+		} else if hit {
+			item.Status, item.Message = CheckStatusFail, "hit blacklist"
+			item.RuleType, item.RuleName = QuoteRuleTypePreserved, parent.rulePreserved.Rules[index].Name
+			continue
+		}
+		hit, index, err = parent.rulePrice.Hit(item.SubAccount)
+		if err != nil {
+			item.Status, item.Message = CheckStatusFail, "Failed to match rules"
+			continue
+		} else if !hit {
+			item.Status, item.Message = CheckStatusFail, "not set price rules"
+			continue
+		}
+		item.RuleType, item.RuleName = QuoteRuleTypePrice, parent.rulePrice.Rules[index].Name
+		item.Price = decimal.NewFromInt(int64(parent.rulePrice.Rules[index].Price)).Div(decimal.NewFromFloat(math.Pow10(6)))
+		if minPrice.GreaterThan(item.Price) {
+			item.Status, item.Message = CheckStatusFail, "Pricing below minimum"
+			continue
+		}
+		for _, token := range parent.tokens {
+			amount := item.Price.Mul(decimal.New(1, token.Decimals)).Div(token.Price).Ceil()
+			item.TokenPrices = append(item.TokenPrices, QuoteTokenPrice{
+				TokenId:  token.TokenId,
+				Symbol:   token.Symbol,
+				Decimals: token.Decimals,
+				Amount:   RoundAmount(amount, token.TokenId),
+			})
+		}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// getQuoteParent the parent account which can't distribute gets the reason in message
+func (h *HttpHandle) getQuoteParent(ctx context.Context, parentAccountId string, tokens map[string]*tables.TTokenPriceInfo, apiResp *api_code.ApiResp) (*quoteParent, error) {
+	var parent quoteParent
+	var checkResp api_code.ApiResp
+	parentAccount, err := h.checkParentAccount(&checkResp, parentAccountId)
+	if err != nil {
+		*apiResp = checkResp
+		return nil, err
+	} else if checkResp.ErrNo != api_code.ApiCodeSuccess {
+		parent.message = checkResp.ErrMsg
+		return &parent, nil
+	}
+	if _, err := h.checkSwitch(ctx, parentAccountId, tables.ActionTypeMint, &checkResp); err != nil {
+		*apiResp = checkResp
+		return nil, err
+	} else if checkResp.ErrNo != api_code.ApiCodeSuccess {
+		parent.message = checkResp.ErrMsg
+		return &parent, nil
+	}
+	parent.rulePreserved, parent.rulePrice, err = h.getSubAccountRules(parentAccount.Account, parentAccountId, &checkResp)
+	if err != nil {
+		*apiResp = checkResp
+		return nil, err
+	} else if checkResp.ErrNo != api_code.ApiCodeSuccess {
+		parent.message = checkResp.ErrMsg
+		return &parent, nil
+	}
+
+	paymentConfig, err := h.DbDao.GetUserPaymentConfig(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to search payment config")
+		return nil, fmt.Errorf("GetUserPaymentConfig err: %s", err.Error())
+	}
+	for _, v := range config.Cfg.Das.AutoMint.SupportPaymentToken {
+		if cfg, ok := paymentConfig.CfgMap[v]; !ok || !cfg.Enable {
+			continue
+		}
+		if token, ok := tokens[v]; ok && token.Price.GreaterThan(decimal.Zero) {
+			parent.tokens = append(parent.tokens, token)
+		}
+	}
+	return &parent, nil
+}
@@ -27,15 +27,17 @@ type ReqAutoAccountSearch struct {
 }
 
 type RespAutoAccountSearch struct {
-	Price             decimal.Decimal `json:"price"`
-	MaxYear           uint64          `json:"max_year"`
-	Status            AccStatus       `json:"status"`
-	IsSelf            bool            `json:"is_self"`
-	OrderId           string          `json:"order_id"`
-	ExpiredAt         uint64          `json:"expired_at"`
-	PremiumPercentage decimal.Decimal `json:"premium_percentage"`
-	PremiumBase       decimal.Decimal `json:"premium_base"`
-	DefaultRenewRule  bool            `json:"default_renew_rule"`
+	Price             decimal.Decimal       `json:"price"`
+	MaxYear           uint64                `json:"max_year"`
+	Status            AccStatus             `json:"status"`
+	IsSelf            bool                  `json:"is_self"`
+	OrderId           string                `json:"order_id"`
+	ExpiredAt         uint64                `json:"expired_at"`
+	PremiumPercentage decimal.Decimal       `json:"premium_percentage"`
+	PremiumBase       decimal.Decimal       `json:"premium_base"`
+	DefaultRenewRule  bool                  `json:"default_renew_rule"`
+	DiscountTiers     []tables.DiscountTier `json:"discount_tiers"`
+	Quote             *PriceQuote           `json:"quote"`
 }
 
 type AccStatus int
@@ -106,6 +108,11 @@ func (h *HttpHandle) doAutoAccountSearch(ctx context.Context, req *ReqAutoAccoun
 	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
 		return nil
 	}
+	if err := h.checkPresale(parentAccountId, req.SubAccount, req.ActionType, hexAddr, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
 
 	// get max years
 	resp.MaxYear = h.getMaxYears(ctx, parentAccount)
@@ -138,6 +145,25 @@ func (h *HttpHandle) doAutoAccountSearch(ctx context.Context, req *ReqAutoAccoun
 	resp.PremiumPercentage = config.Cfg.Stripe.PremiumPercentage
 	resp.PremiumBase = config.Cfg.Stripe.PremiumBase
 
+	// the order amount is price × years after the discount
+	discountConfig, err := h.DbDao.GetUserDiscountConfig(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to search discount config")
+		return fmt.Errorf("GetUserDiscountConfig err: %s", err.Error())
+	}
+	resp.DiscountTiers = discountConfig.Tiers
+
+	// lock the price for auto/order/create
+	address := ""
+	if hexAddr != nil {
+		address = hexAddr.AddressHex
+	}
+	resp.Quote, err = h.createPriceQuote(parentAccountId, req.SubAccount, address, req.ActionType, resp.Price, resp.DefaultRenewRule)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to create price quote")
+		return err
+	}
+
 	apiResp.ApiRespOK(resp)
 	return nil
 }
@@ -0,0 +1,285 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/molecule"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gin-gonic/gin"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 30
+	// each kind is capped separately, so the numbers don't crowd out the affixes
+	maxSuggestCandidatePerKind = 40
+)
+
+type SuggestKind string
+
+const (
+	SuggestKindSwap   SuggestKind = "swap"
+	SuggestKindNumber SuggestKind = "number"
+	SuggestKindAffix  SuggestKind = "affix"
+)
+
+var (
+	suggestPrefixList = []string{"my", "the", "get", "hey", "im"}
+	suggestSuffixList = []string{"x", "hq", "app", "dao", "pro", "club", "labs", "official"}
+	suggestSwapMap    = map[rune][]rune{
+		'o': {'0'}, '0': {'o'},
+		'i': {'1', 'l'}, 'l': {'1', 'i'}, '1': {'i', 'l'},
+		'e': {'3'}, '3': {'e'},
+		'a': {'4'}, '4': {'a'},
+		's': {'5', 'z'}, '5': {'s'}, 'z': {'s'},
+		't': {'7'}, '7': {'t'},
+		'b': {'8'}, '8': {'b'},
+		'g': {'9'}, '9': {'g'},
+	}
+)
+
+type ReqAutoAccountSuggest struct {
+	SubAccount string `json:"sub_account" binding:"required"`
+	Limit      int    `json:"limit"`
+}
+
+type RespAutoAccountSuggest struct {
+	List []SuggestAccount `json:"list"`
+}
+
+type SuggestAccount struct {
+	SubAccount string          `json:"sub_account"`
+	Kind       SuggestKind     `json:"kind"`
+	Price      decimal.Decimal `json:"price"`
+	score      int
+}
+
+func (h *HttpHandle) AutoAccountSuggest(ctx *gin.Context) {
+	var (
+		funcName               = "AutoAccountSuggest"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqAutoAccountSuggest
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doAutoAccountSuggest(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doAutoAccountSuggest err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doAutoAccountSuggest(ctx context.Context, req *ReqAutoAccountSuggest, apiResp *api_code.ApiResp) error {
+	var resp RespAutoAccountSuggest
+	resp.List = make([]SuggestAccount, 0)
+	req.SubAccount = strings.ToLower(strings.TrimSpace(req.SubAccount))
+	if req.Limit <= 0 {
+		req.Limit = defaultSuggestLimit
+	} else if req.Limit > maxSuggestLimit {
+		req.Limit = maxSuggestLimit
+	}
+
+	parentAccountId := h.checkSubAccountName(ctx, apiResp, req.SubAccount)
+	if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	parentAccount, err := h.checkParentAccount(apiResp, parentAccountId)
+	if err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	if _, err := h.checkSwitch(ctx, parentAccountId, tables.ActionTypeMint, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	rulePreserved, rulePrice, err := h.getSubAccountRules(parentAccount.Account, parentAccountId, apiResp)
+	if err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	builder, err := h.DasCore.ConfigCellDataBuilderByTypeArgsList(common.ConfigCellTypeArgsSubAccount)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "Failed to get config info")
+		return fmt.Errorf("ConfigCellDataBuilderByTypeArgsList err: %s", err.Error())
+	}
+	newSubAccountPrice, _ := molecule.Bytes2GoU64(builder.ConfigCellSubAccount.NewSubAccountPrice().RawData())
+	minPrice := decimal.NewFromInt(int64(newSubAccountPrice)).DivRound(decimal.NewFromInt(common.UsdRateBase), 2)
+
+	// the candidates valid in length and char sets, then priced by the rules of the parent account
+	label := req.SubAccount[:strings.Index(req.SubAccount, ".")]
+	suffix := req.SubAccount[len(label):]
+	var candidates []SuggestAccount
+	var accountIds []string
+	mapKindNum := make(map[SuggestKind]int)
+	for _, v := range genSuggestLabels(label) {
+		if mapKindNum[v.Kind] >= maxSuggestCandidatePerKind {
+			continue
+		}
+		subAccount := v.SubAccount + suffix
+		var checkResp api_code.ApiResp
+		if h.checkSubAccountName(ctx, &checkResp, subAccount); checkResp.ErrNo != api_code.ApiCodeSuccess {
+			continue
+		}
+		if hit, _, err := rulePreserved.Hit(subAccount); err != nil || hit {
+			continue
+		}
+		hit, index, err := rulePrice.Hit(subAccount)
+		if err != nil || !hit {
+			continue
+		}
+		v.Price = decimal.NewFromInt(int64(rulePrice.Rules[index].Price)).Div(decimal.NewFromFloat(math.Pow10(6)))
+		if minPrice.GreaterThan(v.Price) {
+			continue
+		}
+		v.SubAccount = subAccount
+		candidates = append(candidates, v)
+		accountIds = append(accountIds, common.Bytes2Hex(common.GetAccountIdByAccount(subAccount)))
+		mapKindNum[v.Kind]++
+	}
+
+	// exclude the minted, the minting and the paid ones
+	mapTaken, err := h.getTakenAccountIds(accountIds)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query sub-account")
+		return err
+	}
+	for i, v := range candidates {
+		if _, ok := mapTaken[accountIds[i]]; !ok {
+			resp.List = append(resp.List, v)
+		}
+	}
+
+	sort.SliceStable(resp.List, func(i, j int) bool {
+		if resp.List[i].score != resp.List[j].score {
+			return resp.List[i].score < resp.List[j].score
+		}
+		return resp.List[i].Price.LessThan(resp.List[j].Price)
+	})
+	if len(resp.List) > req.Limit {
+		resp.List = resp.List[:req.Limit]
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) getSubAccountRules(parentAcc, parentAccountId string, apiResp *api_code.ApiResp) (rulePreserved, rulePrice *witness.SubAccountRuleEntity, e error) {
+	ruleConfig, err := h.DbDao.GetRuleConfigByAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to search rule config")
+		e = fmt.Errorf("GetRuleConfigByAccountId err: %s", err.Error())
+		return
+	} else if ruleConfig.TxHash == "" {
+		apiResp.ApiRespErr(api_code.ApiCodeNoTSetRules, "not set price rules")
+		return
+	}
+	ruleTx, err := h.DasCore.Client().GetTransaction(h.Ctx, types.HexToHash(ruleConfig.TxHash))
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "Failed to search rule tx")
+		e = fmt.Errorf("GetTransaction err: %s", err.Error())
+		return
+	}
+	rulePreserved = witness.NewSubAccountRuleEntity(parentAcc)
+	if err = rulePreserved.ParseFromTx(ruleTx.Transaction, common.ActionDataTypeSubAccountPreservedRules); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "Failed to search rules")
+		e = fmt.Errorf("ParseFromTx err: %s", err.Error())
+		return
+	}
+	rulePrice = witness.NewSubAccountRuleEntity(parentAcc)
+	if err = rulePrice.ParseFromTx(ruleTx.Transaction, common.ActionDataTypeSubAccountPriceRules); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "Failed to search rules")
+		e = fmt.Errorf("ParseFromTx err: %s", err.Error())
+		return
+	}
+	return
+}
+
+// getTakenAccountIds the registered accounts and the registering ones of the smt records or the mint orders in progress
+func (h *HttpHandle) getTakenAccountIds(accountIds []string) (map[string]CheckStatus, error) {
+	mapTaken := make(map[string]CheckStatus)
+	accList, err := h.DbDao.GetAccountListByAccountIds(accountIds)
+	if err != nil {
+		return nil, fmt.Errorf("GetAccountListByAccountIds err: %s", err.Error())
+	}
+	for _, v := range accList {
+		mapTaken[v.AccountId] = CheckStatusRegistered
+	}
+	recordList, err := h.DbDao.GetSelfSmtRecordListByAccountIds(accountIds)
+	if err != nil {
+		return nil, fmt.Errorf("GetSelfSmtRecordListByAccountIds err: %s", err.Error())
+	}
+	for _, v := range recordList {
+		if _, ok := mapTaken[v.AccountId]; !ok {
+			mapTaken[v.AccountId] = CheckStatusRegistering
+		}
+	}
+	orderList, err := h.DbDao.GetMintOrderInProgressByAccountIds(accountIds, tables.ActionTypeMint)
+	if err != nil {
+		return nil, fmt.Errorf("GetMintOrderInProgressByAccountIds err: %s", err.Error())
+	}
+	for _, v := range orderList {
+		if _, ok := mapTaken[v.AccountId]; !ok {
+			mapTaken[v.AccountId] = CheckStatusRegistering
+		}
+	}
+	return mapTaken, nil
+}
+
+// genSuggestLabels the alternatives of the label, the lower score the closer to the label
+func genSuggestLabels(label string) (list []SuggestAccount) {
+	mapExist := map[string]struct{}{label: {}}
+	add := func(name string, kind SuggestKind, score int) {
+		if _, ok := mapExist[name]; ok || name == "" {
+			return
+		}
+		mapExist[name] = struct{}{}
+		list = append(list, SuggestAccount{SubAccount: name, Kind: kind, score: score})
+	}
+
+	// character swaps, only between the digits and the look-alike letters
+	runes := []rune(label)
+	for i, r := range runes {
+		for _, v := range suggestSwapMap[r] {
+			swapped := make([]rune, len(runes))
+			copy(swapped, runes)
+			swapped[i] = v
+			add(string(swapped), SuggestKindSwap, 1)
+		}
+	}
+	// numeric suffixes
+	for i := 1; i <= 99; i++ {
+		add(fmt.Sprintf("%s%d", label, i), SuggestKindNumber, 1+len(fmt.Sprint(i)))
+	}
+	// common affixes
+	for _, v := range suggestSuffixList {
+		add(label+v, SuggestKindAffix, 2+len(v))
+	}
+	for _, v := range suggestPrefixList {
+		add(v+label, SuggestKindAffix, 2+len(v))
+	}
+	return
+}
@@ -0,0 +1,95 @@
+package handle
+
+import (
+	"testing"
+)
+
+func TestGenSuggestLabels(t *testing.T) {
+	tests := []struct {
+		label     string
+		wantIn    map[string]SuggestKind
+		wantNotIn []string
+		wantNum   map[SuggestKind]int
+	}{
+		{
+			label: "bob",
+			wantIn: map[string]SuggestKind{
+				"8ob":   SuggestKindSwap,
+				"b0b":   SuggestKindSwap,
+				"bo8":   SuggestKindSwap,
+				"bob1":  SuggestKindNumber,
+				"bob99": SuggestKindNumber,
+				"bobx":  SuggestKindAffix,
+				"mybob": SuggestKindAffix,
+			},
+			wantNotIn: []string{"bob", "bob0", "bob100"},
+			wantNum: map[SuggestKind]int{
+				SuggestKindSwap:   3,
+				SuggestKindNumber: 99,
+				SuggestKindAffix:  len(suggestSuffixList) + len(suggestPrefixList),
+			},
+		},
+		{
+			label: "il",
+			wantIn: map[string]SuggestKind{
+				"1l": SuggestKindSwap,
+				"ll": SuggestKindSwap,
+				"i1": SuggestKindSwap,
+				"ii": SuggestKindSwap,
+			},
+			wantNum: map[SuggestKind]int{SuggestKindSwap: 4},
+		},
+		{
+			// the swapped names are not duplicated by the numeric suffixes
+			label:  "a",
+			wantIn: map[string]SuggestKind{"4": SuggestKindSwap, "a4": SuggestKindNumber},
+			wantNum: map[SuggestKind]int{
+				SuggestKindSwap:   1,
+				SuggestKindNumber: 99,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			list := genSuggestLabels(tt.label)
+			mapList := make(map[string]SuggestAccount)
+			mapKindNum := make(map[SuggestKind]int)
+			for _, v := range list {
+				if _, ok := mapList[v.SubAccount]; ok {
+					t.Fatal("duplicated:", v.SubAccount)
+				}
+				mapList[v.SubAccount] = v
+				mapKindNum[v.Kind]++
+			}
+			for name, kind := range tt.wantIn {
+				if v, ok := mapList[name]; !ok || v.Kind != kind {
+					t.Fatal(name, v.Kind, kind)
+				}
+			}
+			for _, name := range tt.wantNotIn {
+				if _, ok := mapList[name]; ok {
+					t.Fatal(name)
+				}
+			}
+			for kind, num := range tt.wantNum {
+				if mapKindNum[kind] != num {
+					t.Fatal(kind, mapKindNum[kind], num)
+				}
+			}
+		})
+	}
+}
+
+func TestGenSuggestLabelsScore(t *testing.T) {
+	mapScore := make(map[string]int)
+	for _, v := range genSuggestLabels("bob") {
+		mapScore[v.SubAccount] = v.score
+	}
+	// the swaps are the closest, then the short numbers, the affixes and the long numbers
+	if !(mapScore["b0b"] < mapScore["bob1"] && mapScore["bob1"] < mapScore["bob12"] && mapScore["bob12"] <= mapScore["bobx"]) {
+		t.Fatal(mapScore["b0b"], mapScore["bob1"], mapScore["bob12"], mapScore["bobx"])
+	}
+	if mapScore["bobx"] >= mapScore["bobofficial"] {
+		t.Fatal(mapScore["bobx"], mapScore["bobofficial"])
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/md5"
 	"das_sub_account/config"
+	sub_api_code "das_sub_account/http_server/api_code"
 	"das_sub_account/tables"
 	"das_sub_account/unipay"
 	"fmt"
@@ -31,6 +32,9 @@ type ReqAutoOrderCreate struct {
 	TokenId    tables.TokenId    `json:"token_id"`
 	Years      uint64            `json:"years" binding:"gt=0"`
 	CouponCode string            `json:"coupon_code"`
+	QuoteId    string            `json:"quote_id"`
+	// ReferralCode the referrer gets the share of the income of the owner
+	ReferralCode string `json:"referral_code"`
 }
 
 type RespAutoOrderCreate struct {
@@ -117,6 +121,56 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
 		return nil
 	}
+	if err := h.checkSuspension(parentAccountId, tables.GetSuspensionScopeByActionType(req.ActionType), apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	referral, err := h.getOrderReferral(parentAccountId, req.ReferralCode, hexAddr, apiResp)
+	if err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	// the orders of the address are created one by one until the order is saved,
+	// so the quota of the presale and the mint limit are counted under the lock
+	if req.ActionType == tables.ActionTypeMint {
+		mintLimit, err := h.DbDao.GetUserMintLimit(parentAccountId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to search mint limit")
+			return fmt.Errorf("GetUserMintLimit err: %s", err.Error())
+		}
+		presaleConfig, err := h.DbDao.GetUserPresaleConfig(parentAccountId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query presale config")
+			return fmt.Errorf("GetUserPresaleConfig err: %s", err.Error())
+		}
+		if mintLimit.IsEnable() || presaleConfig.GetStatus(time.Now().UnixMilli()) == tables.PresaleStatusPresale {
+			lockKey := fmt.Sprintf("%x", md5.Sum([]byte("mint:limit:"+parentAccountId+":"+strings.ToLower(hexAddr.AddressHex))))
+			if err := h.RC.Lock(lockKey); err != nil {
+				apiResp.ApiRespErr(api_code.ApiCodeDistributedLockPreemption, "another order of the address is being created, please try again later")
+				return fmt.Errorf("RC.Lock err: %s", err.Error())
+			}
+			defer func() {
+				if err := h.RC.UnLock(lockKey); err != nil {
+					log.Error(ctx, "RC.UnLock err:", err.Error())
+				}
+			}()
+		}
+		if err := h.checkPresale(parentAccountId, req.SubAccount, req.ActionType, hexAddr, apiResp); err != nil {
+			return err
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+		if mintLimit.IsEnable() {
+			if err := h.checkMintLimitOfAddress(&mintLimit, parentAccountId, req.SubAccount, hexAddr, apiResp); err != nil {
+				return err
+			} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+				return nil
+			}
+		}
+	}
 
 	// get max years
 	if maxYear := h.getMaxYears(ctx, parentAccount); req.Years > maxYear {
@@ -138,13 +192,38 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 		return nil
 	}
 
+	newSubAccountPrice, _ := molecule.Bytes2GoU64(builder.ConfigCellSubAccount.NewSubAccountPrice().RawData())
+	minPrice := decimal.NewFromInt(int64(newSubAccountPrice)).DivRound(decimal.NewFromInt(common.UsdRateBase), 2)
+	if req.ActionType == tables.ActionTypeRenew {
+		renewSubAccountPrice, _ := molecule.Bytes2GoU64(builder.ConfigCellSubAccount.RenewSubAccountPrice().RawData())
+		minPrice = decimal.NewFromInt(int64(renewSubAccountPrice)).DivRound(decimal.NewFromInt(common.UsdRateBase), 2)
+	}
+
+	// the price and the token prices locked by auto/account/search
+	var quote *tables.TablePriceQuote
+	var quoteTokens []tables.PriceQuoteToken
+	if req.QuoteId != "" {
+		quote, quoteTokens, err = h.getPriceQuote(req.QuoteId, req.SubAccount, req.ActionType, hexAddr, apiResp)
+		if err != nil {
+			return err
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+		usdAmount = quote.Price
+	}
+
 	log.Info(ctx, "usdAmount:", usdAmount.String(), req.Years)
-	// total usd price
-	usdAmount = usdAmount.Mul(decimal.NewFromInt(int64(req.Years)))
+	// total usd price after the multi-year discount
+	usdAmount, discount, err := h.getDiscountPrice(parentAccountId, usdAmount, minPrice, req.Years)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to search discount config")
+		return err
+	}
 
 	// deduct coupons
 	actualUsdPrice := usdAmount
 	var couponInfo tables.CouponInfo
+	var couponSetInfo tables.CouponSetInfo
 	if req.CouponCode != "" {
 		lockKey := fmt.Sprintf("%x", md5.Sum([]byte("coupon:use:"+req.CouponCode)))
 		if err := h.RC.Lock(lockKey); err != nil {
@@ -176,11 +255,12 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 			return nil
 		}
 
-		setInfo, err := h.DbDao.GetCouponSetInfo(couponInfo.Cid)
+		couponSetInfo, err = h.DbDao.GetCouponSetInfo(couponInfo.Cid)
 		if err != nil {
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, err.Error())
 			return fmt.Errorf("GetCouponSetInfo err: %s", err.Error())
 		}
+		setInfo := couponSetInfo
 		if setInfo.OrderId == "" || setInfo.Status != tables.CouponSetInfoStatusSuccess {
 			apiResp.ApiRespErr(api_code.ApiCodeError500, "this coupon code can not use, because it order not paid")
 			return nil
@@ -198,11 +278,24 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 			return nil
 		}
 
-		if setInfo.Price.LessThan(usdAmount) {
-			actualUsdPrice = usdAmount.Sub(setInfo.Price)
-		} else {
-			actualUsdPrice = decimal.Zero
+		if err := h.checkCouponApplicable(&setInfo, parentAccount.Account, req.SubAccount, req.ActionType, req.Years, apiResp); err != nil {
+			return err
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+		if setInfo.MaxPerAddress > 0 {
+			num, err := h.DbDao.GetCouponOrderNumByPayAddress(req.CouponCode, hexAddr.AddressHex)
+			if err != nil {
+				apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query order")
+				return fmt.Errorf("GetCouponOrderNumByPayAddress err: %s", err.Error())
+			}
+			if num >= setInfo.MaxPerAddress {
+				apiResp.ApiRespErr(sub_api_code.ApiCodeCouponExceededAddressLimit, fmt.Sprintf("this coupon code can not use, because it can be used %d times by an address", setInfo.MaxPerAddress))
+				return nil
+			}
 		}
+
+		actualUsdPrice = setInfo.GetActualPrice(usdAmount, req.Years)
 	}
 
 	amount := decimal.Zero
@@ -237,12 +330,6 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 			}
 		}
 
-		newSubAccountPrice, _ := molecule.Bytes2GoU64(builder.ConfigCellSubAccount.NewSubAccountPrice().RawData())
-		minPrice := decimal.NewFromInt(int64(newSubAccountPrice)).DivRound(decimal.NewFromInt(common.UsdRateBase), 2)
-		if req.ActionType == tables.ActionTypeRenew {
-			renewSubAccountPrice, _ := molecule.Bytes2GoU64(builder.ConfigCellSubAccount.RenewSubAccountPrice().RawData())
-			minPrice = decimal.NewFromInt(int64(renewSubAccountPrice)).DivRound(decimal.NewFromInt(common.UsdRateBase), 2)
-		}
 		if minPrice.GreaterThan(usdAmount) {
 			apiResp.ApiRespErr(api_code.ApiCodePriceRulePriceNotBeLessThanMin, "Pricing below minimum")
 			return fmt.Errorf("price not be less than min: %s$", minPrice.String())
@@ -257,6 +344,22 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 			apiResp.ApiRespErr(api_code.ApiCodeTokenIdNotSupported, "payment method not supported")
 			return nil
 		}
+		if quote != nil {
+			find := false
+			for _, v := range quoteTokens {
+				if v.TokenId == req.TokenId {
+					tokenPrice.Price, find = v.Price, true
+					break
+				}
+			}
+			if !find {
+				apiResp.ApiRespErr(api_code.ApiCodeTokenIdNotSupported, "payment method not in the price quote")
+				return nil
+			}
+		} else if tokenPrice.Price, err = h.getFeedPrice(string(req.TokenId), tokenPrice.Price); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodePaymentMethodDisable, fmt.Sprintf("the price of %s is stale, please try another payment method", tokenPrice.Symbol))
+			return fmt.Errorf("getFeedPrice err: %s", err.Error())
+		}
 		amount = actualUsdPrice.Mul(decimal.New(1, tokenPrice.Decimals)).Div(tokenPrice.Price).Ceil()
 		amount = RoundAmount(amount, req.TokenId)
 
@@ -318,7 +421,9 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 		TokenId:           string(req.TokenId),
 		Amount:            amount,
 		USDAmount:         usdAmount,
+		Discount:          discount,
 		CouponCode:        req.CouponCode,
+		QuoteId:           req.QuoteId,
 		PayStatus:         tables.PayStatusUnpaid,
 		OrderStatus:       tables.OrderStatusDefault,
 		Timestamp:         now.UnixMilli(),
@@ -327,6 +432,12 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 		PremiumBase:       premiumBase,
 		PremiumAmount:     premiumAmount,
 	}
+	if referral != nil {
+		orderInfo.ReferralCode = referral.Code
+		orderInfo.ReferralShare = referral.Share
+		orderInfo.Referrer = referral.Referrer
+		orderInfo.ReferrerId = referral.ReferrerId
+	}
 
 	var paymentInfo tables.PaymentInfo
 	if req.TokenId == tables.TokenIdStripeUSD && res.StripePaymentIntentId != "" {
@@ -341,10 +452,20 @@ func (h *HttpHandle) doAutoOrderCreate(ctx context.Context, req *ReqAutoOrderCre
 		amount = amount.Div(decimal.New(1, tokenPrice.Decimals))
 	}
 
-	if err = h.DbDao.CreateOrderInfoWithCoupon(orderInfo, paymentInfo, couponInfo); err != nil {
+	if err = h.DbDao.CreateOrderInfoWithCoupon(orderInfo, paymentInfo, couponInfo, couponSetInfo.GetMaxRedemption()); err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to create order")
 		return fmt.Errorf("CreateOrderInfo err: %s", err.Error())
 	}
+	if couponInfo.Id > 0 {
+		if err := h.DbDao.CreateWebhookEvent(parentAccountId, tables.WebhookEventCouponRedeemed, tables.WebhookCouponData{
+			Code:    req.CouponCode,
+			Cid:     couponInfo.Cid,
+			OrderId: res.OrderId,
+			Account: req.SubAccount,
+		}); err != nil {
+			log.Error(ctx, "CreateWebhookEvent err:", err.Error())
+		}
+	}
 
 	var resp RespAutoOrderCreate
 	resp.OrderId = res.OrderId
@@ -0,0 +1,119 @@
+package handle
+
+import (
+	"das_sub_account/tables"
+	"encoding/csv"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+	"math"
+	"net/http"
+	"strings"
+)
+
+type ReqAutoPaymentExport struct {
+	Account string `json:"account" binding:"required"`
+}
+
+// AutoPaymentExport the payouts of the parent account as csv
+func (h *HttpHandle) AutoPaymentExport(ctx *gin.Context) {
+	var (
+		funcName               = "AutoPaymentExport"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqAutoPaymentExport
+		apiResp                api_code.ApiResp
+	)
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, ctx.Request.Context())
+	req.Account = strings.ToLower(req.Account)
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+
+	var list []tables.AutoPaymentInfo
+	for page, size := 1, 100; ; page++ {
+		res, _, err := h.DbDao.FindAutoPaymentInfo(accountId, page, size)
+		if err != nil {
+			log.Error("FindAutoPaymentInfo err:", err.Error(), funcName, req.Account)
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query payment")
+			ctx.JSON(http.StatusOK, apiResp)
+			return
+		}
+		list = append(list, res...)
+		if len(res) < size {
+			break
+		}
+	}
+
+	// the referral payouts are paid with the payments of the owner
+	referralPayments, err := h.DbDao.GetReferralPaymentList(accountId)
+	if err != nil {
+		log.Error("GetReferralPaymentList err:", err.Error(), funcName, req.Account)
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query referral payment")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	mapReferralPayment := make(map[string][]tables.TableReferralPayment)
+	for _, v := range referralPayments {
+		mapReferralPayment[v.AutoPaymentId] = append(mapReferralPayment[v.AutoPaymentId], v)
+	}
+
+	var mapToken = make(map[string]tables.TTokenPriceInfo)
+	var records [][]string
+	for _, v := range list {
+		token, ok := mapToken[v.TokenId]
+		if !ok {
+			var err error
+			if token, err = h.DbDao.GetTokenById(tables.TokenId(v.TokenId)); err != nil {
+				log.Error("GetTokenById err:", err.Error(), funcName, v.TokenId)
+				apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query token")
+				ctx.JSON(http.StatusOK, apiResp)
+				return
+			}
+			mapToken[v.TokenId] = token
+		}
+		dec := decimal.NewFromInt(int64(math.Pow10(int(token.Decimals))))
+		records = append(records, []string{
+			v.PaymentDate.Format("2006-01-02 15:04:05"),
+			token.Symbol,
+			v.Amount.DivRound(dec, token.Decimals).String(),
+			v.Fee.DivRound(dec, token.Decimals).String(),
+			v.Address,
+			v.PaymentTx,
+			PayeeOwner,
+		})
+		for _, referral := range mapReferralPayment[v.AutoPaymentId] {
+			records = append(records, []string{
+				referral.PaymentDate.Format("2006-01-02 15:04:05"),
+				token.Symbol,
+				referral.Amount.DivRound(dec, token.Decimals).String(),
+				"0",
+				referral.Address,
+				v.PaymentTx,
+				PayeeReferral + referral.Referrer,
+			})
+		}
+	}
+
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-payments.csv", req.Account))
+	ctx.Header("Content-Type", "text/csv")
+
+	w := csv.NewWriter(ctx.Writer)
+	if err := w.Write([]string{"payment_date", "token", "amount", "fee", "address", "payment_tx", "payee"}); err != nil {
+		log.Error(err)
+		_ = ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err := w.WriteAll(records); err != nil {
+		log.Error(err)
+		_ = ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
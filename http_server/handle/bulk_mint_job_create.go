@@ -0,0 +1,303 @@
+package handle
+
+import (
+	"bytes"
+	"context"
+	"das_sub_account/config"
+	"das_sub_account/tables"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+type ReqBulkMintJobCreate struct {
+	core.ChainTypeAddress
+	Account string `json:"account" binding:"required"`
+	Format  string `json:"format" binding:"required"` // csv, json
+	Content string `json:"content" binding:"required"`
+}
+
+type RespBulkMintJobCreate struct {
+	JobId    string            `json:"job_id"`
+	Total    int               `json:"total"`
+	ValidNum int               `json:"valid_num"`
+	Result   []CheckSubAccount `json:"result"`
+}
+
+const (
+	BulkMintFormatCsv  = "csv"
+	BulkMintFormatJson = "json"
+)
+
+func (h *HttpHandle) BulkMintJobCreate(ctx *gin.Context) {
+	var (
+		funcName               = "BulkMintJobCreate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqBulkMintJobCreate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, req.Format, len(req.Content), ctx.Request.Context())
+
+	if err = h.doBulkMintJobCreate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doBulkMintJobCreate err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doBulkMintJobCreate(ctx context.Context, req *ReqBulkMintJobCreate, apiResp *api_code.ApiResp) error {
+	var resp RespBulkMintJobCreate
+	req.Account = strings.ToLower(req.Account)
+
+	list, err := parseBulkMintContent(req.Account, req.Format, req.Content)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, err.Error())
+		return nil
+	}
+	maxBulkMintCount := 10000
+	if config.Cfg.Das.MaxBulkMintCount > 0 {
+		maxBulkMintCount = config.Cfg.Das.MaxBulkMintCount
+	}
+	if len(list) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: len(sub account list) is 0")
+		return nil
+	} else if len(list) > maxBulkMintCount {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("more than max bulk mint num %d", maxBulkMintCount))
+		return nil
+	}
+
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
+		return nil
+	}
+
+	// check account
+	acc, err := h.doSubAccountCheckAccount(req.Account, apiResp, common.DasActionUpdateSubAccount)
+	if err != nil {
+		return fmt.Errorf("doSubAccountCheckAccount err: %s", err.Error())
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	// the job is still signed by the manager, so the address must be the manager even with an api key
+	if acc.ManagerChainType != addrHex.ChainType || !strings.EqualFold(acc.Manager, addrHex.AddressHex) {
+		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "permission denied")
+		return nil
+	}
+	if err := h.checkSuspension(acc.AccountId, tables.SuspensionScopeMint, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	// check list
+	resp.Result = h.doBulkMintCheckList(ctx, req.ChainTypeAddress, req.Account, list)
+
+	job := tables.TableBulkMintJob{
+		ParentAccountId: acc.AccountId,
+		Account:         req.Account,
+		ChainType:       addrHex.ChainType,
+		Address:         addrHex.AddressHex,
+		Format:          req.Format,
+		Total:           len(list),
+		Status:          tables.BulkMintJobStatusQueued,
+	}
+	job.InitJobId()
+
+	var items []tables.TableBulkMintJobItem
+	for i, v := range resp.Result {
+		owner, _ := json.Marshal(v.ChainTypeAddress)
+		item := tables.TableBulkMintJobItem{
+			JobId:         job.JobId,
+			RowIndex:      i,
+			Account:       v.Account,
+			AccountId:     common.Bytes2Hex(common.GetAccountIdByAccount(v.Account)),
+			Owner:         string(owner),
+			RegisterYears: v.RegisterYears,
+			Status:        tables.BulkMintItemStatusQueued,
+			Message:       v.Message,
+		}
+		if v.Status != CheckStatusOk {
+			item.Status = tables.BulkMintItemStatusInvalid
+		} else {
+			job.ValidNum++
+		}
+		items = append(items, item)
+	}
+	if job.ValidNum == 0 {
+		job.Status = tables.BulkMintJobStatusComplete
+	}
+	if err = h.DbDao.CreateBulkMintJob(&job, items); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to create bulk mint job")
+		return fmt.Errorf("CreateBulkMintJob err: %s", err.Error())
+	}
+
+	resp.JobId = job.JobId
+	resp.Total = job.Total
+	resp.ValidNum = job.ValidNum
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// doBulkMintCheckList runs doSubAccountCheckList in chunks of MaxCreateCount and keeps the row order of the upload
+func (h *HttpHandle) doBulkMintCheckList(ctx context.Context, manager core.ChainTypeAddress, account string, list []CreateSubAccount) []CheckSubAccount {
+	result := make([]CheckSubAccount, 0, len(list))
+	chunkSize := config.Cfg.Das.MaxCreateCount
+	if chunkSize <= 0 {
+		chunkSize = 100
+	}
+
+	var accountMap = make(map[string]struct{})
+	var chunk []CreateSubAccount
+	var chunkIndex []int
+	// every chunk is checked with its own resp, a failed chunk fails its rows only
+	doCheck := func() {
+		if len(chunk) == 0 {
+			return
+		}
+		defer func() {
+			chunk, chunkIndex = nil, nil
+		}()
+		var chunkResp api_code.ApiResp
+		req := ReqSubAccountCreate{
+			ChainTypeAddress: manager,
+			Account:          account,
+			SubAccountList:   chunk,
+		}
+		_, respCheck, err := h.doSubAccountCheckList(ctx, &req, &chunkResp)
+		if err != nil || chunkResp.ErrNo != api_code.ApiCodeSuccess {
+			if err != nil {
+				log.Error("doBulkMintCheckList doSubAccountCheckList err:", err.Error(), account, ctx)
+			}
+			msg := chunkResp.ErrMsg
+			if msg == "" {
+				msg = "failed to check sub-account"
+			}
+			for _, i := range chunkIndex {
+				result[i].Status = CheckStatusFail
+				result[i].Message = msg
+			}
+			return
+		}
+		for i, v := range respCheck.Result {
+			result[chunkIndex[i]] = v
+		}
+	}
+
+	for i, v := range list {
+		result = append(result, CheckSubAccount{CreateSubAccount: v})
+		if _, ok := accountMap[v.Account]; ok {
+			result[i].Status = CheckStatusFail
+			result[i].Message = "same account"
+			continue
+		}
+		accountMap[v.Account] = struct{}{}
+		if v.KeyInfo.Key == "" && v.MintForAccount == "" {
+			result[i].Status = CheckStatusFail
+			result[i].Message = "owner is empty"
+			continue
+		}
+
+		chunk = append(chunk, v)
+		chunkIndex = append(chunkIndex, i)
+		if len(chunk) < chunkSize {
+			continue
+		}
+		doCheck()
+	}
+	doCheck()
+	return result
+}
+
+func formatBulkMintSubAccount(account, subAccount string) string {
+	subAccount = strings.ToLower(strings.TrimSpace(subAccount))
+	if subAccount == "" || strings.HasSuffix(subAccount, "."+account) {
+		return subAccount
+	}
+	return subAccount + "." + account
+}
+
+// parseBulkMintContent
+// csv: header must contain account,register_years and either coin_type,key or mint_for_account
+// json: same struct as sub_account_list of /sub/account/create
+func parseBulkMintContent(account, format, content string) ([]CreateSubAccount, error) {
+	var list []CreateSubAccount
+	switch strings.ToLower(format) {
+	case BulkMintFormatJson:
+		if err := json.Unmarshal([]byte(content), &list); err != nil {
+			return nil, fmt.Errorf("json content invalid: %s", err.Error())
+		}
+	case BulkMintFormatCsv:
+		r := csv.NewReader(bytes.NewBufferString(content))
+		r.TrimLeadingSpace = true
+		header, err := r.Read()
+		if err != nil {
+			return nil, fmt.Errorf("csv header invalid: %s", err.Error())
+		}
+		var columns = make(map[string]int)
+		for i, v := range header {
+			columns[strings.ToLower(strings.TrimSpace(v))] = i
+		}
+		if _, ok := columns["account"]; !ok {
+			return nil, fmt.Errorf("csv header miss column: account")
+		} else if _, ok = columns["register_years"]; !ok {
+			return nil, fmt.Errorf("csv header miss column: register_years")
+		}
+		getColumn := func(record []string, name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		for line := 2; ; line++ {
+			record, err := r.Read()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("csv line %d invalid: %s", line, err.Error())
+			}
+			years, err := strconv.ParseUint(getColumn(record, "register_years"), 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("csv line %d register_years invalid", line)
+			}
+			tmp := CreateSubAccount{
+				Account:        getColumn(record, "account"),
+				MintForAccount: getColumn(record, "mint_for_account"),
+				RegisterYears:  years,
+			}
+			if key := getColumn(record, "key"); key != "" {
+				tmp.ChainTypeAddress = core.ChainTypeAddress{
+					Type: "blockchain",
+					KeyInfo: core.KeyInfo{
+						CoinType: common.CoinType(getColumn(record, "coin_type")),
+						Key:      key,
+					},
+				}
+			}
+			list = append(list, tmp)
+		}
+	default:
+		return nil, fmt.Errorf("format [%s] not supported", format)
+	}
+	for i, v := range list {
+		list[i].Account = formatBulkMintSubAccount(account, v.Account)
+		list[i].MintForAccount = strings.ToLower(v.MintForAccount)
+	}
+	return list, nil
+}
@@ -0,0 +1,94 @@
+package handle
+
+import (
+	"github.com/dotbitHQ/das-lib/core"
+	"testing"
+)
+
+func TestParseBulkMintContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  string
+		content string
+		want    []CreateSubAccount
+		wantErr bool
+	}{
+		{
+			name:    "csv with key",
+			format:  BulkMintFormatCsv,
+			content: "account,register_years,coin_type,key\nAbc,1,60,0xc9f53b1d85356b60453f867610888d89a0b667ad\n",
+			want: []CreateSubAccount{{Account: "abc.test.bit", RegisterYears: 1, ChainTypeAddress: core.ChainTypeAddress{
+				Type:    "blockchain",
+				KeyInfo: core.KeyInfo{CoinType: "60", Key: "0xc9f53b1d85356b60453f867610888d89a0b667ad"},
+			}}},
+		},
+		{
+			name:    "csv with mint_for_account and the full name",
+			format:  "CSV",
+			content: "Account, Register_Years, Mint_For_Account\nabc.test.bit, 2, Owner.bit\n",
+			want:    []CreateSubAccount{{Account: "abc.test.bit", MintForAccount: "owner.bit", RegisterYears: 2}},
+		},
+		{
+			name:    "csv miss account",
+			format:  BulkMintFormatCsv,
+			content: "register_years,key\n1,0x01\n",
+			wantErr: true,
+		},
+		{
+			name:    "csv miss register_years",
+			format:  BulkMintFormatCsv,
+			content: "account,key\nabc,0x01\n",
+			wantErr: true,
+		},
+		{
+			name:    "csv register_years invalid",
+			format:  BulkMintFormatCsv,
+			content: "account,register_years\nabc,one\n",
+			wantErr: true,
+		},
+		{
+			name:    "csv header only",
+			format:  BulkMintFormatCsv,
+			content: "account,register_years\n",
+			want:    nil,
+		},
+		{
+			name:    "json",
+			format:  BulkMintFormatJson,
+			content: `[{"account":"ABC","register_years":3},{"account":"def.test.bit","mint_for_account":"Owner.bit","register_years":1}]`,
+			want: []CreateSubAccount{
+				{Account: "abc.test.bit", RegisterYears: 3},
+				{Account: "def.test.bit", MintForAccount: "owner.bit", RegisterYears: 1},
+			},
+		},
+		{
+			name:    "json invalid",
+			format:  BulkMintFormatJson,
+			content: `{"account":"abc"}`,
+			wantErr: true,
+		},
+		{
+			name:    "format not supported",
+			format:  "xml",
+			content: "<account/>",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := parseBulkMintContent("test.bit", tt.format, tt.content)
+			if (err != nil) != tt.wantErr {
+				t.Fatal(err)
+			}
+			if len(list) != len(tt.want) {
+				t.Fatal(len(list), len(tt.want))
+			}
+			for i, v := range tt.want {
+				if list[i].Account != v.Account || list[i].MintForAccount != v.MintForAccount || list[i].RegisterYears != v.RegisterYears ||
+					list[i].KeyInfo != v.KeyInfo {
+					t.Fatal(i, list[i].Account, list[i].MintForAccount, list[i].RegisterYears)
+				}
+			}
+		})
+	}
+}
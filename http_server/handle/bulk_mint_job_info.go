@@ -0,0 +1,95 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+)
+
+type ReqBulkMintJobInfo struct {
+	Pagination
+	JobId string `json:"job_id" binding:"required"`
+}
+
+type RespBulkMintJobInfo struct {
+	JobId       string                        `json:"job_id"`
+	Account     string                        `json:"account"`
+	Status      tables.BulkMintJobStatus      `json:"status"`
+	Total       int                           `json:"total"`
+	InvalidNum  int64                         `json:"invalid_num"`
+	QueuedNum   int64                         `json:"queued_num"`
+	SignedNum   int64                         `json:"signed_num"`
+	MintedNum   int64                         `json:"minted_num"`
+	FailedNum   int64                         `json:"failed_num"`
+	ItemTotal   int64                         `json:"item_total"`
+	List        []tables.TableBulkMintJobItem `json:"list"`
+	CreatedTime int64                         `json:"created_time"`
+}
+
+func (h *HttpHandle) BulkMintJobInfo(ctx *gin.Context) {
+	var (
+		funcName               = "BulkMintJobInfo"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqBulkMintJobInfo
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doBulkMintJobInfo(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doBulkMintJobInfo err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doBulkMintJobInfo(ctx context.Context, req *ReqBulkMintJobInfo, apiResp *api_code.ApiResp) error {
+	var resp RespBulkMintJobInfo
+
+	job, err := h.DbDao.GetBulkMintJob(req.JobId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query bulk mint job")
+		return fmt.Errorf("GetBulkMintJob err: %s", err.Error())
+	} else if job.Id == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeTaskNotExist, "bulk mint job not exist")
+		return nil
+	}
+
+	statusCount, err := h.DbDao.CountBulkMintJobItems(job.JobId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to count bulk mint job items")
+		return fmt.Errorf("CountBulkMintJobItems err: %s", err.Error())
+	}
+	list, total, err := h.DbDao.GetBulkMintJobItems(job.JobId, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query bulk mint job items")
+		return fmt.Errorf("GetBulkMintJobItems err: %s", err.Error())
+	}
+
+	resp.JobId = job.JobId
+	resp.Account = job.Account
+	resp.Status = job.Status
+	resp.Total = job.Total
+	resp.InvalidNum = statusCount[tables.BulkMintItemStatusInvalid]
+	resp.QueuedNum = statusCount[tables.BulkMintItemStatusQueued]
+	resp.SignedNum = statusCount[tables.BulkMintItemStatusSigned]
+	resp.MintedNum = statusCount[tables.BulkMintItemStatusMinted]
+	resp.FailedNum = statusCount[tables.BulkMintItemStatusFailed]
+	resp.ItemTotal = total
+	resp.List = list
+	resp.CreatedTime = job.CreatedAt.UnixMilli()
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -0,0 +1,143 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/config"
+	"das_sub_account/internal"
+	"das_sub_account/tables"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+)
+
+type ReqBulkMintJobSign struct {
+	core.ChainTypeAddress
+	JobId string `json:"job_id" binding:"required"`
+}
+
+func (h *HttpHandle) BulkMintJobSign(ctx *gin.Context) {
+	var (
+		funcName               = "BulkMintJobSign"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqBulkMintJobSign
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doBulkMintJobSign(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doBulkMintJobSign err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		doApiError(err, &apiResp)
+	}
+
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doBulkMintJobSign(ctx context.Context, req *ReqBulkMintJobSign, apiResp *api_code.ApiResp) error {
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+
+	job, err := h.DbDao.GetBulkMintJob(req.JobId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query bulk mint job")
+		return fmt.Errorf("GetBulkMintJob err: %s", err.Error())
+	} else if job.Id == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeTaskNotExist, "bulk mint job not exist")
+		return nil
+	}
+	addrHex, err := req.FormatChainTypeAddress(config.Cfg.Server.Net, true)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params is invalid: "+err.Error())
+		return nil
+	}
+	if job.ChainType != addrHex.ChainType || !strings.EqualFold(job.Address, addrHex.AddressHex) {
+		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "permission denied")
+		return nil
+	}
+
+	// check account
+	acc, err := h.doSubAccountCheckAccount(job.Account, apiResp, common.DasActionUpdateSubAccount)
+	if err != nil {
+		return fmt.Errorf("doSubAccountCheckAccount err: %s", err.Error())
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	// next batch
+	maxCreateCount := config.Cfg.Das.MaxCreateCount
+	if maxCreateCount <= 0 {
+		maxCreateCount = 100
+	}
+	items, err := h.DbDao.GetBulkMintJobQueuedItems(job.JobId, maxCreateCount)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query bulk mint job items")
+		return fmt.Errorf("GetBulkMintJobQueuedItems err: %s", err.Error())
+	} else if len(items) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "no queued sub account in bulk mint job")
+		return nil
+	}
+
+	subReq := ReqSubAccountCreate{
+		ChainTypeAddress: req.ChainTypeAddress,
+		chainType:        addrHex.ChainType,
+		address:          addrHex.AddressHex,
+		Account:          job.Account,
+	}
+	for _, v := range items {
+		tmp := CreateSubAccount{
+			Account:       v.Account,
+			RegisterYears: v.RegisterYears,
+		}
+		if err := json.Unmarshal([]byte(v.Owner), &tmp.ChainTypeAddress); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "bulk mint item owner invalid")
+			return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+		}
+		subReq.SubAccountList = append(subReq.SubAccountList, tmp)
+	}
+
+	// the names may be taken since the job was uploaded
+	_, respCheck, err := h.doSubAccountCheckList(ctx, &subReq, apiResp)
+	if err != nil {
+		return fmt.Errorf("doSubAccountCheckList err: %s", err.Error())
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	var okList []CreateSubAccount
+	var okItemIds []uint64
+	for i, v := range respCheck.Result {
+		if v.Status == CheckStatusOk {
+			okList = append(okList, subReq.SubAccountList[i])
+			okItemIds = append(okItemIds, items[i].Id)
+			continue
+		}
+		if err := h.DbDao.UpdateBulkMintJobItemStatus([]uint64{items[i].Id}, tables.BulkMintItemStatusQueued, tables.BulkMintItemStatusFailed, v.Message); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update bulk mint job item")
+			return fmt.Errorf("UpdateBulkMintJobItemStatus err: %s", err.Error())
+		}
+	}
+	if len(okList) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeCreateListCheckFail, "create list check failed")
+		return nil
+	}
+	subReq.SubAccountList = okList
+
+	return h.doSubAccountCreateSignInfo(ctx, &subReq, acc, job.JobId, okItemIds, apiResp)
+}
@@ -0,0 +1,50 @@
+package handle
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"io"
+	"strings"
+	"time"
+)
+
+type ReqCacheTag struct {
+	Account    string `json:"account"`
+	SubAccount string `json:"sub_account"`
+}
+
+// CacheTag tags the key of toolib.MiddlewareCacheByRedis by the account ids of the request,
+// so the cached responses are purged when the parser or the task runners commit changes of the accounts.
+// It must be placed right before the cache middleware, the expiration is the data expiration of the cache
+func (h *HttpHandle) CacheTag(expiration time.Duration) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		bodyBytes, err := ctx.GetRawData()
+		if err != nil {
+			log.Error("CacheTag GetRawData err:", err.Error())
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+		var req ReqCacheTag
+		if err := json.Unmarshal(bodyBytes, &req); err != nil {
+			return
+		}
+		var accountIds []string
+		for _, v := range []string{req.Account, req.SubAccount} {
+			if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+				accountIds = append(accountIds, common.Bytes2Hex(common.GetAccountIdByAccount(v)))
+			}
+		}
+		if len(accountIds) == 0 {
+			return
+		}
+		// the same key as toolib.MiddlewareCacheByRedis
+		cacheKey := toolib.Md5Hash(append([]byte(ctx.Request.URL.String()), bodyBytes...))
+		if err := h.RC.AddCacheTag(cacheKey, expiration, accountIds...); err != nil {
+			log.Error("CacheTag AddCacheTag err:", err.Error())
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package handle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderApiKey    = "X-Api-Key"
+	ctxKeyApiKey    = "api_key"
+	apiKeyPrefix    = "dsk_"
+	defaultApiLimit = 60
+)
+
+type ReqCheckApiKey struct {
+	Account string `json:"account" binding:"required"`
+}
+
+// CheckApiKey authenticates the request by X-Api-Key when the header is present,
+// requests without the header are passed on to the next middleware unchanged
+func (h *HttpHandle) CheckApiKey(scope tables.ApiKeyScope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(HeaderApiKey)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		var apiResp api_code.ApiResp
+		defer func() {
+			if apiResp.ErrNo != 0 {
+				ctx.JSON(http.StatusOK, apiResp)
+				ctx.Abort()
+			}
+		}()
+
+		var req ReqCheckApiKey
+		if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+			return
+		}
+		restoreRequestBody(ctx)
+
+		keyId, ok := parseApiKey(key)
+		if !ok {
+			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "api key invalid")
+			return
+		}
+		apiKey, err := h.DbDao.GetApiKey(keyId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query api key")
+			return
+		}
+		if apiKey.Id == 0 || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(getApiKeyHash(key))) != 1 {
+			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "api key invalid")
+			return
+		}
+		if apiKey.Status != tables.ApiKeyStatusNormal || apiKey.IsExpired() {
+			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "api key revoked or expired")
+			return
+		}
+		if !apiKey.HasScope(scope) {
+			apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "permission denied")
+			return
+		}
+		if apiKey.ParentAccountId != common.Bytes2Hex(common.GetAccountIdByAccount(strings.ToLower(req.Account))) {
+			apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "permission denied")
+			return
+		}
+		// the keys issued by a former owner are not valid any more
+		acc, err := h.DbDao.GetAccountInfoByAccountId(apiKey.ParentAccountId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query account")
+			return
+		}
+		if !apiKey.IsIssuedByOwner(acc) {
+			if err := h.DbDao.UpdateApiKeyToRevoked(apiKey.KeyId); err != nil {
+				log.Error("UpdateApiKeyToRevoked err:", err.Error(), apiKey.KeyId)
+			}
+			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "api key revoked or expired")
+			return
+		}
+
+		limit := apiKey.RateLimit
+		if limit <= 0 {
+			limit = defaultApiLimit
+		}
+		num, err := h.RC.IncrApiKeyRate(apiKey.KeyId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+			return
+		}
+		if num > int64(limit) {
+			apiResp.ApiRespErr(api_code.ApiCodeOperationFrequent, "the operation is too frequent")
+			return
+		}
+		// last used is tracked at minute granularity
+		if num == 1 {
+			clientIp, remoteAddrIP := GetClientIp(ctx)
+			if clientIp == "" {
+				clientIp = remoteAddrIP
+			}
+			if err := h.DbDao.UpdateApiKeyLastUsed(apiKey.KeyId, clientIp, time.Now().UnixMilli()); err != nil {
+				log.Error("UpdateApiKeyLastUsed err:", err.Error(), apiKey.KeyId)
+			}
+		}
+		ctx.Set(ctxKeyApiKey, &apiKey)
+		ctx.Next()
+	}
+}
+
+// restoreRequestBody the middlewares bind the body by ShouldBindBodyWith,
+// the cache middleware and the handlers behind still read it from the request
+func restoreRequestBody(ctx *gin.Context) {
+	if body, ok := ctx.Get(gin.BodyBytesKey); ok {
+		if bys, ok := body.([]byte); ok {
+			ctx.Request.Body = io.NopCloser(bytes.NewReader(bys))
+		}
+	}
+}
+
+func getCtxApiKey(ctx *gin.Context) *tables.TableApiKey {
+	if v, ok := ctx.Get(ctxKeyApiKey); ok {
+		if apiKey, ok := v.(*tables.TableApiKey); ok {
+			return apiKey
+		}
+	}
+	return nil
+}
+
+// api key: dsk_<key_id>_<secret>
+func parseApiKey(key string) (string, bool) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(key, apiKeyPrefix), "_")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+	return parts[0], true
+}
+
+func getApiKeyHash(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(hash[:])
+}
@@ -0,0 +1,85 @@
+package handle
+
+import (
+	"das_sub_account/tables"
+	"encoding/json"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseApiKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		wantKeyId string
+		wantOk    bool
+	}{
+		{"dsk_abc123_secret", "abc123", true},
+		{"abc123_secret", "", false},
+		{"DSK_abc123_secret", "", false},
+		{"dsk_abc123", "", false},
+		{"dsk__secret", "", false},
+		{"dsk_abc123_", "", false},
+		{"dsk_abc_123_secret", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			if keyId, ok := parseApiKey(tt.key); keyId != tt.wantKeyId || ok != tt.wantOk {
+				t.Fatal(keyId, ok)
+			}
+		})
+	}
+}
+
+func TestCheckApiKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// the requests of the cases are rejected or passed on before the db
+	h := &HttpHandle{}
+	engine := gin.New()
+	engine.POST("/v1/mint/config/update", h.CheckApiKey(tables.ApiKeyScopeMintConfigManage), func(ctx *gin.Context) {
+		var req ReqCheckApiKey
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			ctx.JSON(http.StatusOK, api_code.ApiResp{ErrNo: api_code.ApiCodeParamsInvalid})
+			return
+		}
+		ctx.JSON(http.StatusOK, api_code.ApiResp{Data: getCtxApiKey(ctx) != nil})
+	})
+
+	tests := []struct {
+		name      string
+		key       string
+		body      string
+		wantErrNo api_code.ApiCode
+	}{
+		{"no key passed on", "", `{"account":"test.bit"}`, 0},
+		{"no key the body is left to the handler", "", `{}`, api_code.ApiCodeParamsInvalid},
+		{"account missing", "dsk_abc123_secret", `{}`, api_code.ApiCodeParamsInvalid},
+		{"body invalid", "dsk_abc123_secret", `account`, api_code.ApiCodeParamsInvalid},
+		{"key invalid", "abc123_secret", `{"account":"test.bit"}`, api_code.ApiCodeUnauthorized},
+		{"key without secret", "dsk_abc123", `{"account":"test.bit"}`, api_code.ApiCodeUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/mint/config/update", strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set(HeaderApiKey, tt.key)
+			}
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, req)
+			var resp api_code.ApiResp
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err, w.Body.String())
+			}
+			if resp.ErrNo != tt.wantErrNo {
+				t.Fatal(resp.ErrNo, resp.ErrMsg)
+			}
+			if resp.ErrNo == 0 && resp.Data != false {
+				t.Fatal(resp.Data)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package handle
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"das_sub_account/config"
+	"encoding/hex"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderInternalCaller    = "X-Internal-Caller"
+	HeaderInternalTimestamp = "X-Internal-Timestamp"
+	HeaderInternalNonce     = "X-Internal-Nonce"
+	HeaderInternalSignature = "X-Internal-Signature"
+	defaultInternalMaxSkew  = 300
+	maxInternalNonceLen     = 64
+	ctxKeyInternalCaller    = "internal_caller"
+	// the caller recorded when the internal auth is disabled
+	defaultInternalCaller = "internal"
+)
+
+// CheckInternalAuth authenticates the caller of the internal api by the hmac signature of the request,
+// the caller must be allowed to the route, the timestamp within the max skew and the nonce used only once.
+// The signature is hex(hmac_sha256(secret, method\npath\ntimestamp\nnonce\nhex(sha256(body))))
+func (h *HttpHandle) CheckInternalAuth(ctx *gin.Context) {
+	if !config.Cfg.InternalAuth.Enable {
+		ctx.Next()
+		return
+	}
+
+	var (
+		apiResp                api_code.ApiResp
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		route                  = ctx.FullPath()
+		callerName             = ctx.GetHeader(HeaderInternalCaller)
+	)
+	deny := func(msg string) {
+		log.Warn("CheckInternalAuth denied:", callerName, route, msg, clientIp, remoteAddrIP)
+		apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
+		ctx.JSON(http.StatusOK, apiResp)
+		ctx.Abort()
+	}
+
+	caller := getInternalCaller(callerName)
+	if caller == nil {
+		deny("unknown caller")
+		return
+	}
+	if !caller.isAllowed(route) {
+		deny("route not allowed")
+		return
+	}
+
+	maxSkew := config.Cfg.InternalAuth.MaxSkew
+	if maxSkew <= 0 {
+		maxSkew = defaultInternalMaxSkew
+	}
+	timestamp := ctx.GetHeader(HeaderInternalTimestamp)
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		deny("timestamp invalid")
+		return
+	}
+	if skew := time.Now().Unix() - ts; skew > maxSkew || skew < -maxSkew {
+		deny("timestamp expired")
+		return
+	}
+	nonce := ctx.GetHeader(HeaderInternalNonce)
+	if nonce == "" || len(nonce) > maxInternalNonceLen {
+		deny("nonce invalid")
+		return
+	}
+
+	body, err := ctx.GetRawData()
+	if err != nil {
+		deny("failed to read body")
+		return
+	}
+	ctx.Set(gin.BodyBytesKey, body)
+	restoreRequestBody(ctx)
+	sign := GetInternalSignature(caller.Secret, ctx.Request.Method, route, timestamp, nonce, body)
+	if !hmac.Equal([]byte(sign), []byte(ctx.GetHeader(HeaderInternalSignature))) {
+		deny("signature invalid")
+		return
+	}
+
+	// checked last, so a forged request can't burn the nonce of the caller
+	if ok, err := h.RC.SetInternalNonce(caller.Name, nonce, time.Duration(maxSkew*2)*time.Second); err != nil {
+		log.Error("SetInternalNonce err:", err.Error(), caller.Name)
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		ctx.JSON(http.StatusOK, apiResp)
+		ctx.Abort()
+		return
+	} else if !ok {
+		deny("nonce replayed")
+		return
+	}
+	ctx.Set(ctxKeyInternalCaller, caller.Name)
+	ctx.Next()
+}
+
+// getCtxInternalCaller the name of the caller authenticated by CheckInternalAuth
+func getCtxInternalCaller(ctx *gin.Context) string {
+	if name := ctx.GetString(ctxKeyInternalCaller); name != "" {
+		return name
+	}
+	return defaultInternalCaller
+}
+
+type internalCaller config.InternalCaller
+
+func getInternalCaller(name string) *internalCaller {
+	if name == "" {
+		return nil
+	}
+	for _, v := range config.Cfg.InternalAuth.Callers {
+		if v.Name == name && v.Secret != "" {
+			caller := internalCaller(v)
+			return &caller
+		}
+	}
+	return nil
+}
+
+func (c *internalCaller) isAllowed(route string) bool {
+	for _, v := range c.Routes {
+		if v == route {
+			return true
+		}
+	}
+	return false
+}
+
+// GetInternalSignature the signature of the internal request, the path is the route without the query
+func GetInternalSignature(secret, method, path, timestamp, nonce string, body []byte) string {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = io.WriteString(mac, fmt.Sprintf("%s\n%s\n%s\n%s\n%s", method, path, timestamp, nonce, hex.EncodeToString(bodyHash[:])))
+	return hex.EncodeToString(mac.Sum(nil))
+}
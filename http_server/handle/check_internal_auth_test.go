@@ -0,0 +1,237 @@
+package handle
+
+import (
+	"bufio"
+	"bytes"
+	"das_sub_account/cache"
+	"das_sub_account/config"
+	"encoding/json"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestRedisCache a redis cache backed by an in-memory server of the commands used by the middlewares: set (nx), get, exists
+func newTestRedisCache(t *testing.T) *cache.RedisCache {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lock sync.Mutex
+	data := make(map[string]string)
+	serve := func(conn net.Conn) {
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		for {
+			args, err := readTestRedisCommand(reader)
+			if err != nil {
+				return
+			}
+			lock.Lock()
+			var reply string
+			switch strings.ToLower(args[0]) {
+			case "set":
+				nx := false
+				for _, v := range args[3:] {
+					nx = nx || strings.EqualFold(v, "nx")
+				}
+				if _, ok := data[args[1]]; ok && nx {
+					reply = "$-1\r\n"
+				} else {
+					data[args[1]] = args[2]
+					reply = "+OK\r\n"
+				}
+			case "get":
+				if v, ok := data[args[1]]; ok {
+					reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+				} else {
+					reply = "$-1\r\n"
+				}
+			case "exists":
+				num := 0
+				for _, v := range args[1:] {
+					if _, ok := data[v]; ok {
+						num++
+					}
+				}
+				reply = fmt.Sprintf(":%d\r\n", num)
+			default:
+				reply = "+OK\r\n"
+			}
+			lock.Unlock()
+			if _, err := io.WriteString(conn, reply); err != nil {
+				return
+			}
+		}
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serve(conn)
+		}
+	}()
+
+	red := redis.NewClient(&redis.Options{Addr: ln.Addr().String()})
+	t.Cleanup(func() {
+		_ = red.Close()
+		_ = ln.Close()
+	})
+	return &cache.RedisCache{Red: red}
+}
+
+func readTestRedisCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	num, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || num <= 0 {
+		return nil, fmt.Errorf("invalid command: %s", line)
+	}
+	args := make([]string, 0, num)
+	for i := 0; i < num; i++ {
+		if line, err = reader.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "$")))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+	return args, nil
+}
+
+func newTestInternalRequest(caller, secret, path, timestamp, nonce string, body []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set(HeaderInternalCaller, caller)
+	req.Header.Set(HeaderInternalTimestamp, timestamp)
+	req.Header.Set(HeaderInternalNonce, nonce)
+	req.Header.Set(HeaderInternalSignature, GetInternalSignature(secret, http.MethodPost, path, timestamp, nonce, body))
+	return req
+}
+
+func TestCheckInternalAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.Cfg.InternalAuth
+	defer func() { config.Cfg.InternalAuth = cfg }()
+	config.Cfg.InternalAuth.Enable = true
+	config.Cfg.InternalAuth.MaxSkew = 60
+	config.Cfg.InternalAuth.Callers = []config.InternalCaller{
+		{Name: "ops", Secret: "ops-secret", Routes: []string{"/v1/internal/recycle/account"}},
+	}
+
+	h := &HttpHandle{RC: newTestRedisCache(t)}
+	engine := gin.New()
+	for _, path := range []string{"/v1/internal/recycle/account", "/v1/internal/suspension/create"} {
+		engine.POST(path, h.CheckInternalAuth, func(ctx *gin.Context) {
+			var body map[string]string
+			if err := ctx.ShouldBindJSON(&body); err != nil {
+				ctx.JSON(http.StatusOK, api_code.ApiResp{ErrNo: api_code.ApiCodeParamsInvalid})
+				return
+			}
+			ctx.JSON(http.StatusOK, api_code.ApiResp{Data: getCtxInternalCaller(ctx)})
+		})
+	}
+
+	body := []byte(`{"sub_account_ids":"0x01"}`)
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	tests := []struct {
+		name       string
+		req        *http.Request
+		wantErrNo  api_code.ApiCode
+		wantCaller string
+	}{
+		{
+			name:       "valid",
+			req:        newTestInternalRequest("ops", "ops-secret", "/v1/internal/recycle/account", now, "nonce-1", body),
+			wantCaller: "ops",
+		},
+		{
+			name:      "replayed nonce",
+			req:       newTestInternalRequest("ops", "ops-secret", "/v1/internal/recycle/account", now, "nonce-1", body),
+			wantErrNo: api_code.ApiCodeUnauthorized,
+		},
+		{
+			name:      "bad signature",
+			req:       newTestInternalRequest("ops", "other-secret", "/v1/internal/recycle/account", now, "nonce-2", body),
+			wantErrNo: api_code.ApiCodeUnauthorized,
+		},
+		{
+			name:      "skewed timestamp",
+			req:       newTestInternalRequest("ops", "ops-secret", "/v1/internal/recycle/account", strconv.FormatInt(time.Now().Unix()-61, 10), "nonce-3", body),
+			wantErrNo: api_code.ApiCodeUnauthorized,
+		},
+		{
+			name:      "future timestamp",
+			req:       newTestInternalRequest("ops", "ops-secret", "/v1/internal/recycle/account", strconv.FormatInt(time.Now().Unix()+61, 10), "nonce-4", body),
+			wantErrNo: api_code.ApiCodeUnauthorized,
+		},
+		{
+			name:      "route not allowed",
+			req:       newTestInternalRequest("ops", "ops-secret", "/v1/internal/suspension/create", now, "nonce-5", body),
+			wantErrNo: api_code.ApiCodeUnauthorized,
+		},
+		{
+			name:      "unknown caller",
+			req:       newTestInternalRequest("other", "ops-secret", "/v1/internal/recycle/account", now, "nonce-6", body),
+			wantErrNo: api_code.ApiCodeUnauthorized,
+		},
+		{
+			name:      "nonce missing",
+			req:       newTestInternalRequest("ops", "ops-secret", "/v1/internal/recycle/account", now, "", body),
+			wantErrNo: api_code.ApiCodeUnauthorized,
+		},
+		{
+			// the nonce of the bad signature is not burnt
+			name:       "valid after a forged request",
+			req:        newTestInternalRequest("ops", "ops-secret", "/v1/internal/recycle/account", now, "nonce-2", body),
+			wantCaller: "ops",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			engine.ServeHTTP(w, tt.req)
+			var resp api_code.ApiResp
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err, w.Body.String())
+			}
+			if resp.ErrNo != tt.wantErrNo {
+				t.Fatal(resp.ErrNo, resp.ErrMsg)
+			}
+			if tt.wantCaller != "" && resp.Data != tt.wantCaller {
+				t.Fatal(resp.Data)
+			}
+		})
+	}
+
+	// the auth disabled passes the requests as the default caller
+	config.Cfg.InternalAuth.Enable = false
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/internal/suspension/create", bytes.NewReader(body)))
+	var resp api_code.ApiResp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ErrNo != 0 || resp.Data != defaultInternalCaller {
+		t.Fatal(resp.ErrNo, resp.Data)
+	}
+}
@@ -1,24 +1,47 @@
 package handle
 
 import (
-	"das_sub_account/config"
+	"das_sub_account/tables"
 	"errors"
+	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	api_code "github.com/dotbitHQ/das-lib/http_api"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
-	"github.com/golang-jwt/jwt/v5"
 	"net/http"
 	"strings"
 )
 
 type ReqCheckPermissions struct {
 	core.ChainTypeAddress
-	Account string `json:"account" binding:"required"`
+	Account  string `json:"account"`
+	Referrer string `json:"referrer"`
 }
 
+// CheckPermissions only the owner and the manager
 func (h *HttpHandle) CheckPermissions(ctx *gin.Context) {
+	h.checkPermissions(ctx, nil, false)
+}
+
+// CheckPermissionsWithRoles the owner, the manager and the addresses granted one of the roles
+func (h *HttpHandle) CheckPermissionsWithRoles(roles ...tables.AccountRole) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		h.checkPermissions(ctx, roles, false)
+	}
+}
+
+// CheckReferrerPermissions only the owner and the manager of the referrer account of the request
+func (h *HttpHandle) CheckReferrerPermissions(ctx *gin.Context) {
+	h.checkPermissions(ctx, nil, true)
+}
+
+func (h *HttpHandle) checkPermissions(ctx *gin.Context, roles []tables.AccountRole, byReferrer bool) {
+	// already authenticated by CheckApiKey
+	if getCtxApiKey(ctx) != nil {
+		return
+	}
+
 	var apiResp api_code.ApiResp
 	defer func() {
 		if apiResp.ErrNo != 0 {
@@ -27,7 +50,7 @@ func (h *HttpHandle) CheckPermissions(ctx *gin.Context) {
 		}
 	}()
 
-	tokenVal, err := ctx.Cookie("token")
+	tokenVal, err := ctx.Cookie(jwtCookieAccess)
 	if err != nil {
 		if errors.Is(err, http.ErrNoCookie) {
 			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
@@ -37,20 +60,13 @@ func (h *HttpHandle) CheckPermissions(ctx *gin.Context) {
 		return
 	}
 
-	claims := &Claims{}
-	tkn, err := jwt.ParseWithClaims(tokenVal, claims, func(token *jwt.Token) (any, error) {
-		return []byte(config.Cfg.Das.JwtKey), nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	claims, err := h.parseJwtToken(tokenVal, JwtTokenTypeAccess)
 	if err != nil {
-		if errors.Is(err, jwt.ErrSignatureInvalid) {
+		if errors.Is(err, errJwtUnauthorized) {
 			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
 			return
 		}
-		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
-		return
-	}
-	if !tkn.Valid {
-		apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
 		return
 	}
 
@@ -59,19 +75,32 @@ func (h *HttpHandle) CheckPermissions(ctx *gin.Context) {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
 		return
 	}
-	addrHex, err := req.FormatChainTypeAddress(h.DasCore.NetType(), false)
-	if err != nil {
+	restoreRequestBody(ctx)
+	account := req.Account
+	if byReferrer {
+		account = req.Referrer
+	}
+	if account == "" {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
 		return
 	}
-	address := common.FormatAddressPayload(addrHex.AddressPayload, addrHex.DasAlgorithmId)
 
-	if !strings.EqualFold(address, claims.Address) {
-		apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
-		return
+	// the dashboard reads don't carry the address, the one in the token is used
+	address := claims.Address
+	if req.KeyInfo.Key != "" {
+		addrHex, err := req.FormatChainTypeAddress(h.DasCore.NetType(), false)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+			return
+		}
+		address = common.FormatAddressPayload(addrHex.AddressPayload, addrHex.DasAlgorithmId)
+		if !strings.EqualFold(address, claims.Address) {
+			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
+			return
+		}
 	}
 
-	accId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	accId := common.Bytes2Hex(common.GetAccountIdByAccount(strings.ToLower(account)))
 	accInfo, err := h.DbDao.GetAccountInfoByAccountId(accId)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query parent account")
@@ -85,8 +114,37 @@ func (h *HttpHandle) CheckPermissions(ctx *gin.Context) {
 		apiResp.ApiRespErr(api_code.ApiCodeParentAccountExpired, "account expired")
 		return
 	}
-	if !strings.EqualFold(address, accInfo.Owner) && !strings.EqualFold(address, accInfo.Manager) {
+	if ok, err := h.hasAccountPermission(accInfo, address, roles); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query account role")
+		return
+	} else if !ok {
 		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "permission denied")
 		return
 	}
 }
+
+// hasAccountPermission the owner and the manager have all the roles
+func (h *HttpHandle) hasAccountPermission(acc tables.TableAccountInfo, address string, roles []tables.AccountRole) (bool, error) {
+	if strings.EqualFold(address, acc.Owner) || strings.EqualFold(address, acc.Manager) {
+		return true, nil
+	}
+	if len(roles) == 0 {
+		return false, nil
+	}
+	list, err := h.DbDao.GetAccountRoleListByAddress(acc.AccountId, address)
+	if err != nil {
+		return false, fmt.Errorf("GetAccountRoleListByAddress err: %s", err.Error())
+	}
+	for _, v := range list {
+		// the roles granted by a former owner are not valid any more
+		if !v.IsIssuedByOwner(acc) {
+			continue
+		}
+		for _, role := range roles {
+			if v.Role == role {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
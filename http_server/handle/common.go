@@ -58,6 +58,7 @@ type SignInfoCache struct {
 	Account   string                             `json:"account"`
 	Capacity  uint64                             `json:"capacity"`
 	BuilderTx *txbuilder.DasTxBuilderTransaction `json:"builder_tx"`
+	Audit     *AuditInfo                         `json:"audit,omitempty"`
 }
 
 func (s *SignInfoCache) SignKey() string {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"das_sub_account/config"
 	"das_sub_account/internal"
+	"das_sub_account/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
@@ -91,6 +92,7 @@ func (h *HttpHandle) doConfigAutoMintUpdate(ctx context.Context, req *ReqConfigA
 		address:   res.AddressHex,
 		action:    action,
 		account:   req.Account,
+		audit:     newAuditInfo(tables.AuditActionAutoMintUpdate, h.getAuditAutoMint(req.Account), auditAutoMint{Enable: req.Enable}),
 	})
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "buildTx err: "+err.Error())
@@ -28,12 +28,14 @@ type RespCouponCodeList struct {
 	ExpiredAt int64            `json:"expired_at"`
 	CreatedAt int64            `json:"created_at"`
 	List      []RespCouponCode `json:"list"`
+	CouponTypeInfo
 }
 
 type RespCouponCode struct {
-	Code   string              `json:"code"`
-	UsedBy string              `json:"used_by"`
-	Status tables.CouponStatus `json:"status"`
+	Code    string              `json:"code"`
+	UsedBy  string              `json:"used_by"`
+	UsedNum int64               `json:"used_num"`
+	Status  tables.CouponStatus `json:"status"`
 }
 
 func (h *HttpHandle) CouponCodeList(ctx *gin.Context) {
@@ -88,12 +90,15 @@ func (h *HttpHandle) doCouponCodeList(ctx context.Context, req *ReqCouponCodeLis
 		CreatedAt: setInfo.CreatedAt.UnixMilli(),
 		List:      make([]RespCouponCode, 0),
 	}
+	resp.CouponTypeInfo = getCouponTypeInfo(&setInfo)
 	for _, v := range couponList {
 		couponInfo := RespCouponCode{
-			Code:   v.Code,
-			Status: v.Status,
+			Code:    v.Code,
+			UsedNum: v.UsedNum,
+			Status:  v.Status,
 		}
-		if v.Status == tables.CouponStatusUsed {
+		// the multi-use codes are used by more than one account
+		if v.Status == tables.CouponStatusUsed && setInfo.GetMaxRedemption() == 1 {
 			order, err := h.DbDao.GetOrderByCoupon(v.Code)
 			if err != nil {
 				apiResp.ApiRespErr(api_code.ApiCodeDbError, err.Error())
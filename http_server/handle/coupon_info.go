@@ -9,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -16,6 +17,11 @@ type ReqCouponInfo struct {
 	core.ChainTypeAddress
 	Code     string `json:"code" binding:"required"`
 	clientIP string
+
+	// the order to check whether the coupon applies to, optional
+	SubAccount string            `json:"sub_account"`
+	ActionType tables.ActionType `json:"action_type"`
+	Years      uint64            `json:"years"`
 }
 
 type RespCouponInfo struct {
@@ -24,6 +30,29 @@ type RespCouponInfo struct {
 	BeginAt   int64               `json:"begin_at"`
 	ExpiredAt int64               `json:"expired_at"`
 	Status    tables.CouponStatus `json:"status"`
+	UsedNum   int64               `json:"used_num"`
+	CouponTypeInfo
+	// Applicable whether the coupon applies to the sub_account of the request, Reason is why not
+	Applicable bool   `json:"applicable"`
+	Reason     string `json:"reason"`
+}
+
+type CouponTypeInfo struct {
+	Type          tables.CouponType         `json:"coupon_type"`
+	Discount      string                    `json:"discount"`
+	MaxRedemption int64                     `json:"max_redemption"`
+	MaxPerAddress int64                     `json:"max_per_address"`
+	Restriction   *tables.CouponRestriction `json:"restriction"`
+}
+
+func getCouponTypeInfo(setInfo *tables.CouponSetInfo) CouponTypeInfo {
+	return CouponTypeInfo{
+		Type:          setInfo.Type,
+		Discount:      setInfo.Discount.String(),
+		MaxRedemption: setInfo.GetMaxRedemption(),
+		MaxPerAddress: setInfo.MaxPerAddress,
+		Restriction:   setInfo.Restriction,
+	}
 }
 
 func (h *HttpHandle) CouponInfo(ctx *gin.Context) {
@@ -85,11 +114,29 @@ func (h *HttpHandle) doCouponInfo(ctx context.Context, req *ReqCouponInfo, apiRe
 	}
 
 	resp := &RespCouponInfo{
-		Code:      req.Code,
-		Price:     setInfo.Price.String(),
-		BeginAt:   setInfo.BeginAt,
-		ExpiredAt: setInfo.ExpiredAt,
-		Status:    couponInfo.Status,
+		Code:           req.Code,
+		Price:          setInfo.Price.String(),
+		BeginAt:        setInfo.BeginAt,
+		ExpiredAt:      setInfo.ExpiredAt,
+		Status:         couponInfo.Status,
+		UsedNum:        couponInfo.UsedNum,
+		CouponTypeInfo: getCouponTypeInfo(&setInfo),
+	}
+	if req.SubAccount != "" {
+		if req.Years == 0 {
+			req.Years = 1
+		}
+		req.SubAccount = strings.ToLower(req.SubAccount)
+		if !strings.HasSuffix(req.SubAccount, "."+setInfo.Account) {
+			resp.Reason = "this coupon code can not use, because it not belong to this account"
+		} else {
+			var checkResp api_code.ApiResp
+			if err := h.checkCouponApplicable(&setInfo, setInfo.Account, req.SubAccount, req.ActionType, req.Years, &checkResp); err != nil {
+				*apiResp = checkResp
+				return err
+			}
+			resp.Applicable, resp.Reason = checkResp.ErrNo == api_code.ApiCodeSuccess, checkResp.ErrMsg
+		}
 	}
 	apiResp.ApiRespOK(resp)
 	return nil
@@ -5,6 +5,7 @@ import (
 	"crypto/md5"
 	"das_sub_account/cache"
 	"das_sub_account/config"
+	sub_api_code "das_sub_account/http_server/api_code"
 	"das_sub_account/internal"
 	"das_sub_account/tables"
 	"das_sub_account/unipay"
@@ -28,7 +29,8 @@ import (
 const couponCreateLockKey = "coupon:create:"
 
 var (
-	priceReg = regexp.MustCompile(`^(\d+)(.\d{0,2})?$`)
+	priceReg    = regexp.MustCompile(`^(\d+)(.\d{0,2})?$`)
+	discountReg = regexp.MustCompile(`^0\.\d{1,2}$`)
 )
 
 type ReqCouponOrderCreate struct {
@@ -42,6 +44,13 @@ type ReqCouponOrderCreate struct {
 	Price     string         `json:"price" binding:"required"`
 	BeginAt   int64          `json:"begin_at"`
 	ExpiredAt int64          `json:"expired_at" binding:"required"`
+	// Type the price is the max off of the percentage coupons, the floor price of each year of the floor coupons
+	Type          tables.CouponType `json:"coupon_type"`
+	Discount      string            `json:"discount"`
+	MaxRedemption int64             `json:"max_redemption" binding:"min=0,max=10000"`
+	MaxPerAddress int64             `json:"max_per_address" binding:"min=0"`
+	// Restriction the orders the coupon applies to
+	Restriction *tables.CouponRestriction `json:"restriction"`
 }
 
 type RespCouponOrderCreate struct {
@@ -105,7 +114,17 @@ func (h *HttpHandle) doCouponOrderCreate(ctx context.Context, req *ReqCouponOrde
 		return nil
 	}
 
-	usdAmount := decimal.NewFromFloat(config.Cfg.Das.Coupon.CouponPrice).Mul(decimal.NewFromInt(int64(req.Num)))
+	setInfo := tables.CouponSetInfo{
+		Num:           req.Num,
+		Type:          req.Type,
+		Discount:      res.discount,
+		MaxRedemption: req.MaxRedemption,
+		MaxPerAddress: req.MaxPerAddress,
+	}
+	if !req.Restriction.IsEmpty() {
+		setInfo.Restriction = req.Restriction
+	}
+	usdAmount := decimal.NewFromFloat(config.Cfg.Das.Coupon.CouponPrice).Mul(setInfo.GetBillingNum()).Round(2)
 	amount := usdAmount.Mul(decimal.New(1, res.tokenPrice.Decimals)).Div(res.tokenPrice.Price).Ceil()
 	if amount.LessThanOrEqual(decimal.Zero) {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, fmt.Sprintf("price err: %s", amount.String()))
@@ -201,27 +220,25 @@ func (h *HttpHandle) doCouponOrderCreate(ctx context.Context, req *ReqCouponOrde
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "price invalid")
 		return nil
 	}
-	setInfo := tables.CouponSetInfo{
-		OrderId:       createOrderRes.OrderId,
-		AccountId:     res.accId,
-		Account:       req.Account,
-		ManagerAid:    int(hexAddr.DasAlgorithmId),
-		ManagerSubAid: int(hexAddr.DasSubAlgorithmId),
-		Manager:       hexAddr.AddressHex,
-		Name:          req.Name,
-		Note:          req.Note,
-		Price:         price,
-		Num:           req.Num,
-		BeginAt:       req.BeginAt,
-		ExpiredAt:     req.ExpiredAt,
-		Status:        tables.CouponSetInfoStatusCreated,
-	}
+	setInfo.OrderId = createOrderRes.OrderId
+	setInfo.AccountId = res.accId
+	setInfo.Account = req.Account
+	setInfo.ManagerAid = int(hexAddr.DasAlgorithmId)
+	setInfo.ManagerSubAid = int(hexAddr.DasSubAlgorithmId)
+	setInfo.Manager = hexAddr.AddressHex
+	setInfo.Name = req.Name
+	setInfo.Note = req.Note
+	setInfo.Price = price
+	setInfo.BeginAt = req.BeginAt
+	setInfo.ExpiredAt = req.ExpiredAt
+	setInfo.Status = tables.CouponSetInfoStatusCreated
 	setInfo.InitCid()
 
 	if err = h.DbDao.CreateOrderInfo(orderInfo, oldOrder, paymentInfo, setInfo); err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to create order")
 		return fmt.Errorf("CreateOrderInfo err: %s", err.Error())
 	}
+	h.addAuditLogByAddress(ctx, req.Account, hexAddr.AddressHex, newAuditInfo(tables.AuditActionCouponCreate, nil, setInfo), "")
 
 	if req.TokenId == tables.TokenIdDp {
 		amount = usdAmount
@@ -242,6 +259,7 @@ type checkCreateParamsResp struct {
 	accId      string
 	dasAddr    *core.DasAddressHex
 	tokenPrice tables.TTokenPriceInfo
+	discount   decimal.Decimal
 }
 
 func (h *HttpHandle) couponCreateParamsCheck(req *ReqCouponOrderCreate, apiResp *api_code.ApiResp) *checkCreateParamsResp {
@@ -296,6 +314,36 @@ func (h *HttpHandle) couponCreateParamsCheck(req *ReqCouponOrderCreate, apiResp
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "price invalid")
 		return nil
 	}
+	var discount decimal.Decimal
+	switch req.Type {
+	case tables.CouponTypePercent:
+		if !discountReg.MatchString(req.Discount) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "discount invalid")
+			return nil
+		}
+		discount, _ = decimal.NewFromString(req.Discount)
+		if discount.LessThanOrEqual(decimal.Zero) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "discount invalid")
+			return nil
+		}
+	case tables.CouponTypeFixed, tables.CouponTypeFloor:
+		// the price off grows with the years, which are billed by max_years
+		if req.Restriction == nil || req.Restriction.MaxYears == 0 {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "restriction.max_years is required")
+			return nil
+		}
+	case tables.CouponTypeWaive:
+	default:
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "coupon_type invalid")
+		return nil
+	}
+	if req.MaxPerAddress > 0 && req.MaxRedemption > 0 && req.MaxPerAddress > req.MaxRedemption {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "max_per_address must not be more than max_redemption")
+		return nil
+	}
+	if !checkCouponRestriction(req.Restriction, apiResp) {
+		return nil
+	}
 	if req.BeginAt >= req.ExpiredAt {
 		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "begin time must less than expired time")
 		return nil
@@ -313,7 +361,10 @@ func (h *HttpHandle) couponCreateParamsCheck(req *ReqCouponOrderCreate, apiResp
 	}
 	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
 
-	if !strings.EqualFold(accInfo.Owner, address) && !strings.EqualFold(accInfo.Manager, address) {
+	if ok, err := h.hasAccountPermission(accInfo, address, []tables.AccountRole{tables.AccountRoleCouponManager}); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query account role")
+		return nil
+	} else if !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeNoAccountPermissions, "no account permissions")
 		return nil
 	}
@@ -326,9 +377,78 @@ func (h *HttpHandle) couponCreateParamsCheck(req *ReqCouponOrderCreate, apiResp
 		apiResp.ApiRespErr(api_code.ApiCodeTokenIdNotSupported, "payment method not supported")
 		return nil
 	}
+	if tokenPrice.Price, err = h.getFeedPrice(string(req.TokenId), tokenPrice.Price); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodePaymentMethodDisable, fmt.Sprintf("the price of %s is stale, please try another payment method", tokenPrice.Symbol))
+		return nil
+	}
 	return &checkCreateParamsResp{
 		accId:      accountId,
 		dasAddr:    res,
 		tokenPrice: tokenPrice,
+		discount:   discount,
 	}
 }
+
+// checkCouponRestriction the names are formatted to lower case
+func checkCouponRestriction(restriction *tables.CouponRestriction, apiResp *api_code.ApiResp) bool {
+	if restriction.IsEmpty() {
+		return true
+	}
+	if restriction.MaxLength > 0 && restriction.MinLength > restriction.MaxLength {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "min_length must not be more than max_length")
+		return false
+	}
+	for _, v := range restriction.ActionTypes {
+		if v != tables.ActionTypeMint && v != tables.ActionTypeRenew {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "action_types invalid")
+			return false
+		}
+	}
+	if len(restriction.Names) > 10000 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "names must not be more than 10000")
+		return false
+	}
+	for i, v := range restriction.Names {
+		restriction.Names[i] = strings.ToLower(strings.TrimSpace(v))
+		if restriction.Names[i] == "" || strings.Contains(restriction.Names[i], ".") {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("name [%s] invalid, without the parent account", v))
+			return false
+		}
+	}
+	for _, v := range restriction.PriceRules {
+		if v == "" {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "price_rules invalid")
+			return false
+		}
+	}
+	return true
+}
+
+// checkCouponApplicable returns ApiCodeCouponNotApplicable with the reason if the coupon does not apply to the order
+func (h *HttpHandle) checkCouponApplicable(setInfo *tables.CouponSetInfo, parentAccount, subAccount string, actionType tables.ActionType, years uint64, apiResp *api_code.ApiResp) error {
+	if setInfo.Restriction.IsEmpty() {
+		return nil
+	}
+	ruleName := ""
+	if len(setInfo.Restriction.PriceRules) > 0 {
+		var checkResp api_code.ApiResp
+		_, rulePrice, err := h.getSubAccountRules(parentAccount, setInfo.AccountId, &checkResp)
+		if err != nil {
+			*apiResp = checkResp
+			return err
+		}
+		if rulePrice != nil {
+			hit, index, err := rulePrice.Hit(subAccount)
+			if err != nil {
+				apiResp.ApiRespErr(api_code.ApiCodeError500, "Failed to match rules")
+				return fmt.Errorf("rulePrice.Hit err: %s", err.Error())
+			} else if hit {
+				ruleName = rulePrice.Rules[index].Name
+			}
+		}
+	}
+	if reason := setInfo.Restriction.Check(subAccount, actionType, years, ruleName); reason != "" {
+		apiResp.ApiRespErr(sub_api_code.ApiCodeCouponNotApplicable, reason)
+	}
+	return nil
+}
@@ -37,6 +37,8 @@ type RespCouponSetInfo struct {
 	BeginAt   int64  `json:"begin_at"`
 	ExpiredAt int64  `json:"expired_at"`
 	CreatedAt int64  `json:"created_at"`
+	Redeemed  int64  `json:"redeemed"`
+	CouponTypeInfo
 }
 
 func (h *HttpHandle) CouponSetList(ctx *gin.Context) {
@@ -99,19 +101,25 @@ func (h *HttpHandle) doCouponSetList(ctx context.Context, req *ReqCouponSetList,
 			if err != nil {
 				return err
 			}
+			redeemed, err := h.DbDao.GetRedeemedCoupon(setInfo[idx].Cid)
+			if err != nil {
+				return err
+			}
 			resp.List = append(resp.List, RespCouponSetInfo{
-				Cid:       v.Cid,
-				OrderId:   v.OrderId,
-				Account:   v.Account,
-				Name:      v.Name,
-				Note:      v.Note,
-				Price:     v.Price.String(),
-				Num:       v.Num,
-				Used:      used,
-				Status:    v.Status,
-				BeginAt:   v.BeginAt,
-				ExpiredAt: v.ExpiredAt,
-				CreatedAt: v.CreatedAt.UnixMilli(),
+				Cid:            v.Cid,
+				OrderId:        v.OrderId,
+				Account:        v.Account,
+				Name:           v.Name,
+				Note:           v.Note,
+				Price:          v.Price.String(),
+				Num:            v.Num,
+				Used:           used,
+				Status:         v.Status,
+				BeginAt:        v.BeginAt,
+				ExpiredAt:      v.ExpiredAt,
+				CreatedAt:      v.CreatedAt.UnixMilli(),
+				Redeemed:       redeemed,
+				CouponTypeInfo: getCouponTypeInfo(v),
 			})
 		}
 		return nil
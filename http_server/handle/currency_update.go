@@ -6,6 +6,7 @@ import (
 	"das_sub_account/config"
 	"das_sub_account/consts"
 	"das_sub_account/internal"
+	"das_sub_account/tables"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -58,12 +59,48 @@ func (h *HttpHandle) CurrencyUpdate(ctx *gin.Context) {
 	}
 	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
 
+	// requests authenticated by api key are applied without wallet signature
+	if getCtxApiKey(ctx) != nil {
+		if err = h.doCurrencyUpdateByApiKey(ctx.Request.Context(), getCtxApiKey(ctx), &req, &apiResp); err != nil {
+			log.Error("doCurrencyUpdateByApiKey err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		}
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
 	if err = h.doCurrencyUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
 		log.Error("doCurrencyUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
 	}
 	ctx.JSON(http.StatusOK, apiResp)
 }
 
+func (h *HttpHandle) doCurrencyUpdateByApiKey(ctx context.Context, apiKey *tables.TableApiKey, req *ReqCurrencyUpdate, apiResp *api_code.ApiResp) error {
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if !isSupportPaymentToken(req.TokenId) {
+		err := fmt.Errorf("token_id: %s, no support now", req.TokenId)
+		apiResp.ApiRespErr(api_code.ApiCodeNoSupportPaymentToken, err.Error())
+		return err
+	}
+	auditInfo, err := h.saveCurrencyConfig(req, apiResp)
+	if err != nil {
+		return err
+	}
+	h.addAuditLogByApiKey(ctx, req.Account, apiKey, auditInfo)
+	apiResp.ApiRespOK(nil)
+	return nil
+}
+
+func isSupportPaymentToken(tokenId string) bool {
+	for _, v := range config.Cfg.Das.AutoMint.SupportPaymentToken {
+		if v == tokenId {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *HttpHandle) doCurrencyUpdate(ctx context.Context, req *ReqCurrencyUpdate, apiResp *api_code.ApiResp) error {
 	var resp RespCurrencyUpdate
 	resp.List = make([]SignInfo, 0)
@@ -92,14 +129,7 @@ func (h *HttpHandle) doCurrencyUpdate(ctx context.Context, req *ReqCurrencyUpdat
 		return nil
 	}
 
-	find := false
-	for _, v := range config.Cfg.Das.AutoMint.SupportPaymentToken {
-		if v == req.TokenId {
-			find = true
-			break
-		}
-	}
-	if !find {
+	if !isSupportPaymentToken(req.TokenId) {
 		err := fmt.Errorf("token_id: %s, no support now", req.TokenId)
 		apiResp.ApiRespErr(api_code.ApiCodeNoSupportPaymentToken, err.Error())
 		return err
@@ -188,6 +188,7 @@ type paramBuildTx struct {
 	subAction  common.SubAction
 	account    string
 	evmChainId int64
+	audit      *AuditInfo
 }
 
 func (h *HttpHandle) buildTx(ctx context.Context, p *paramBuildTx) (*SignInfoList, string, error) {
@@ -237,6 +238,7 @@ func (h *HttpHandle) buildTx(ctx context.Context, p *paramBuildTx) (*SignInfoLis
 		Action:    p.action,
 		Account:   p.account,
 		BuilderTx: txBuilder.DasTxBuilderTransaction,
+		Audit:     p.audit,
 	}
 	signKey := sic.SignKey()
 	cacheStr := toolib.JsonString(&sic)
@@ -0,0 +1,288 @@
+package handle
+
+import (
+	"context"
+	"crypto/md5"
+	"das_sub_account/config"
+	"das_sub_account/consts"
+	"das_sub_account/internal"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	maxDiscountTiers = 10
+	maxDiscountYears = 20
+)
+
+var maxDiscount = decimal.NewFromFloat(0.9)
+
+type ReqDiscountConfigUpdate struct {
+	core.ChainTypeAddress
+	Account   string                `json:"account" binding:"required"`
+	Tiers     []tables.DiscountTier `json:"tiers"`
+	Timestamp int64                 `json:"timestamp" binding:"required"`
+}
+
+type RespDiscountConfigUpdate struct {
+	SignInfoList
+}
+
+func (r *ReqDiscountConfigUpdate) GetSignInfo() (signKey, signMsg, reqDataStr string) {
+	reqData, _ := json.Marshal(r)
+	reqDataStr = string(reqData)
+	signKey = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%d", reqDataStr, time.Now().UnixNano()))))
+	signMsg = common.DotBitPrefix + hex.EncodeToString(common.Blake2b(reqData))
+	return
+}
+
+func (h *HttpHandle) DiscountConfigUpdate(ctx *gin.Context) {
+	var (
+		funcName               = "DiscountConfigUpdate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqDiscountConfigUpdate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	// requests authenticated by api key are applied without wallet signature
+	if getCtxApiKey(ctx) != nil {
+		if err = h.doDiscountConfigUpdateByApiKey(ctx.Request.Context(), getCtxApiKey(ctx), &req, &apiResp); err != nil {
+			log.Error("doDiscountConfigUpdateByApiKey err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		}
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	if err = h.doDiscountConfigUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doDiscountConfigUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doDiscountConfigUpdateByApiKey(ctx context.Context, apiKey *tables.TableApiKey, req *ReqDiscountConfigUpdate, apiResp *api_code.ApiResp) error {
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if !checkDiscountTiers(req.Tiers, apiResp) {
+		return nil
+	}
+	auditInfo, err := h.saveDiscountConfig(req, apiResp)
+	if err != nil {
+		return err
+	}
+	h.addAuditLogByApiKey(ctx, req.Account, apiKey, auditInfo)
+	apiResp.ApiRespOK(nil)
+	return nil
+}
+
+func (h *HttpHandle) doDiscountConfigUpdate(ctx context.Context, req *ReqDiscountConfigUpdate, apiResp *api_code.ApiResp) error {
+	var resp RespDiscountConfigUpdate
+	resp.List = make([]SignInfo, 0)
+
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+	res, err := req.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return err
+	}
+	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+
+	action := consts.ActionDiscountUpdate
+	req.Account = strings.ToLower(req.Account)
+	if err := h.check(address, req.Account, action, apiResp); err != nil {
+		return err
+	}
+
+	if time.UnixMilli(req.Timestamp).Add(time.Minute * 10).Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params timestamp invalid")
+		return nil
+	}
+	if !checkDiscountTiers(req.Tiers, apiResp) {
+		return nil
+	}
+
+	//
+	signKey, signMsg, reqDataStr := req.GetSignInfo()
+
+	// cache
+	if err = h.RC.SetSignTxCache(signKey, reqDataStr); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("SetSignTxCache err: %s", err.Error())
+	}
+
+	//
+	signType := res.DasAlgorithmId
+	if signType == common.DasAlgorithmIdEth712 {
+		signType = common.DasAlgorithmIdEth
+	}
+	resp.Action = action
+	resp.SignKey = signKey
+	resp.List = append(resp.List, SignInfo{
+		SignList: []txbuilder.SignData{{
+			SignType: signType,
+			SignMsg:  signMsg,
+		}},
+	})
+	resp.SignList = []txbuilder.SignData{{
+		SignType: signType,
+		SignMsg:  signMsg,
+	}}
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// checkDiscountTiers an empty list removes the discounts
+func checkDiscountTiers(tiers []tables.DiscountTier, apiResp *api_code.ApiResp) bool {
+	if len(tiers) > maxDiscountTiers {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("more than max discount tiers %d", maxDiscountTiers))
+		return false
+	}
+	mapYears := make(map[uint64]struct{})
+	for _, v := range tiers {
+		if v.Years < 2 || v.Years > maxDiscountYears {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("years must be between 2 and %d", maxDiscountYears))
+			return false
+		}
+		if _, ok := mapYears[v.Years]; ok {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("duplicate tier of %d years", v.Years))
+			return false
+		}
+		mapYears[v.Years] = struct{}{}
+		if v.Discount.LessThanOrEqual(decimal.Zero) || v.Discount.GreaterThan(maxDiscount) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("discount must be greater than 0 and not more than %s", maxDiscount))
+			return false
+		}
+	}
+	return true
+}
+
+func (h *HttpHandle) doActionDiscountUpdate(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp) error {
+	var data ReqDiscountConfigUpdate
+	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
+		if err == redis.Nil {
+			apiResp.ApiRespErr(api_code.ApiCodeTxExpired, "sign key not exist(tx expired)")
+		} else {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		}
+		return fmt.Errorf("GetSignTxCache err: %s", err.Error())
+	} else if err = json.Unmarshal([]byte(txStr), &data); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Unmarshal err")
+		return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	res, err := data.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	_, signMsg, _ := data.GetSignInfo()
+	address := ""
+	var signType common.DasAlgorithmId
+	var signature string
+	if len(req.List) != 0 {
+		signType = req.List[0].SignList[0].SignType
+		signature = req.List[0].SignList[0].SignMsg
+	} else {
+		signType = req.SignList[0].SignType
+		signature = req.SignList[0].SignMsg
+	}
+	if signType == common.DasAlgorithmIdWebauthn {
+		address = req.SignAddress
+	} else {
+		address = res.AddressHex
+	}
+	verifyRes, _, err := api_code.VerifySignature(signType, signMsg, signature, address)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "VerifySignature err")
+		return fmt.Errorf("VerifySignature err: %s", err.Error())
+	}
+	if !verifyRes {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
+		return nil
+	}
+	auditInfo, err := h.saveDiscountConfig(&data, apiResp)
+	if err != nil {
+		return err
+	}
+	h.addAuditLogByAddress(ctx, data.Account, address, auditInfo, "")
+	return nil
+}
+
+func (h *HttpHandle) saveDiscountConfig(data *ReqDiscountConfigUpdate, apiResp *api_code.ApiResp) (*AuditInfo, error) {
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
+	before, err := h.DbDao.GetUserDiscountConfig(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return nil, fmt.Errorf("GetUserDiscountConfig err: %s", err.Error())
+	}
+	after := tables.DiscountConfig{Tiers: make([]tables.DiscountTier, 0)}
+	after.Tiers = append(after.Tiers, data.Tiers...)
+	sort.Slice(after.Tiers, func(i, j int) bool {
+		return after.Tiers[i].Years < after.Tiers[j].Years
+	})
+	if err := h.DbDao.CreateUserConfigWithDiscountConfig(tables.UserConfig{
+		Account:   data.Account,
+		AccountId: accountId,
+	}, after); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update discount config")
+		return nil, fmt.Errorf("CreateUserConfigWithDiscountConfig err: %s", err.Error())
+	}
+	return newAuditInfo(tables.AuditActionDiscountUpdate, before, after), nil
+}
+
+// getDiscountPrice the total price of the years after the discount of the parent account,
+// which is not less than the min price of the years required on chain, and the discount applied, 0 means not discounted
+func (h *HttpHandle) getDiscountPrice(parentAccountId string, price, minPrice decimal.Decimal, years uint64) (decimal.Decimal, decimal.Decimal, error) {
+	total := price.Mul(decimal.NewFromInt(int64(years)))
+	discountConfig, err := h.DbDao.GetUserDiscountConfig(parentAccountId)
+	if err != nil {
+		return total, decimal.Zero, fmt.Errorf("GetUserDiscountConfig err: %s", err.Error())
+	}
+	total, discount := calDiscountPrice(&discountConfig, price, minPrice, years)
+	return total, discount, nil
+}
+
+// calDiscountPrice the discount is 0 if the total is not discounted
+func calDiscountPrice(discountConfig *tables.DiscountConfig, price, minPrice decimal.Decimal, years uint64) (decimal.Decimal, decimal.Decimal) {
+	total := price.Mul(decimal.NewFromInt(int64(years)))
+	discount := discountConfig.GetDiscount(years)
+	if discount.LessThanOrEqual(decimal.Zero) {
+		return total, decimal.Zero
+	}
+	discountTotal := total.Mul(decimal.NewFromInt(1).Sub(discount)).Round(2)
+	if minTotal := minPrice.Mul(decimal.NewFromInt(int64(years))); discountTotal.LessThan(minTotal) {
+		discountTotal = decimal.Min(minTotal, total)
+	}
+	if !discountTotal.LessThan(total) {
+		return total, decimal.Zero
+	}
+	return discountTotal, discount
+}
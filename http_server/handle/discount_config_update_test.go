@@ -0,0 +1,40 @@
+package handle
+
+import (
+	"das_sub_account/tables"
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func TestCalDiscountPrice(t *testing.T) {
+	discountConfig := tables.DiscountConfig{Tiers: []tables.DiscountTier{
+		{Years: 3, Discount: decimal.NewFromFloat(0.1)},
+		{Years: 5, Discount: decimal.NewFromFloat(0.2)},
+	}}
+	tests := []struct {
+		name         string
+		config       *tables.DiscountConfig
+		price        float64
+		minPrice     float64
+		years        uint64
+		wantTotal    string
+		wantDiscount string
+	}{
+		{"no config", nil, 10, 0.99, 3, "30", "0"},
+		{"below the first tier", &discountConfig, 10, 0.99, 2, "20", "0"},
+		{"the first tier", &discountConfig, 10, 0.99, 3, "27", "0.1"},
+		{"between the tiers", &discountConfig, 10, 0.99, 4, "36", "0.1"},
+		{"the most years tier", &discountConfig, 10, 0.99, 6, "48", "0.2"},
+		{"rounded", &discountConfig, 3.33, 0.99, 3, "8.99", "0.1"},
+		{"the min price of the years", &discountConfig, 1, 0.95, 5, "4.75", "0.2"},
+		{"not discounted by the min price", &discountConfig, 0.99, 0.99, 5, "4.95", "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, discount := calDiscountPrice(tt.config, decimal.NewFromFloat(tt.price), decimal.NewFromFloat(tt.minPrice), tt.years)
+			if !total.Equal(decimal.RequireFromString(tt.wantTotal)) || !discount.Equal(decimal.RequireFromString(tt.wantDiscount)) {
+				t.Fatal(total, discount)
+			}
+		})
+	}
+}
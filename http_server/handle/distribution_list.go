@@ -43,6 +43,11 @@ type DistributionListElement struct {
 		CouponPrice string `json:"coupon_price"`
 		UserAmount  string `json:"user_amount"`
 	} `json:"coupon_info"`
+	ReferralInfo struct {
+		Code     string `json:"code"`
+		Referrer string `json:"referrer"`
+		Share    string `json:"share"`
+	} `json:"referral_info"`
 }
 
 func (h *HttpHandle) DistributionList(ctx *gin.Context) {
@@ -155,6 +160,12 @@ func (h *HttpHandle) doDistributionList(ctx context.Context, req *ReqDistributio
 					}
 					resp.List[idx].Amount = amount.String()
 
+					if order.ReferralCode != "" {
+						resp.List[idx].ReferralInfo.Code = order.ReferralCode
+						resp.List[idx].ReferralInfo.Referrer = order.Referrer
+						resp.List[idx].ReferralInfo.Share = order.ReferralShare.String()
+					}
+
 					if order.CouponCode != "" {
 						couponInfo, err := h.DbDao.GetCouponByCode(order.CouponCode)
 						if err != nil {
@@ -187,11 +198,8 @@ func (h *HttpHandle) doDistributionList(ctx context.Context, req *ReqDistributio
 						resp.List[idx].CouponInfo.OrderAmount = fmt.Sprintf("$%s", order.USDAmount)
 						resp.List[idx].CouponInfo.SetName = couponSetInfo.Name
 						resp.List[idx].CouponInfo.Code = resCode
-						resp.List[idx].CouponInfo.CouponPrice = fmt.Sprintf("-$%s", couponSetInfo.Price)
-						userAmount := decimal.Zero
-						if couponSetInfo.Price.LessThan(order.USDAmount) {
-							userAmount = order.USDAmount.Sub(couponSetInfo.Price)
-						}
+						userAmount := couponSetInfo.GetActualPrice(order.USDAmount, order.Years)
+						resp.List[idx].CouponInfo.CouponPrice = fmt.Sprintf("-$%s", order.USDAmount.Sub(userAmount))
 						resp.List[idx].CouponInfo.UserAmount = fmt.Sprintf("$%s", userAmount)
 					}
 				}
@@ -6,6 +6,7 @@ import (
 	"das_sub_account/config"
 	"das_sub_account/dao"
 	"das_sub_account/lb"
+	"das_sub_account/pricefeed"
 	"das_sub_account/txtool"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
@@ -35,6 +36,7 @@ type HttpHandle struct {
 	LB            *lb.LoadBalancing
 	SmtServerUrl  *string
 	ServerScript  *types.Script
+	PriceFeed     *pricefeed.Feed
 }
 
 func GetClientIp(ctx *gin.Context) (string, string) {
@@ -44,11 +46,15 @@ func GetClientIp(ctx *gin.Context) (string, string) {
 }
 
 func (h *HttpHandle) checkSystemUpgrade(apiResp *api_code.ApiResp) error {
+	return checkSystemUpgrade(h.DasCore, apiResp)
+}
+
+func checkSystemUpgrade(dasCore *core.DasCore, apiResp *api_code.ApiResp) error {
 	if config.Cfg.Server.IsUpdate {
 		apiResp.ApiRespErr(api_code.ApiCodeSystemUpgrade, api_code.TextSystemUpgrade)
 		return fmt.Errorf("backend system upgrade")
 	}
-	ConfigCellDataBuilder, err := h.DasCore.ConfigCellDataBuilderByTypeArgs(common.ConfigCellTypeArgsMain)
+	ConfigCellDataBuilder, err := dasCore.ConfigCellDataBuilderByTypeArgs(common.ConfigCellTypeArgsMain)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "ConfigCellDataBuilderByTypeArgs err")
 		return fmt.Errorf("ConfigCellDataBuilderByTypeArgs err: %s", err.Error())
@@ -58,7 +64,7 @@ func (h *HttpHandle) checkSystemUpgrade(apiResp *api_code.ApiResp) error {
 		apiResp.ApiRespErr(api_code.ApiCodeSystemUpgrade, api_code.TextSystemUpgrade)
 		return fmt.Errorf("contract system upgrade")
 	}
-	ok, err := h.DasCore.CheckContractStatusOK(common.DASContractNameSubAccountCellType)
+	ok, err := dasCore.CheckContractStatusOK(common.DASContractNameSubAccountCellType)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "CheckContractStatusOK err")
 		return fmt.Errorf("CheckContractStatusOK err: %s", err.Error())
@@ -107,7 +113,10 @@ func doDasBalanceError(err error, apiResp *api_code.ApiResp) error {
 }
 
 type LBHttpHandle struct {
-	Ctx context.Context
-	RC  *cache.RedisCache
-	LB  *lb.LoadBalancing
+	Ctx          context.Context
+	RC           *cache.RedisCache
+	LB           *lb.LoadBalancing
+	DasCore      *core.DasCore
+	DbDao        *dao.DbDao
+	SmtServerUrl *string
 }
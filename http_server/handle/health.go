@@ -0,0 +1,192 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/cache"
+	"das_sub_account/dao"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/smt"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	readyCheckTimeout = time.Second * 5
+	maxParserLag      = 20 // blocks
+)
+
+type ReadyStatus string
+
+const (
+	ReadyStatusOk       ReadyStatus = "ok"
+	ReadyStatusFail     ReadyStatus = "fail"
+	ReadyStatusDegraded ReadyStatus = "degraded"
+)
+
+type RespReadyz struct {
+	Status     ReadyStatus      `json:"status"`
+	Components []ReadyComponent `json:"components"`
+}
+
+type ReadyComponent struct {
+	Name     string      `json:"name"`
+	Status   ReadyStatus `json:"status"`
+	Latency  int64       `json:"latency"` // ms
+	Optional bool        `json:"optional"`
+	Error    string      `json:"error"`
+}
+
+// readyCheck the instance is not ready if a check fails, except the optional ones
+type readyCheck struct {
+	name     string
+	optional bool
+	check    func(ctx context.Context) error
+}
+
+// readyDeps the dependencies shared by the main and the lb binaries
+type readyDeps struct {
+	dasCore      *core.DasCore
+	dbDao        *dao.DbDao
+	rc           *cache.RedisCache
+	smtServerUrl *string
+}
+
+func (h *HttpHandle) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": ReadyStatusOk})
+}
+
+func (h *HttpHandle) Readyz(ctx *gin.Context) {
+	deps := readyDeps{dasCore: h.DasCore, dbDao: h.DbDao, rc: h.RC, smtServerUrl: h.SmtServerUrl}
+	doReadyz(ctx, deps.getReadyChecks())
+}
+
+func (h *LBHttpHandle) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": ReadyStatusOk})
+}
+
+func (h *LBHttpHandle) Readyz(ctx *gin.Context) {
+	deps := readyDeps{dasCore: h.DasCore, dbDao: h.DbDao, rc: h.RC, smtServerUrl: h.SmtServerUrl}
+	doReadyz(ctx, deps.getReadyChecks())
+}
+
+func doReadyz(ctx *gin.Context, checks []readyCheck) {
+	var apiResp api_code.ApiResp
+	resp := runReadyChecks(ctx.Request.Context(), checks)
+	if resp.Status == ReadyStatusFail {
+		clientIp, remoteAddrIP := GetClientIp(ctx)
+		log.Warn("Readyz:", clientIp, remoteAddrIP, resp.Components)
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "not ready")
+		apiResp.Data = resp
+		ctx.JSON(http.StatusServiceUnavailable, apiResp)
+		return
+	}
+	apiResp.ApiRespOK(resp)
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+// runReadyChecks runs the checks concurrently, a check not finished in readyCheckTimeout fails
+func runReadyChecks(ctx context.Context, checks []readyCheck) RespReadyz {
+	ctx, cancel := context.WithTimeout(ctx, readyCheckTimeout)
+	defer cancel()
+
+	resp := RespReadyz{Status: ReadyStatusOk, Components: make([]ReadyComponent, len(checks))}
+	var wg sync.WaitGroup
+	for i, v := range checks {
+		wg.Add(1)
+		go func(i int, v readyCheck) {
+			defer wg.Done()
+			start := time.Now()
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- v.check(ctx)
+			}()
+			var err error
+			select {
+			case err = <-errCh:
+			case <-ctx.Done():
+				err = fmt.Errorf("timeout")
+			}
+			resp.Components[i] = ReadyComponent{
+				Name:     v.name,
+				Status:   ReadyStatusOk,
+				Latency:  time.Since(start).Milliseconds(),
+				Optional: v.optional,
+			}
+			if err != nil {
+				resp.Components[i].Status = ReadyStatusFail
+				resp.Components[i].Error = err.Error()
+			}
+		}(i, v)
+	}
+	wg.Wait()
+
+	for _, v := range resp.Components {
+		if v.Status == ReadyStatusOk {
+			continue
+		}
+		if !v.Optional {
+			resp.Status = ReadyStatusFail
+			break
+		}
+		resp.Status = ReadyStatusDegraded
+	}
+	return resp
+}
+
+func (d *readyDeps) getReadyChecks() []readyCheck {
+	return []readyCheck{
+		{name: "mysql", check: func(ctx context.Context) error {
+			return d.dbDao.Ping()
+		}},
+		{name: "parser_mysql", check: func(ctx context.Context) error {
+			return d.dbDao.PingParserDb()
+		}},
+		{name: "redis", check: func(ctx context.Context) error {
+			if d.rc == nil || d.rc.Red == nil {
+				return fmt.Errorf("redis is nil")
+			}
+			return d.rc.Red.Ping().Err()
+		}},
+		{name: "ckb_node", check: func(ctx context.Context) error {
+			_, err := d.dasCore.Client().GetTipBlockNumber(ctx)
+			return err
+		}},
+		{name: "smt_server", check: func(ctx context.Context) error {
+			if d.smtServerUrl == nil || *d.smtServerUrl == "" {
+				return fmt.Errorf("smt server url is nil")
+			}
+			tree := smt.NewSmtSrv(*d.smtServerUrl, common.Bytes2Hex(smt.Sha256("test")))
+			_, err := tree.GetSmtRoot()
+			return err
+		}},
+		{name: "parser_lag", check: d.checkParserLag},
+		// reads are still served during the upgrade
+		{name: "system_upgrade", optional: true, check: func(ctx context.Context) error {
+			var apiResp api_code.ApiResp
+			return checkSystemUpgrade(d.dasCore, &apiResp)
+		}},
+	}
+}
+
+func (d *readyDeps) checkParserLag(ctx context.Context) error {
+	tipBlockNumber, err := d.dasCore.Client().GetTipBlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("GetTipBlockNumber err: %s", err.Error())
+	}
+	block, err := d.dbDao.FindBlockInfo(tables.ParserTypeSubAccount)
+	if err != nil {
+		return fmt.Errorf("FindBlockInfo err: %s", err.Error())
+	} else if block.Id == 0 {
+		return fmt.Errorf("parser not started")
+	}
+	if tipBlockNumber > block.BlockNumber+maxParserLag {
+		return fmt.Errorf("parser lag: %d blocks behind the tip %d", tipBlockNumber-block.BlockNumber, tipBlockNumber)
+	}
+	return nil
+}
@@ -83,8 +83,9 @@ func (h *HttpHandle) doInternalSubAccountMintNew(ctx context.Context, req *ReqSu
 
 	// do distribution
 	parentAccountId := acc.AccountId
-	if _, ok := config.Cfg.SuspendMap[parentAccountId]; ok {
-		apiResp.ApiRespErr(api_code.ApiCodeSuspendOperation, "suspend operation")
+	if err := h.checkSuspension(parentAccountId, tables.SuspensionScopeMint, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
 		return nil
 	}
 
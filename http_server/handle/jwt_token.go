@@ -0,0 +1,139 @@
+package handle
+
+import (
+	"crypto/md5"
+	"das_sub_account/config"
+	"errors"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"time"
+)
+
+const (
+	JwtTokenTypeAccess  = "access"
+	JwtTokenTypeRefresh = "refresh"
+
+	jwtCookieAccess  = "token"
+	jwtCookieRefresh = "refresh_token"
+
+	jwtAccessExpiration  = time.Hour
+	jwtRefreshExpiration = time.Hour * 24 * 7
+)
+
+var errJwtUnauthorized = errors.New("unauthorized")
+
+func getJwtKey(kid string) ([]byte, bool) {
+	if kid == config.Cfg.Das.JwtKid {
+		return []byte(config.Cfg.Das.JwtKey), config.Cfg.Das.JwtKey != ""
+	}
+	if key, ok := config.Cfg.Das.JwtOldKeys[kid]; ok && key != "" {
+		return []byte(key), true
+	}
+	return nil, false
+}
+
+func newJwtId(sessionId, tokenType string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s%s%d", sessionId, tokenType, time.Now().UnixNano()))))
+}
+
+// newJwtTokens issues a pair of access and refresh tokens of the same session
+func newJwtTokens(claims Claims) (access, refresh *Claims, accessStr, refreshStr string, err error) {
+	now := time.Now()
+	if claims.SessionId == "" {
+		claims.SessionId = newJwtId(claims.Address, "session")
+	}
+
+	accessClaims, refreshClaims := claims, claims
+	accessClaims.TokenType = JwtTokenTypeAccess
+	accessClaims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        newJwtId(claims.SessionId, JwtTokenTypeAccess),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(jwtAccessExpiration)),
+	}
+	refreshClaims.TokenType = JwtTokenTypeRefresh
+	refreshClaims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        newJwtId(claims.SessionId, JwtTokenTypeRefresh),
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(jwtRefreshExpiration)),
+	}
+
+	if accessStr, err = signJwtToken(&accessClaims); err != nil {
+		return
+	}
+	if refreshStr, err = signJwtToken(&refreshClaims); err != nil {
+		return
+	}
+	return &accessClaims, &refreshClaims, accessStr, refreshStr, nil
+}
+
+func signJwtToken(claims *Claims) (string, error) {
+	key, ok := getJwtKey(config.Cfg.Das.JwtKid)
+	if !ok {
+		return "", fmt.Errorf("jwt key is empty")
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	if config.Cfg.Das.JwtKid != "" {
+		token.Header["kid"] = config.Cfg.Das.JwtKid
+	}
+	return token.SignedString(key)
+}
+
+// parseJwtToken verifies the signature by the kid of the token, then checks the type and the revocation
+func (h *HttpHandle) parseJwtToken(tokenStr, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	tkn, err := jwt.ParseWithClaims(tokenStr, claims, func(token *jwt.Token) (any, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := getJwtKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown kid: %s", kid)
+		}
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	if err != nil || !tkn.Valid {
+		return nil, errJwtUnauthorized
+	}
+	// tokens issued before refresh tokens have no token type, they are treated as access tokens
+	if claims.TokenType != tokenType && !(claims.TokenType == "" && tokenType == JwtTokenTypeAccess) {
+		return nil, errJwtUnauthorized
+	}
+
+	revoked, err := h.RC.IsJwtRevoked(claims.SessionId, claims.ID)
+	if err != nil {
+		return nil, fmt.Errorf("IsJwtRevoked err: %s", err.Error())
+	} else if revoked {
+		return nil, errJwtUnauthorized
+	}
+	revokedBefore, err := h.RC.GetJwtRevokedBefore(claims.Address)
+	if err != nil {
+		return nil, fmt.Errorf("GetJwtRevokedBefore err: %s", err.Error())
+	}
+	if revokedBefore > 0 && (claims.IssuedAt == nil || claims.IssuedAt.UnixMilli() <= revokedBefore) {
+		return nil, errJwtUnauthorized
+	}
+	return claims, nil
+}
+
+func setJwtCookie(ctx *gin.Context, name, value string, maxAge int) {
+	if config.Cfg.Server.Net == common.DasNetTypeMainNet {
+		ctx.SetCookie(name, value, maxAge, "/", "topdid.com", true, true)
+	} else {
+		ctx.SetCookie(name, value, maxAge, "/", "", false, false)
+	}
+}
+
+func setJwtCookies(ctx *gin.Context, access, refresh *Claims, accessStr, refreshStr string) {
+	now := time.Now()
+	setJwtCookie(ctx, jwtCookieAccess, accessStr, int(access.ExpiresAt.Sub(now).Seconds()))
+	setJwtCookie(ctx, jwtCookieRefresh, refreshStr, int(refresh.ExpiresAt.Sub(now).Seconds()))
+}
+
+func clearJwtCookies(ctx *gin.Context) {
+	setJwtCookie(ctx, jwtCookieAccess, "", -1)
+	setJwtCookie(ctx, jwtCookieRefresh, "", -1)
+}
+
+func claimsAccountId(claims *Claims) string {
+	return common.Bytes2Hex(common.GetAccountIdByAccount(claims.Account))
+}
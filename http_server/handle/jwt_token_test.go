@@ -0,0 +1,100 @@
+package handle
+
+import (
+	"das_sub_account/config"
+	"github.com/golang-jwt/jwt/v5"
+	"testing"
+	"time"
+)
+
+func newTestJwtToken(t *testing.T, method jwt.SigningMethod, kid string, key []byte, claims Claims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	str, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return str
+}
+
+func TestParseJwtToken(t *testing.T) {
+	das := config.Cfg.Das
+	defer func() { config.Cfg.Das = das }()
+	config.Cfg.Das.JwtKid = "k2"
+	config.Cfg.Das.JwtKey = "key-2"
+	config.Cfg.Das.JwtOldKeys = map[string]string{"k1": "key-1", "k0": ""}
+
+	h := &HttpHandle{RC: newTestRedisCache(t)}
+	now := time.Now()
+	newClaims := func(address, sessionId, id, tokenType string, issuedAt time.Time) Claims {
+		return Claims{
+			Address:   address,
+			TokenType: tokenType,
+			SessionId: sessionId,
+			RegisteredClaims: jwt.RegisteredClaims{
+				ID:        id,
+				IssuedAt:  jwt.NewNumericDate(issuedAt),
+				ExpiresAt: jwt.NewNumericDate(issuedAt.Add(jwtAccessExpiration)),
+			},
+		}
+	}
+
+	access, refresh, accessStr, refreshStr, err := newJwtTokens(Claims{Address: "0xaaa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := h.RC.RevokeJwt("revoked-session", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.RC.RevokeJwt("revoked-id", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.RC.SetJwtRevokedBefore("0xccc", now.UnixMilli(), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType string
+		wantOk    bool
+	}{
+		{"access", accessStr, JwtTokenTypeAccess, true},
+		{"refresh", refreshStr, JwtTokenTypeRefresh, true},
+		{"access as refresh", accessStr, JwtTokenTypeRefresh, false},
+		{"refresh as access", refreshStr, JwtTokenTypeAccess, false},
+		{"no type as access", newTestJwtToken(t, jwt.SigningMethodHS256, "k2", []byte("key-2"), newClaims("0xaaa", "s1", "t1", "", now)), JwtTokenTypeAccess, true},
+		{"no type as refresh", newTestJwtToken(t, jwt.SigningMethodHS256, "k2", []byte("key-2"), newClaims("0xaaa", "s1", "t1", "", now)), JwtTokenTypeRefresh, false},
+		{"old kid", newTestJwtToken(t, jwt.SigningMethodHS256, "k1", []byte("key-1"), newClaims("0xaaa", "s1", "t1", JwtTokenTypeAccess, now)), JwtTokenTypeAccess, true},
+		{"old kid with the current key", newTestJwtToken(t, jwt.SigningMethodHS256, "k1", []byte("key-2"), newClaims("0xaaa", "s1", "t1", JwtTokenTypeAccess, now)), JwtTokenTypeAccess, false},
+		{"old kid with an empty key", newTestJwtToken(t, jwt.SigningMethodHS256, "k0", []byte(""), newClaims("0xaaa", "s1", "t1", JwtTokenTypeAccess, now)), JwtTokenTypeAccess, false},
+		{"unknown kid", newTestJwtToken(t, jwt.SigningMethodHS256, "k9", []byte("key-2"), newClaims("0xaaa", "s1", "t1", JwtTokenTypeAccess, now)), JwtTokenTypeAccess, false},
+		{"no kid", newTestJwtToken(t, jwt.SigningMethodHS256, "", []byte("key-2"), newClaims("0xaaa", "s1", "t1", JwtTokenTypeAccess, now)), JwtTokenTypeAccess, false},
+		{"other method", newTestJwtToken(t, jwt.SigningMethodHS512, "k2", []byte("key-2"), newClaims("0xaaa", "s1", "t1", JwtTokenTypeAccess, now)), JwtTokenTypeAccess, false},
+		{"expired", newTestJwtToken(t, jwt.SigningMethodHS256, "k2", []byte("key-2"), newClaims("0xaaa", "s1", "t1", JwtTokenTypeAccess, now.Add(-jwtAccessExpiration*2))), JwtTokenTypeAccess, false},
+		{"revoked session", newTestJwtToken(t, jwt.SigningMethodHS256, "k2", []byte("key-2"), newClaims("0xaaa", "revoked-session", "t1", JwtTokenTypeAccess, now)), JwtTokenTypeAccess, false},
+		{"revoked token", newTestJwtToken(t, jwt.SigningMethodHS256, "k2", []byte("key-2"), newClaims("0xaaa", "s1", "revoked-id", JwtTokenTypeAccess, now)), JwtTokenTypeAccess, false},
+		{"issued before revoked", newTestJwtToken(t, jwt.SigningMethodHS256, "k2", []byte("key-2"), newClaims("0xCCC", "s1", "t1", JwtTokenTypeAccess, now.Add(-time.Second))), JwtTokenTypeAccess, false},
+		{"issued after revoked", newTestJwtToken(t, jwt.SigningMethodHS256, "k2", []byte("key-2"), newClaims("0xccc", "s1", "t1", JwtTokenTypeAccess, now.Add(time.Second))), JwtTokenTypeAccess, true},
+		{"invalid", "token", JwtTokenTypeAccess, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := h.parseJwtToken(tt.token, tt.tokenType)
+			if tt.wantOk {
+				if err != nil || claims == nil {
+					t.Fatal(err)
+				}
+			} else if err != errJwtUnauthorized {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// the access and the refresh tokens are of the same session
+	if access.SessionId == "" || access.SessionId != refresh.SessionId || access.ID == refresh.ID {
+		t.Fatal(access.SessionId, refresh.SessionId)
+	}
+}
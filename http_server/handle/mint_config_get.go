@@ -2,17 +2,33 @@ package handle
 
 import (
 	"context"
+	"das_sub_account/tables"
 	"github.com/dotbitHQ/das-lib/common"
 	api_code "github.com/dotbitHQ/das-lib/http_api"
 	"github.com/gin-gonic/gin"
 	"github.com/scorpiotzh/toolib"
 	"net/http"
+	"time"
 )
 
 type ReqMintConfigGet struct {
 	Account string `json:"account" binding:"required"`
 }
 
+type RespMintConfigGet struct {
+	tables.MintConfig
+	UpcomingPriceRules []ScheduledRule  `json:"upcoming_price_rules"`
+	Presale            *PresaleInfo     `json:"presale"`
+	MintLimit          tables.MintLimit `json:"mint_limit"`
+}
+
+// PresaleInfo nil if no presale is configured
+type PresaleInfo struct {
+	tables.PresaleConfig
+	Status       tables.PresaleStatus `json:"status"`
+	AllowlistNum int64                `json:"allowlist_num"`
+}
+
 func (h *HttpHandle) MintConfigGet(ctx *gin.Context) {
 	var (
 		funcName               = "MintConfigGet"
@@ -47,6 +63,37 @@ func (h *HttpHandle) doMintConfigGet(ctx context.Context, req *ReqMintConfigGet,
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
 		return err
 	}
-	apiResp.ApiRespOK(mintConfig)
+
+	// the price rules to be activated
+	scheduledList, err := h.DbDao.GetPendingScheduledRuleList(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return err
+	}
+	resp := RespMintConfigGet{
+		MintConfig:         mintConfig,
+		UpcomingPriceRules: make([]ScheduledRule, 0),
+	}
+	for _, v := range scheduledList {
+		resp.UpcomingPriceRules = append(resp.UpcomingPriceRules, newScheduledRule(v))
+	}
+
+	presaleConfig, err := h.DbDao.GetUserPresaleConfig(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return err
+	}
+	if status := presaleConfig.GetStatus(time.Now().UnixMilli()); status != tables.PresaleStatusNone {
+		resp.Presale = &PresaleInfo{PresaleConfig: presaleConfig, Status: status}
+		if resp.Presale.AllowlistNum, err = h.DbDao.GetMintAllowlistNum(accountId); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+			return err
+		}
+	}
+	if resp.MintLimit, err = h.DbDao.GetUserMintLimit(accountId); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return err
+	}
+	apiResp.ApiRespOK(resp)
 	return nil
 }
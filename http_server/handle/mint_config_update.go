@@ -67,12 +67,34 @@ func (h *HttpHandle) MintConfigUpdate(ctx *gin.Context) {
 	}
 	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
 
+	// requests authenticated by api key are applied without wallet signature
+	if getCtxApiKey(ctx) != nil {
+		if err = h.doMintConfigUpdateByApiKey(ctx.Request.Context(), getCtxApiKey(ctx), &req, &apiResp); err != nil {
+			log.Error("doMintConfigUpdateByApiKey err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		}
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
 	if err = h.doMintConfigUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
 		log.Error("doMintConfigUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
 	}
 	ctx.JSON(http.StatusOK, apiResp)
 }
 
+func (h *HttpHandle) doMintConfigUpdateByApiKey(ctx context.Context, apiKey *tables.TableApiKey, req *ReqMintConfigUpdate, apiResp *api_code.ApiResp) error {
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	auditInfo, err := h.saveMintConfig(req, apiResp)
+	if err != nil {
+		return err
+	}
+	h.addAuditLogByApiKey(ctx, req.Account, apiKey, auditInfo)
+	apiResp.ApiRespOK(nil)
+	return nil
+}
+
 func (h *HttpHandle) doMintConfigUpdate(ctx context.Context, req *ReqMintConfigUpdate, apiResp *api_code.ApiResp) error {
 	var resp RespMintConfigUpdate
 	resp.List = make([]SignInfo, 0)
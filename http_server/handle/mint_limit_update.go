@@ -0,0 +1,311 @@
+package handle
+
+import (
+	"context"
+	"crypto/md5"
+	"das_sub_account/config"
+	"das_sub_account/consts"
+	sub_api_code "das_sub_account/http_server/api_code"
+	"das_sub_account/internal"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the unpaid orders hold the quota of the pay address for a while
+const mintLimitUnpaidReserve = time.Minute * 30
+
+type ReqMintLimitUpdate struct {
+	core.ChainTypeAddress
+	Account string `json:"account" binding:"required"`
+	tables.MintLimit
+	Timestamp int64 `json:"timestamp" binding:"required"`
+}
+
+type RespMintLimitUpdate struct {
+	SignInfoList
+}
+
+func (r *ReqMintLimitUpdate) GetSignInfo() (signKey, signMsg, reqDataStr string) {
+	reqData, _ := json.Marshal(r)
+	reqDataStr = string(reqData)
+	signKey = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%d", reqDataStr, time.Now().UnixNano()))))
+	signMsg = common.DotBitPrefix + hex.EncodeToString(common.Blake2b(reqData))
+	return
+}
+
+func (h *HttpHandle) MintLimitUpdate(ctx *gin.Context) {
+	var (
+		funcName               = "MintLimitUpdate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqMintLimitUpdate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	// requests authenticated by api key are applied without wallet signature
+	if getCtxApiKey(ctx) != nil {
+		if err = h.doMintLimitUpdateByApiKey(ctx.Request.Context(), getCtxApiKey(ctx), &req, &apiResp); err != nil {
+			log.Error("doMintLimitUpdateByApiKey err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		}
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	if err = h.doMintLimitUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doMintLimitUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doMintLimitUpdateByApiKey(ctx context.Context, apiKey *tables.TableApiKey, req *ReqMintLimitUpdate, apiResp *api_code.ApiResp) error {
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if !checkMintLimit(&req.MintLimit, apiResp) {
+		return nil
+	}
+	req.Account = strings.ToLower(req.Account)
+	auditInfo, err := h.saveMintLimit(req, apiResp)
+	if err != nil {
+		return err
+	}
+	h.addAuditLogByApiKey(ctx, req.Account, apiKey, auditInfo)
+	apiResp.ApiRespOK(nil)
+	return nil
+}
+
+func (h *HttpHandle) doMintLimitUpdate(ctx context.Context, req *ReqMintLimitUpdate, apiResp *api_code.ApiResp) error {
+	var resp RespMintLimitUpdate
+	resp.List = make([]SignInfo, 0)
+
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+	res, err := req.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return err
+	}
+	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+
+	action := consts.ActionMintLimitUpdate
+	req.Account = strings.ToLower(req.Account)
+	if err := h.check(address, req.Account, action, apiResp); err != nil {
+		return err
+	}
+
+	if time.UnixMilli(req.Timestamp).Add(time.Minute * 10).Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params timestamp invalid")
+		return nil
+	}
+	if !checkMintLimit(&req.MintLimit, apiResp) {
+		return nil
+	}
+
+	//
+	signKey, signMsg, reqDataStr := req.GetSignInfo()
+
+	// cache
+	if err = h.RC.SetSignTxCache(signKey, reqDataStr); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("SetSignTxCache err: %s", err.Error())
+	}
+
+	//
+	signType := res.DasAlgorithmId
+	if signType == common.DasAlgorithmIdEth712 {
+		signType = common.DasAlgorithmIdEth
+	}
+	resp.Action = action
+	resp.SignKey = signKey
+	resp.List = append(resp.List, SignInfo{
+		SignList: []txbuilder.SignData{{
+			SignType: signType,
+			SignMsg:  signMsg,
+		}},
+	})
+	resp.SignList = []txbuilder.SignData{{
+		SignType: signType,
+		SignMsg:  signMsg,
+	}}
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// checkMintLimit all 0 removes the limits
+func checkMintLimit(mintLimit *tables.MintLimit, apiResp *api_code.ApiResp) bool {
+	if (mintLimit.ShortNameLength == 0) != (mintLimit.MaxShortName == 0) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "short_name_length and max_short_name must be set together")
+		return false
+	}
+	if mintLimit.MaxPerOwner > 0 && mintLimit.MaxShortName > mintLimit.MaxPerOwner {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "max_short_name must not be more than max_per_owner")
+		return false
+	}
+	return true
+}
+
+func (h *HttpHandle) doActionMintLimitUpdate(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp) error {
+	var data ReqMintLimitUpdate
+	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
+		if err == redis.Nil {
+			apiResp.ApiRespErr(api_code.ApiCodeTxExpired, "sign key not exist(tx expired)")
+		} else {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		}
+		return fmt.Errorf("GetSignTxCache err: %s", err.Error())
+	} else if err = json.Unmarshal([]byte(txStr), &data); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Unmarshal err")
+		return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	res, err := data.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	_, signMsg, _ := data.GetSignInfo()
+	address := ""
+	var signType common.DasAlgorithmId
+	var signature string
+	if len(req.List) != 0 {
+		signType = req.List[0].SignList[0].SignType
+		signature = req.List[0].SignList[0].SignMsg
+	} else {
+		signType = req.SignList[0].SignType
+		signature = req.SignList[0].SignMsg
+	}
+	if signType == common.DasAlgorithmIdWebauthn {
+		address = req.SignAddress
+	} else {
+		address = res.AddressHex
+	}
+	verifyRes, _, err := api_code.VerifySignature(signType, signMsg, signature, address)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "VerifySignature err")
+		return fmt.Errorf("VerifySignature err: %s", err.Error())
+	}
+	if !verifyRes {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
+		return nil
+	}
+	auditInfo, err := h.saveMintLimit(&data, apiResp)
+	if err != nil {
+		return err
+	}
+	h.addAuditLogByAddress(ctx, data.Account, address, auditInfo, "")
+	return nil
+}
+
+func (h *HttpHandle) saveMintLimit(data *ReqMintLimitUpdate, apiResp *api_code.ApiResp) (*AuditInfo, error) {
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
+	before, err := h.DbDao.GetUserMintLimit(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return nil, fmt.Errorf("GetUserMintLimit err: %s", err.Error())
+	}
+	if err := h.DbDao.CreateUserConfigWithMintLimit(tables.UserConfig{
+		Account:   data.Account,
+		AccountId: accountId,
+	}, data.MintLimit); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update mint limit")
+		return nil, fmt.Errorf("CreateUserConfigWithMintLimit err: %s", err.Error())
+	}
+	return newAuditInfo(tables.AuditActionMintLimitUpdate, before, data.MintLimit), nil
+}
+
+// checkMintLimitOfAddress the caller holds the lock of the address, so the orders of the address are checked one by one.
+// The owner of the sub-account minted by auto-mint is the pay address
+func (h *HttpHandle) checkMintLimitOfAddress(mintLimit *tables.MintLimit, parentAccountId, subAccount string, hexAddr *core.DasAddressHex, apiResp *api_code.ApiResp) error {
+	now := time.Now()
+	unpaidSince := now.Add(-mintLimitUnpaidReserve).UnixMilli()
+
+	if mintLimit.MaxPerDay > 0 {
+		list, err := h.DbDao.GetMintOrderAccountsByPayAddress(parentAccountId, hexAddr.AddressHex, now.Add(-time.Hour*24).UnixMilli(), unpaidSince)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query order")
+			return fmt.Errorf("GetMintOrderAccountsByPayAddress err: %s", err.Error())
+		}
+		if num := countOtherAccounts(subAccount, list); num >= mintLimit.MaxPerDay {
+			apiResp.ApiRespErr(sub_api_code.ApiCodeExceededDailyLimit, fmt.Sprintf("exceeded the limit of %d sub-accounts per address in 24 hours", mintLimit.MaxPerDay))
+			return nil
+		}
+	}
+
+	checkShortName := false
+	if mintLimit.ShortNameLength > 0 && mintLimit.MaxShortName > 0 {
+		_, accLen, _ := common.GetDotBitAccountLength(subAccount)
+		checkShortName = uint64(accLen) <= mintLimit.ShortNameLength
+	}
+	if mintLimit.MaxPerOwner == 0 && !checkShortName {
+		return nil
+	}
+
+	// the sub-accounts owned, and being minted or minted but not synced yet
+	owned, err := h.DbDao.GetSubAccountNamesByOwner(parentAccountId, hexAddr.ChainType, hexAddr.AddressHex)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query sub-account")
+		return fmt.Errorf("GetSubAccountNamesByOwner err: %s", err.Error())
+	}
+	minting, err := h.DbDao.GetMintOrderAccountsByPayAddress(parentAccountId, hexAddr.AddressHex, tables.GetEfficientOrderTimestamp(), unpaidSince)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query order")
+		return fmt.Errorf("GetMintOrderAccountsByPayAddress err: %s", err.Error())
+	}
+	list := append(owned, minting...)
+
+	if mintLimit.MaxPerOwner > 0 {
+		if num := countOtherAccounts(subAccount, list); num >= mintLimit.MaxPerOwner {
+			apiResp.ApiRespErr(sub_api_code.ApiCodeExceededOwnerLimit, fmt.Sprintf("exceeded the limit of %d sub-accounts per address", mintLimit.MaxPerOwner))
+			return nil
+		}
+	}
+	if checkShortName {
+		var shortList []string
+		for _, v := range list {
+			if _, accLen, err := common.GetDotBitAccountLength(v); err == nil && uint64(accLen) <= mintLimit.ShortNameLength {
+				shortList = append(shortList, v)
+			}
+		}
+		if num := countOtherAccounts(subAccount, shortList); num >= mintLimit.MaxShortName {
+			apiResp.ApiRespErr(sub_api_code.ApiCodeExceededShortNameLimit, fmt.Sprintf("exceeded the limit of %d sub-accounts of no more than %d characters per address", mintLimit.MaxShortName, mintLimit.ShortNameLength))
+			return nil
+		}
+	}
+	return nil
+}
+
+// countOtherAccounts the distinct accounts except the one being ordered, which may be ordered again
+func countOtherAccounts(account string, list []string) uint64 {
+	accounts := make(map[string]struct{})
+	for _, v := range list {
+		v = strings.ToLower(v)
+		if v != strings.ToLower(account) {
+			accounts[v] = struct{}{}
+		}
+	}
+	return uint64(len(accounts))
+}
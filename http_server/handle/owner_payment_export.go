@@ -51,7 +51,7 @@ func (h *HttpHandle) OwnerPaymentExport(ctx *gin.Context) {
 	ctx.Header("Content-Type", "text/csv")
 
 	w := csv.NewWriter(ctx.Writer)
-	if err := w.Write([]string{"parent_account", "payment_address", "payment_type", "amount"}); err != nil {
+	if err := w.Write([]string{"parent_account", "payment_address", "payment_type", "amount", "payee"}); err != nil {
 		log.Error(err)
 		_ = ctx.AbortWithError(http.StatusInternalServerError, err)
 		return
@@ -59,11 +59,19 @@ func (h *HttpHandle) OwnerPaymentExport(ctx *gin.Context) {
 	for _, v := range recordsNew {
 		for _, record := range v {
 			amount := record.Amount.DivRound(decimal.New(1, record.Decimals), record.Decimals)
-			if err := w.Write([]string{record.Account, record.Address, record.TokenId, amount.String()}); err != nil {
+			if err := w.Write([]string{record.Account, record.Address, record.TokenId, amount.String(), PayeeOwner}); err != nil {
 				log.Error(err)
 				_ = ctx.AbortWithError(http.StatusInternalServerError, err)
 				return
 			}
+			for _, referral := range record.Referrals {
+				referralAmount := referral.Amount.DivRound(decimal.New(1, record.Decimals), record.Decimals)
+				if err := w.Write([]string{record.Account, referral.Address, record.TokenId, referralAmount.String(), PayeeReferral + referral.Referrer}); err != nil {
+					log.Error(err)
+					_ = ctx.AbortWithError(http.StatusInternalServerError, err)
+					return
+				}
+			}
 		}
 	}
 	w.Flush()
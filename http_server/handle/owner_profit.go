@@ -2,6 +2,7 @@ package handle
 
 import (
 	"context"
+	"das_sub_account/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
@@ -70,8 +71,12 @@ func (h *HttpHandle) doOwnerProfit(ctx context.Context, req *ReqOwnerProfit, api
 	} else if acc.Id == 0 {
 		apiResp.ApiRespErr(api_code.ApiCodeAccountNotExist, "account not exist")
 		return nil
-	} else if acc.OwnerChainType != hexAddress.ChainType || !strings.EqualFold(acc.Owner, hexAddress.AddressHex) {
-		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "owner permission required")
+	}
+	if ok, err := h.hasAccountPermission(acc, hexAddress.AddressHex, []tables.AccountRole{tables.AccountRoleFinance}); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query account role")
+		return fmt.Errorf("hasAccountPermission err: %s", err.Error())
+	} else if !ok {
+		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "permission denied")
 		return nil
 	}
 
@@ -0,0 +1,409 @@
+package handle
+
+import (
+	"context"
+	"crypto/md5"
+	"das_sub_account/config"
+	"das_sub_account/consts"
+	"das_sub_account/internal"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	maxPresaleHolders   = 10
+	maxPresaleAllowlist = 5000
+)
+
+type ReqPresaleConfigUpdate struct {
+	core.ChainTypeAddress
+	Account     string                 `json:"account" binding:"required"`
+	StartAt     int64                  `json:"start_at"` // ms, 0 removes the presale
+	EndAt       int64                  `json:"end_at"`   // ms
+	Holders     []tables.PresaleHolder `json:"holders"`
+	HolderQuota uint64                 `json:"holder_quota"`
+	Allowlist   []PresaleAllowlistItem `json:"allowlist"`
+	Timestamp   int64                  `json:"timestamp" binding:"required"`
+}
+
+type PresaleAllowlistItem struct {
+	core.ChainTypeAddress
+	Quota uint64 `json:"quota"` // 0 means no limit
+}
+
+type RespPresaleConfigUpdate struct {
+	SignInfoList
+}
+
+// PresaleAudit the allowlist is recorded by the num
+type PresaleAudit struct {
+	tables.PresaleConfig
+	AllowlistNum int64 `json:"allowlist_num"`
+}
+
+func (r *ReqPresaleConfigUpdate) GetSignInfo() (signKey, signMsg, reqDataStr string) {
+	reqData, _ := json.Marshal(r)
+	reqDataStr = string(reqData)
+	signKey = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%d", reqDataStr, time.Now().UnixNano()))))
+	signMsg = common.DotBitPrefix + hex.EncodeToString(common.Blake2b(reqData))
+	return
+}
+
+func (h *HttpHandle) PresaleConfigUpdate(ctx *gin.Context) {
+	var (
+		funcName               = "PresaleConfigUpdate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqPresaleConfigUpdate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	// requests authenticated by api key are applied without wallet signature
+	if getCtxApiKey(ctx) != nil {
+		if err = h.doPresaleConfigUpdateByApiKey(ctx.Request.Context(), getCtxApiKey(ctx), &req, &apiResp); err != nil {
+			log.Error("doPresaleConfigUpdateByApiKey err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		}
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	if err = h.doPresaleConfigUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doPresaleConfigUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doPresaleConfigUpdateByApiKey(ctx context.Context, apiKey *tables.TableApiKey, req *ReqPresaleConfigUpdate, apiResp *api_code.ApiResp) error {
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	req.Account = strings.ToLower(req.Account)
+	if _, ok := h.checkPresaleConfig(req, apiResp); !ok {
+		return nil
+	}
+	auditInfo, err := h.savePresaleConfig(req, apiResp)
+	if err != nil {
+		return err
+	}
+	h.addAuditLogByApiKey(ctx, req.Account, apiKey, auditInfo)
+	apiResp.ApiRespOK(nil)
+	return nil
+}
+
+func (h *HttpHandle) doPresaleConfigUpdate(ctx context.Context, req *ReqPresaleConfigUpdate, apiResp *api_code.ApiResp) error {
+	var resp RespPresaleConfigUpdate
+	resp.List = make([]SignInfo, 0)
+
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+	res, err := req.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return err
+	}
+	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+
+	action := consts.ActionPresaleUpdate
+	req.Account = strings.ToLower(req.Account)
+	if err := h.check(address, req.Account, action, apiResp); err != nil {
+		return err
+	}
+
+	if time.UnixMilli(req.Timestamp).Add(time.Minute * 10).Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params timestamp invalid")
+		return nil
+	}
+	if _, ok := h.checkPresaleConfig(req, apiResp); !ok {
+		return nil
+	}
+
+	//
+	signKey, signMsg, reqDataStr := req.GetSignInfo()
+
+	// cache
+	if err = h.RC.SetSignTxCache(signKey, reqDataStr); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("SetSignTxCache err: %s", err.Error())
+	}
+
+	//
+	signType := res.DasAlgorithmId
+	if signType == common.DasAlgorithmIdEth712 {
+		signType = common.DasAlgorithmIdEth
+	}
+	resp.Action = action
+	resp.SignKey = signKey
+	resp.List = append(resp.List, SignInfo{
+		SignList: []txbuilder.SignData{{
+			SignType: signType,
+			SignMsg:  signMsg,
+		}},
+	})
+	resp.SignList = []txbuilder.SignData{{
+		SignType: signType,
+		SignMsg:  signMsg,
+	}}
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// checkPresaleConfig the allowlist with the formatted addresses, start_at 0 removes the presale
+func (h *HttpHandle) checkPresaleConfig(req *ReqPresaleConfigUpdate, apiResp *api_code.ApiResp) ([]tables.TableMintAllowlist, bool) {
+	list := make([]tables.TableMintAllowlist, 0)
+	if req.StartAt == 0 {
+		return list, true
+	}
+	if req.EndAt <= req.StartAt {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "end_at must be after start_at")
+		return nil, false
+	}
+	if req.EndAt <= time.Now().UnixMilli() {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "end_at must be in the future")
+		return nil, false
+	}
+	if len(req.Holders) > maxPresaleHolders {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("more than max holders %d", maxPresaleHolders))
+		return nil, false
+	}
+	for i, v := range req.Holders {
+		req.Holders[i].Account = strings.ToLower(v.Account)
+		if !strings.HasSuffix(req.Holders[i].Account, common.DasAccountSuffix) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("holder account[%s] invalid", req.Holders[i].Account))
+			return nil, false
+		}
+		switch v.Role {
+		case tables.PresaleHolderRoleOwner, tables.PresaleHolderRoleManager, tables.PresaleHolderRoleSubAccountOwner:
+		default:
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("holder role[%s] invalid", v.Role))
+			return nil, false
+		}
+	}
+	if len(req.Allowlist) > maxPresaleAllowlist {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("more than max allowlist %d", maxPresaleAllowlist))
+		return nil, false
+	}
+	if len(req.Holders) == 0 && len(req.Allowlist) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "allowlist and holders are empty")
+		return nil, false
+	}
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	mapAddress := make(map[string]struct{})
+	for _, v := range req.Allowlist {
+		hexAddr, err := v.FormatChainTypeAddress(h.DasCore.NetType(), true)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("allowlist address[%s] invalid", v.KeyInfo.Key))
+			return nil, false
+		}
+		address := strings.ToLower(hexAddr.AddressHex)
+		if _, ok := mapAddress[address]; ok {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("duplicate allowlist address[%s]", v.KeyInfo.Key))
+			return nil, false
+		}
+		mapAddress[address] = struct{}{}
+		list = append(list, tables.TableMintAllowlist{
+			ParentAccountId: parentAccountId,
+			Account:         req.Account,
+			ChainType:       hexAddr.ChainType,
+			Address:         address,
+			Quota:           v.Quota,
+		})
+	}
+	return list, true
+}
+
+func (h *HttpHandle) doActionPresaleUpdate(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp) error {
+	var data ReqPresaleConfigUpdate
+	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
+		if err == redis.Nil {
+			apiResp.ApiRespErr(api_code.ApiCodeTxExpired, "sign key not exist(tx expired)")
+		} else {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		}
+		return fmt.Errorf("GetSignTxCache err: %s", err.Error())
+	} else if err = json.Unmarshal([]byte(txStr), &data); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Unmarshal err")
+		return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	res, err := data.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	_, signMsg, _ := data.GetSignInfo()
+	address := ""
+	var signType common.DasAlgorithmId
+	var signature string
+	if len(req.List) != 0 {
+		signType = req.List[0].SignList[0].SignType
+		signature = req.List[0].SignList[0].SignMsg
+	} else {
+		signType = req.SignList[0].SignType
+		signature = req.SignList[0].SignMsg
+	}
+	if signType == common.DasAlgorithmIdWebauthn {
+		address = req.SignAddress
+	} else {
+		address = res.AddressHex
+	}
+	verifyRes, _, err := api_code.VerifySignature(signType, signMsg, signature, address)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "VerifySignature err")
+		return fmt.Errorf("VerifySignature err: %s", err.Error())
+	}
+	if !verifyRes {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
+		return nil
+	}
+	auditInfo, err := h.savePresaleConfig(&data, apiResp)
+	if err != nil {
+		return err
+	}
+	h.addAuditLogByAddress(ctx, data.Account, address, auditInfo, "")
+	return nil
+}
+
+func (h *HttpHandle) savePresaleConfig(data *ReqPresaleConfigUpdate, apiResp *api_code.ApiResp) (*AuditInfo, error) {
+	list, ok := h.checkPresaleConfig(data, apiResp)
+	if !ok {
+		return nil, fmt.Errorf("checkPresaleConfig err: %s", apiResp.ErrMsg)
+	}
+
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
+	var before PresaleAudit
+	var err error
+	if before.PresaleConfig, err = h.DbDao.GetUserPresaleConfig(accountId); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return nil, fmt.Errorf("GetUserPresaleConfig err: %s", err.Error())
+	}
+	if before.AllowlistNum, err = h.DbDao.GetMintAllowlistNum(accountId); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return nil, fmt.Errorf("GetMintAllowlistNum err: %s", err.Error())
+	}
+
+	after := PresaleAudit{AllowlistNum: int64(len(list))}
+	after.Holders = make([]tables.PresaleHolder, 0)
+	if data.StartAt > 0 {
+		after.StartAt, after.EndAt, after.HolderQuota = data.StartAt, data.EndAt, data.HolderQuota
+		after.Holders = append(after.Holders, data.Holders...)
+	}
+	if err := h.DbDao.CreateUserConfigWithPresaleConfig(tables.UserConfig{
+		Account:   data.Account,
+		AccountId: accountId,
+	}, after.PresaleConfig, list); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update presale config")
+		return nil, fmt.Errorf("CreateUserConfigWithPresaleConfig err: %s", err.Error())
+	}
+	return newAuditInfo(tables.AuditActionPresaleUpdate, before, after), nil
+}
+
+// checkPresale only the mint of the allowlisted addresses and the holders within their quota in the presale window
+func (h *HttpHandle) checkPresale(parentAccountId, subAccount string, actionType tables.ActionType, hexAddr *core.DasAddressHex, apiResp *api_code.ApiResp) error {
+	if actionType != tables.ActionTypeMint {
+		return nil
+	}
+	presaleConfig, err := h.DbDao.GetUserPresaleConfig(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query presale config")
+		return fmt.Errorf("GetUserPresaleConfig err: %s", err.Error())
+	}
+	if presaleConfig.GetStatus(time.Now().UnixMilli()) != tables.PresaleStatusPresale {
+		return nil
+	}
+	if hexAddr == nil {
+		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "presale: only the addresses in the allowlist can mint")
+		return nil
+	}
+
+	eligible, quota, err := h.getPresaleQuota(parentAccountId, &presaleConfig, hexAddr)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query presale allowlist")
+		return err
+	}
+	if !eligible {
+		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "presale: address not in the allowlist")
+		return nil
+	}
+	if quota == 0 {
+		return nil
+	}
+	// the unpaid orders in the window hold the quota too, the order of the same sub-account is not counted again
+	unpaidSince := time.Now().Add(-mintLimitUnpaidReserve).UnixMilli()
+	if unpaidSince < presaleConfig.StartAt {
+		unpaidSince = presaleConfig.StartAt
+	}
+	list, err := h.DbDao.GetMintOrderAccountsByPayAddress(parentAccountId, hexAddr.AddressHex, presaleConfig.StartAt, unpaidSince)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query order")
+		return fmt.Errorf("GetMintOrderAccountsByPayAddress err: %s", err.Error())
+	}
+	if num := countOtherAccounts(subAccount, list); num >= quota {
+		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, fmt.Sprintf("presale: exceeded the quota of %d", quota))
+	}
+	return nil
+}
+
+// getPresaleQuota the quota of the allowlist is used before the holder quota, 0 means no limit
+func (h *HttpHandle) getPresaleQuota(parentAccountId string, presaleConfig *tables.PresaleConfig, hexAddr *core.DasAddressHex) (bool, uint64, error) {
+	allowlist, err := h.DbDao.GetMintAllowlist(parentAccountId, hexAddr.AddressHex)
+	if err != nil {
+		return false, 0, fmt.Errorf("GetMintAllowlist err: %s", err.Error())
+	}
+	if allowlist.Id > 0 {
+		return true, allowlist.Quota, nil
+	}
+
+	for _, v := range presaleConfig.Holders {
+		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(v.Account))
+		switch v.Role {
+		case tables.PresaleHolderRoleOwner, tables.PresaleHolderRoleManager:
+			acc, err := h.DbDao.GetAccountInfoByAccountId(accountId)
+			if err != nil {
+				return false, 0, fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+			}
+			if acc.Id == 0 || acc.IsExpired() {
+				continue
+			}
+			if v.Role == tables.PresaleHolderRoleOwner && acc.OwnerChainType == hexAddr.ChainType && strings.EqualFold(acc.Owner, hexAddr.AddressHex) {
+				return true, presaleConfig.HolderQuota, nil
+			}
+			if v.Role == tables.PresaleHolderRoleManager && acc.ManagerChainType == hexAddr.ChainType && strings.EqualFold(acc.Manager, hexAddr.AddressHex) {
+				return true, presaleConfig.HolderQuota, nil
+			}
+		case tables.PresaleHolderRoleSubAccountOwner:
+			num, err := h.DbDao.GetSubAccountNumByOwner(accountId, hexAddr.ChainType, hexAddr.AddressHex)
+			if err != nil {
+				return false, 0, fmt.Errorf("GetSubAccountNumByOwner err: %s", err.Error())
+			}
+			if num > 0 {
+				return true, presaleConfig.HolderQuota, nil
+			}
+		}
+	}
+	return false, 0, nil
+}
@@ -10,7 +10,6 @@ import (
 	api_code "github.com/dotbitHQ/das-lib/http_api"
 	"github.com/gin-gonic/gin"
 	"github.com/scorpiotzh/toolib"
-	"gorm.io/gorm"
 	"net/http"
 )
 
@@ -73,6 +72,7 @@ func (h *HttpHandle) doPreservedRuleUpdate(ctx context.Context, req *ReqPriceRul
 		address:   res.AddressHex,
 		action:    action,
 		account:   req.Account,
+		audit:     newAuditInfo(tables.AuditActionPreservedRuleUpdate, h.getAuditRules(req.Account, common.ActionDataTypeSubAccountPreservedRules), req.List),
 	})
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "buildTx err: "+err.Error())
@@ -86,27 +86,7 @@ func (h *HttpHandle) doPreservedRuleUpdate(ctx context.Context, req *ReqPriceRul
 	resp.SignList = signList.List[0].SignList
 	log.Info(ctx, "doPreservedRuleUpdate:", toolib.JsonString(resp))
 
-	if err := h.DbDao.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("tx_hash=? and tx_status=?", txHash, tables.TxStatusPending).
-			Delete(&tables.RuleWhitelist{}).Error; err != nil && err != gorm.ErrRecordNotFound {
-			return err
-		}
-		for accountId, whiteList := range whiteListMap {
-			if err := tx.Create(&tables.RuleWhitelist{
-				TxHash:          txHash,
-				ParentAccount:   req.Account,
-				ParentAccountId: parentAccountId,
-				RuleType:        tables.RuleTypePreservedRules,
-				RuleIndex:       whiteList.Index,
-				Account:         whiteList.Account,
-				AccountId:       accountId,
-				TxStatus:        tables.TxStatusPending,
-			}).Error; err != nil {
-				return err
-			}
-		}
-		return nil
-	}); err != nil {
+	if err := h.createRuleWhitelist(txHash, req.Account, parentAccountId, tables.RuleTypePreservedRules, whiteListMap); err != nil {
 		return err
 	}
 	apiResp.ApiRespOK(resp)
@@ -0,0 +1,133 @@
+package handle
+
+import (
+	"crypto/md5"
+	"das_sub_account/config"
+	"das_sub_account/pricefeed"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"strings"
+	"time"
+)
+
+const defaultQuoteExpiry = time.Minute * 10
+
+// PriceQuote the price of one year locked until expired_at, pass the quote_id to auto/order/create
+type PriceQuote struct {
+	QuoteId   string                   `json:"quote_id"`
+	Price     decimal.Decimal          `json:"price"`
+	TokenList []tables.PriceQuoteToken `json:"token_list"`
+	ExpiredAt int64                    `json:"expired_at"`
+}
+
+func getQuoteExpiry() time.Duration {
+	if config.Cfg.Das.AutoMint.QuoteExpiry > 0 {
+		return time.Duration(config.Cfg.Das.AutoMint.QuoteExpiry) * time.Second
+	}
+	return defaultQuoteExpiry
+}
+
+// getFeedPrice the aggregated price of the token, the price of t_token_price_info is used for the untracked tokens
+func (h *HttpHandle) getFeedPrice(tokenId string, price decimal.Decimal) (decimal.Decimal, error) {
+	if h.PriceFeed == nil {
+		return price, nil
+	}
+	feedPrice, err := h.PriceFeed.GetPrice(tokenId)
+	if err == pricefeed.ErrNotTracked {
+		return price, nil
+	} else if err != nil {
+		return decimal.Zero, err
+	}
+	return feedPrice, nil
+}
+
+// createPriceQuote the token prices of the payment tokens of the parent account are locked with the price
+func (h *HttpHandle) createPriceQuote(parentAccountId, subAccount, address string, actionType tables.ActionType, price decimal.Decimal, defaultRenewRule bool) (*PriceQuote, error) {
+	tokenIds := config.Cfg.Das.AutoMint.SupportPaymentToken
+	if !defaultRenewRule {
+		paymentConfig, err := h.DbDao.GetUserPaymentConfig(parentAccountId)
+		if err != nil {
+			return nil, fmt.Errorf("GetUserPaymentConfig err: %s", err.Error())
+		}
+		tokenIds = make([]string, 0)
+		for _, v := range config.Cfg.Das.AutoMint.SupportPaymentToken {
+			if cfg, ok := paymentConfig.CfgMap[v]; ok && cfg.Enable {
+				tokenIds = append(tokenIds, v)
+			}
+		}
+	}
+	tokens, err := h.DbDao.FindTokens()
+	if err != nil {
+		return nil, fmt.Errorf("FindTokens err: %s", err.Error())
+	}
+
+	now := time.Now()
+	quote := PriceQuote{
+		QuoteId:   fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%s_%d", subAccount, address, now.UnixNano())))),
+		Price:     price,
+		TokenList: make([]tables.PriceQuoteToken, 0),
+		ExpiredAt: now.Add(getQuoteExpiry()).UnixMilli(),
+	}
+	for _, v := range tokenIds {
+		token, ok := tokens[v]
+		if !ok {
+			continue
+		}
+		if token.Price, err = h.getFeedPrice(v, token.Price); err != nil {
+			log.Warn("getFeedPrice err:", v, err.Error())
+			continue
+		} else if token.Price.LessThanOrEqual(decimal.Zero) {
+			continue
+		}
+		amount := price.Mul(decimal.New(1, token.Decimals)).Div(token.Price).Ceil()
+		quote.TokenList = append(quote.TokenList, tables.PriceQuoteToken{
+			TokenId:  token.TokenId,
+			Symbol:   token.Symbol,
+			Decimals: token.Decimals,
+			Price:    token.Price,
+			Amount:   RoundAmount(amount, token.TokenId),
+		})
+	}
+
+	if err := h.DbDao.CreatePriceQuote(&tables.TablePriceQuote{
+		QuoteId:         quote.QuoteId,
+		ParentAccountId: parentAccountId,
+		SubAccount:      subAccount,
+		ActionType:      actionType,
+		Address:         address,
+		Price:           price,
+		TokenAmounts:    toolib.JsonString(quote.TokenList),
+		ExpiredAt:       quote.ExpiredAt,
+	}); err != nil {
+		return nil, fmt.Errorf("CreatePriceQuote err: %s", err.Error())
+	}
+	return &quote, nil
+}
+
+// getPriceQuote the quote must be unexpired and of the same sub-account, action and address
+func (h *HttpHandle) getPriceQuote(quoteId, subAccount string, actionType tables.ActionType, hexAddr *core.DasAddressHex, apiResp *api_code.ApiResp) (*tables.TablePriceQuote, []tables.PriceQuoteToken, error) {
+	quote, err := h.DbDao.GetPriceQuote(quoteId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to search price quote")
+		return nil, nil, fmt.Errorf("GetPriceQuote err: %s", err.Error())
+	}
+	if quote.Id == 0 || quote.SubAccount != subAccount || quote.ActionType != actionType ||
+		!strings.EqualFold(quote.Address, hexAddr.AddressHex) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "price quote not exist")
+		return nil, nil, nil
+	}
+	if quote.IsExpired() {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "price quote expired, please search again")
+		return nil, nil, nil
+	}
+	tokenList, err := quote.GetTokenAmounts()
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "Failed to parse price quote")
+		return nil, nil, fmt.Errorf("GetTokenAmounts err: %s", err.Error())
+	}
+	return &quote, tokenList, nil
+}
@@ -0,0 +1,126 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/config"
+	"das_sub_account/tables"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/dotbitHQ/das-lib/witness"
+	"strings"
+	"time"
+)
+
+// SendScheduledRule send the config tx of the scheduled price rules, signed by signFn with the key of the delegated manager
+// the reason is returned if the schedule can never be sent, the err should be retried
+func (h *HttpHandle) SendScheduledRule(ctx context.Context, info *tables.TableScheduledRule, signFn func(signList []txbuilder.SignData) error) (txHash, reason string, err error) {
+	var list witness.SubAccountRuleSlice
+	if err := json.Unmarshal([]byte(info.Rules), &list); err != nil {
+		return "", "invalid rules", nil
+	}
+
+	managerAddress := config.Cfg.Das.PriceRuleSchedule.ManagerAddress
+	if managerAddress == "" {
+		return "", "", fmt.Errorf("price_rule_schedule not configured")
+	}
+	acc, err := h.DbDao.GetAccountInfoByAccountId(info.ParentAccountId)
+	if err != nil {
+		return "", "", fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	}
+	if !strings.EqualFold(acc.Manager, managerAddress) {
+		return "", fmt.Sprintf("the manager of %s is not %s any more", info.Account, managerAddress), nil
+	}
+
+	var apiResp api_code.ApiResp
+	req := ReqPriceRuleUpdate{
+		ChainTypeAddress: core.ChainTypeAddress{
+			Type: "blockchain",
+			KeyInfo: core.KeyInfo{
+				CoinType: common.CoinTypeEth,
+				Key:      managerAddress,
+			},
+		},
+		Account: info.Account,
+		List:    list,
+	}
+	if err := h.check(strings.ToLower(managerAddress), info.Account, common.DasActionConfigSubAccount, &apiResp); err != nil {
+		return "", scheduleFailedReason(&apiResp), fmt.Errorf("check err: %s", err.Error())
+	}
+	before := h.getAuditRules(info.Account, common.ActionDataTypeSubAccountPriceRules)
+	after := make(witness.SubAccountRuleSlice, len(list))
+	copy(after, list)
+
+	txParams, whiteListMap, err := h.rulesTxAssemble(ctx, RulesTxAssembleParams{
+		Req:                 &req,
+		ApiResp:             &apiResp,
+		InputActionDataType: common.ActionDataTypeSubAccountPriceRules,
+	})
+	if err != nil {
+		return "", scheduleFailedReason(&apiResp), fmt.Errorf("rulesTxAssemble err: %s", err.Error())
+	}
+
+	txBuilder := txbuilder.NewDasTxBuilderFromBase(h.TxBuilderBase, nil)
+	if err := txBuilder.BuildTransaction(txParams); err != nil {
+		return "", "", fmt.Errorf("BuildTransaction err: %s", err.Error())
+	}
+	sizeInBlock, _ := txBuilder.Transaction.SizeInBlock()
+	latestOutput := len(txBuilder.Transaction.Outputs) - 1
+	txBuilder.Transaction.Outputs[latestOutput].Capacity -= sizeInBlock + 5000
+
+	signList, err := txBuilder.GenerateDigestListFromTx(nil)
+	if err != nil {
+		return "", "", fmt.Errorf("GenerateDigestListFromTx err: %s", err.Error())
+	}
+	if err := signFn(signList); err != nil {
+		return "", "", fmt.Errorf("signFn err: %s", err.Error())
+	}
+	if err := txBuilder.AddSignatureForTx(signList); err != nil {
+		return "", "", fmt.Errorf("AddSignatureForTx err: %s", err.Error())
+	}
+
+	hash, err := txBuilder.Transaction.ComputeHash()
+	if err != nil {
+		return "", "", fmt.Errorf("ComputeHash err: %s", err.Error())
+	}
+	if err := h.createRuleWhitelist(hash.Hex(), info.Account, info.ParentAccountId, tables.RuleTypePriceRules, whiteListMap); err != nil {
+		return "", "", fmt.Errorf("createRuleWhitelist err: %s", err.Error())
+	}
+	if _, err := txBuilder.SendTransaction(); err != nil {
+		return "", "", fmt.Errorf("SendTransaction err: %s", err.Error())
+	}
+	h.DasCache.AddCellInputByAction("", txBuilder.Transaction.Inputs)
+	log.Info(ctx, "SendScheduledRule:", info.Id, info.Account, hash.Hex())
+
+	taskInfo := tables.TableTaskInfo{
+		SvrName:         config.Cfg.Slb.SvrName,
+		TaskType:        tables.TaskTypeNormal,
+		ParentAccountId: info.ParentAccountId,
+		Action:          common.DasActionConfigSubAccount,
+		Outpoint:        common.OutPoint2String(hash.Hex(), 1),
+		Timestamp:       time.Now().UnixNano() / 1e6,
+		SmtStatus:       tables.SmtStatusWriteComplete,
+		TxStatus:        tables.TxStatusPending,
+	}
+	taskInfo.InitTaskId()
+	if err := h.DbDao.CreateTask(&taskInfo); err != nil {
+		log.Error(ctx, "CreateTask err: ", err.Error())
+	}
+	h.addAuditLog(ctx, info.Account, managerAddress, tables.AuditRoleInternal,
+		newAuditInfo(tables.AuditActionPriceRuleUpdate, before, after), hash.Hex())
+	return hash.Hex(), "", nil
+}
+
+// scheduleFailedReason the errors of the rules or the account are not retried
+func scheduleFailedReason(apiResp *api_code.ApiResp) string {
+	switch apiResp.ErrNo {
+	case api_code.ApiCodeSuccess, api_code.ApiCodeError500, api_code.ApiCodeDbError,
+		api_code.ApiCodeCacheError, api_code.ApiCodeConfigSubAccountPending,
+		api_code.ApiCodeSyncBlockNumber, api_code.ApiCodeSystemUpgrade:
+		return ""
+	}
+	return apiResp.ErrMsg
+}
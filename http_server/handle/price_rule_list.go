@@ -48,35 +48,48 @@ func (h *HttpHandle) PriceRuleList(ctx *gin.Context) {
 }
 
 func (h *HttpHandle) doRuleList(ctx context.Context, actionDataType common.ActionDataType, req *ReqPriceRuleList, apiResp *api_code.ApiResp) error {
-	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
-	if err := h.checkForSearch(parentAccountId, apiResp); err != nil {
-		return err
+	resp := &RespPriceRuleList{
+		List: []interface{}{},
 	}
-	taskInfo, err := h.DbDao.GetLatestTask(parentAccountId, common.DasActionConfigSubAccount, "smt_status=? and tx_status=?", tables.SmtStatusWriteComplete, tables.TxStatusCommitted)
+	rules, err := h.getRuleList(ctx, req.Account, actionDataType, apiResp)
 	if err != nil {
-		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
 		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	if rules != nil {
+		resp.List = rules
 	}
+	apiResp.ApiRespOK(resp)
+	return nil
+}
 
-	resp := &RespPriceRuleList{
-		List: []interface{}{},
+// getRuleList the committed rules of the parent account, the prices are in USD and the in_list values are the sub-account names
+func (h *HttpHandle) getRuleList(ctx context.Context, account string, actionDataType common.ActionDataType, apiResp *api_code.ApiResp) (witness.SubAccountRuleSlice, error) {
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	if err := h.checkForSearch(parentAccountId, apiResp); err != nil {
+		return nil, err
+	}
+	taskInfo, err := h.DbDao.GetLatestTask(parentAccountId, common.DasActionConfigSubAccount, "smt_status=? and tx_status=?", tables.SmtStatusWriteComplete, tables.TxStatusCommitted)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return nil, err
 	}
 	if taskInfo.Id == 0 {
-		apiResp.ApiRespOK(resp)
-		return nil
+		return nil, nil
 	}
 
 	outpoint := common.String2OutPointStruct(taskInfo.Outpoint)
 	subAccountTx, err := h.DasCore.Client().GetTransaction(h.Ctx, outpoint.TxHash)
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "internal error")
-		return err
+		return nil, err
 	}
 
-	subAccountEntity := witness.NewSubAccountRuleEntity(req.Account)
+	subAccountEntity := witness.NewSubAccountRuleEntity(account)
 	if err := subAccountEntity.ParseFromTx(subAccountTx.Transaction, actionDataType); err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "internal error")
-		return err
+		return nil, err
 	}
 
 	for idx, v := range subAccountEntity.Rules {
@@ -97,25 +110,22 @@ func (h *HttpHandle) doRuleList(ctx context.Context, actionDataType common.Actio
 			rules, err := h.DbDao.GetRulesBySubAccountIds(parentAccountId, ruleType, accIdWhitelist)
 			if err != nil {
 				apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
-				return err
+				return nil, err
 			}
 			if len(rules) != len(accIdWhitelist) {
 				err := errors.New("data aberrant")
 				apiResp.ApiRespErr(api_code.ApiCodeDbError, err.Error())
-				return err
+				return nil, err
 			}
 
 			accWhitelist := make([]string, 0, len(accIdWhitelist))
 			for _, v := range rules {
-				accWhitelist = append(accWhitelist, strings.TrimSuffix(v.Account, "."+req.Account))
+				accWhitelist = append(accWhitelist, strings.TrimSuffix(v.Account, "."+account))
 			}
 			subAccountEntity.Rules[idx].Ast.Arguments[1].Value = accWhitelist
 		}
 	}
-
-	resp.List = subAccountEntity.Rules
-	apiResp.ApiRespOK(resp)
-	return nil
+	return subAccountEntity.Rules, nil
 }
 
 func (h *HttpHandle) checkForSearch(parentAccountId string, apiResp *api_code.ApiResp) error {
@@ -0,0 +1,395 @@
+package handle
+
+import (
+	"context"
+	"crypto/md5"
+	"das_sub_account/config"
+	"das_sub_account/consts"
+	"das_sub_account/internal"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-redis/redis"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	minScheduleDelay   = time.Minute * 10
+	maxScheduleDelay   = time.Hour * 24 * 90
+	maxPendingSchedule = 5
+)
+
+type ReqPriceRuleSchedule struct {
+	core.ChainTypeAddress
+	Account    string                      `json:"account" binding:"required"`
+	List       witness.SubAccountRuleSlice `json:"list"`
+	ActivateAt int64                       `json:"activate_at"` // ms
+	CancelId   uint64                      `json:"cancel_id"`   // cancel the pending schedule instead
+	Timestamp  int64                       `json:"timestamp" binding:"required"`
+}
+
+type RespPriceRuleSchedule struct {
+	SignInfoList
+}
+
+func (r *ReqPriceRuleSchedule) GetSignInfo() (signKey, signMsg, reqDataStr string) {
+	reqData, _ := json.Marshal(r)
+	reqDataStr = string(reqData)
+	signKey = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%d", reqDataStr, time.Now().UnixNano()))))
+	signMsg = common.DotBitPrefix + hex.EncodeToString(common.Blake2b(reqData))
+	return
+}
+
+// ScheduledRule the scheduled price rules shown to the owner and on the mint page
+type ScheduledRule struct {
+	Id         uint64                      `json:"id"`
+	ActivateAt int64                       `json:"activate_at"`
+	List       witness.SubAccountRuleSlice `json:"list"`
+	Address    string                      `json:"address"`
+	Status     tables.ScheduledRuleStatus  `json:"status"`
+	TxHash     string                      `json:"tx_hash"`
+	Reason     string                      `json:"reason"`
+	CreatedAt  int64                       `json:"created_at"`
+}
+
+func newScheduledRule(info tables.TableScheduledRule) ScheduledRule {
+	item := ScheduledRule{
+		Id:         info.Id,
+		ActivateAt: info.ActivateAt,
+		Address:    info.Address,
+		Status:     info.Status,
+		TxHash:     info.TxHash,
+		Reason:     info.Reason,
+		CreatedAt:  info.CreatedAt.UnixMilli(),
+	}
+	if err := json.Unmarshal([]byte(info.Rules), &item.List); err != nil {
+		log.Warn("newScheduledRule json.Unmarshal err:", info.Id, err.Error())
+	}
+	if item.List == nil {
+		item.List = make(witness.SubAccountRuleSlice, 0)
+	}
+	return item
+}
+
+func (h *HttpHandle) PriceRuleSchedule(ctx *gin.Context) {
+	var (
+		funcName               = "PriceRuleSchedule"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqPriceRuleSchedule
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doPriceRuleSchedule(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doPriceRuleSchedule err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doPriceRuleSchedule(ctx context.Context, req *ReqPriceRuleSchedule, apiResp *api_code.ApiResp) error {
+	var resp RespPriceRuleSchedule
+	resp.List = make([]SignInfo, 0)
+
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+
+	res, err := req.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return err
+	}
+	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+
+	action := consts.ActionRuleSchedule
+	req.Account = strings.ToLower(req.Account)
+	if err := h.check(address, req.Account, action, apiResp); err != nil {
+		return err
+	}
+
+	if time.UnixMilli(req.Timestamp).Add(time.Minute * 10).Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params timestamp invalid")
+		return nil
+	}
+	if err := h.checkPriceRuleSchedule(ctx, req, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	//
+	signKey, signMsg, reqDataStr := req.GetSignInfo()
+
+	// cache
+	if err = h.RC.SetSignTxCache(signKey, reqDataStr); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("SetSignTxCache err: %s", err.Error())
+	}
+
+	//
+	signType := res.DasAlgorithmId
+	if signType == common.DasAlgorithmIdEth712 {
+		signType = common.DasAlgorithmIdEth
+	}
+	resp.Action = action
+	resp.SignKey = signKey
+	resp.List = append(resp.List, SignInfo{
+		SignList: []txbuilder.SignData{{
+			SignType: signType,
+			SignMsg:  signMsg,
+		}},
+	})
+	resp.SignList = []txbuilder.SignData{{
+		SignType: signType,
+		SignMsg:  signMsg,
+	}}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// checkPriceRuleSchedule the rules are checked the same as Update Price Rule, with the cells of now
+func (h *HttpHandle) checkPriceRuleSchedule(ctx context.Context, req *ReqPriceRuleSchedule, apiResp *api_code.ApiResp) error {
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	if req.CancelId > 0 {
+		info, err := h.DbDao.GetScheduledRule(req.CancelId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query scheduled rule")
+			return fmt.Errorf("GetScheduledRule err: %s", err.Error())
+		}
+		if info.Id == 0 || info.ParentAccountId != parentAccountId {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "scheduled rule not exist")
+			return nil
+		}
+		if info.Status != tables.ScheduledRuleStatusPending {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "scheduled rule is not pending")
+			return nil
+		}
+		return nil
+	}
+
+	// the config tx is signed by the delegated manager at activate_at
+	managerAddress := config.Cfg.Das.PriceRuleSchedule.ManagerAddress
+	if managerAddress == "" {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "scheduled price rules not supported")
+		return nil
+	}
+	acc, err := h.DbDao.GetAccountInfoByAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
+		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	}
+	if !strings.EqualFold(acc.Manager, managerAddress) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("please set the manager of %s to %s before scheduling", req.Account, managerAddress))
+		return nil
+	}
+
+	now := time.Now()
+	if req.ActivateAt < now.Add(minScheduleDelay).UnixMilli() || req.ActivateAt > now.Add(maxScheduleDelay).UnixMilli() {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("activate_at must be between %s and %s later", minScheduleDelay, maxScheduleDelay))
+		return nil
+	}
+	if len(req.List) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "list is empty")
+		return nil
+	}
+	pendingList, err := h.DbDao.GetPendingScheduledRuleList(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query scheduled rule")
+		return fmt.Errorf("GetPendingScheduledRuleList err: %s", err.Error())
+	}
+	if len(pendingList) >= maxPendingSchedule {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("more than max pending scheduled rules %d", maxPendingSchedule))
+		return nil
+	}
+
+	// rulesTxAssemble changes the prices of the list
+	list := make(witness.SubAccountRuleSlice, len(req.List))
+	copy(list, req.List)
+	if _, _, err := h.rulesTxAssemble(ctx, RulesTxAssembleParams{
+		Req: &ReqPriceRuleUpdate{
+			ChainTypeAddress: req.ChainTypeAddress,
+			Account:          req.Account,
+			List:             list,
+		},
+		ApiResp:             apiResp,
+		InputActionDataType: common.ActionDataTypeSubAccountPriceRules,
+	}); err != nil {
+		if apiResp.ErrNo == api_code.ApiCodeSuccess {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "failed to check the rules")
+		}
+		return fmt.Errorf("rulesTxAssemble err: %s", err.Error())
+	}
+	return nil
+}
+
+func (h *HttpHandle) doActionPriceRuleSchedule(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp, resp *RespTransactionSend) error {
+	var data ReqPriceRuleSchedule
+	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
+		if err == redis.Nil {
+			apiResp.ApiRespErr(api_code.ApiCodeTxExpired, "sign key not exist(tx expired)")
+		} else {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		}
+		return fmt.Errorf("GetSignTxCache err: %s", err.Error())
+	} else if err = json.Unmarshal([]byte(txStr), &data); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Unmarshal err")
+		return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	res, err := data.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	_, signMsg, _ := data.GetSignInfo()
+	address := ""
+	var signType common.DasAlgorithmId
+	var signature string
+	if len(req.List) != 0 {
+		signType = req.List[0].SignList[0].SignType
+		signature = req.List[0].SignList[0].SignMsg
+	} else {
+		signType = req.SignList[0].SignType
+		signature = req.SignList[0].SignMsg
+	}
+	if signType == common.DasAlgorithmIdWebauthn {
+		address = req.SignAddress
+	} else {
+		address = res.AddressHex
+	}
+	verifyRes, _, err := api_code.VerifySignature(signType, signMsg, signature, address)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "VerifySignature err")
+		return fmt.Errorf("VerifySignature err: %s", err.Error())
+	}
+	if !verifyRes {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
+		return nil
+	}
+
+	// the schedules may be changed since the sign info was returned
+	if err := h.checkPriceRuleSchedule(ctx, &data, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	if data.CancelId > 0 {
+		info, err := h.DbDao.GetScheduledRule(data.CancelId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query scheduled rule")
+			return fmt.Errorf("GetScheduledRule err: %s", err.Error())
+		}
+		if ok, err := h.DbDao.UpdateScheduledRuleStatus(info.Id, tables.ScheduledRuleStatusCancelled, "", ""); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to cancel scheduled rule")
+			return fmt.Errorf("UpdateScheduledRuleStatus err: %s", err.Error())
+		} else if !ok {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "scheduled rule is not pending")
+			return nil
+		}
+		h.addAuditLogByAddress(ctx, data.Account, address, newAuditInfo(tables.AuditActionPriceRuleSchedule,
+			map[string]interface{}{"id": info.Id, "activate_at": info.ActivateAt, "status": info.Status},
+			map[string]interface{}{"id": info.Id, "activate_at": info.ActivateAt, "status": tables.ScheduledRuleStatusCancelled}), "")
+		return nil
+	}
+
+	rules, err := json.Marshal(data.List)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Marshal err")
+		return fmt.Errorf("json.Marshal err: %s", err.Error())
+	}
+	info := tables.TableScheduledRule{
+		ParentAccountId: common.Bytes2Hex(common.GetAccountIdByAccount(data.Account)),
+		Account:         data.Account,
+		Rules:           string(rules),
+		ActivateAt:      data.ActivateAt,
+		ChainType:       res.ChainType,
+		Address:         strings.ToLower(address),
+		Signature:       signature,
+		Status:          tables.ScheduledRuleStatusPending,
+	}
+	if err := h.DbDao.CreateScheduledRule(&info); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to create scheduled rule")
+		return fmt.Errorf("CreateScheduledRule err: %s", err.Error())
+	}
+	resp.ScheduleId = info.Id
+	h.addAuditLogByAddress(ctx, data.Account, address, newAuditInfo(tables.AuditActionPriceRuleSchedule, nil,
+		map[string]interface{}{"id": info.Id, "activate_at": info.ActivateAt, "list": data.List}), "")
+	return nil
+}
+
+type ReqPriceRuleScheduleList struct {
+	core.ChainTypeAddress
+	Pagination
+	Account string `json:"account" binding:"required"`
+}
+
+type RespPriceRuleScheduleList struct {
+	Total int64           `json:"total"`
+	List  []ScheduledRule `json:"list"`
+}
+
+func (h *HttpHandle) PriceRuleScheduleList(ctx *gin.Context) {
+	var (
+		funcName               = "PriceRuleScheduleList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqPriceRuleScheduleList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doPriceRuleScheduleList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doPriceRuleScheduleList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doPriceRuleScheduleList(ctx context.Context, req *ReqPriceRuleScheduleList, apiResp *api_code.ApiResp) error {
+	var resp RespPriceRuleScheduleList
+	resp.List = make([]ScheduledRule, 0)
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(strings.ToLower(req.Account)))
+	list, total, err := h.DbDao.GetScheduledRuleList(parentAccountId, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query scheduled rule")
+		return fmt.Errorf("GetScheduledRuleList err: %s", err.Error())
+	}
+	resp.Total = total
+	for _, v := range list {
+		resp.List = append(resp.List, newScheduledRule(v))
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
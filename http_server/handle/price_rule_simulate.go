@@ -0,0 +1,259 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gogf/gf/v2/util/gconv"
+	"github.com/shopspring/decimal"
+	"math"
+	"net/http"
+	"strings"
+)
+
+const (
+	maxSimulateNum      = 1000
+	maxSimulateProbeLen = 20
+)
+
+type SimulateRuleReason string
+
+const (
+	SimulateRuleReasonDisabled SimulateRuleReason = "disabled"
+	SimulateRuleReasonNoMatch  SimulateRuleReason = "no_match"
+	SimulateRuleReasonShadowed SimulateRuleReason = "shadowed"
+)
+
+type ReqPriceRuleSimulate struct {
+	core.ChainTypeAddress
+	Account        string                      `json:"account" binding:"required"`
+	List           witness.SubAccountRuleSlice `json:"list" binding:"required"`
+	SubAccountList []string                    `json:"sub_account_list"`
+	UseExisting    bool                        `json:"use_existing"`
+}
+
+type RespPriceRuleSimulate struct {
+	List        []SimulateAccount `json:"list"`
+	ChangedList []string          `json:"changed_list"`
+	Rules       []SimulateRule    `json:"rules"`
+}
+
+type SimulateAccount struct {
+	SubAccount       string          `json:"sub_account"`
+	RuleIndex        int             `json:"rule_index"` // -1 if no rule matched
+	RuleName         string          `json:"rule_name"`
+	Price            decimal.Decimal `json:"price"`
+	CurrentRuleIndex int             `json:"current_rule_index"`
+	CurrentRuleName  string          `json:"current_rule_name"`
+	CurrentPrice     decimal.Decimal `json:"current_price"`
+	Changed          bool            `json:"changed"`
+}
+
+type SimulateRule struct {
+	Index      int                `json:"index"`
+	Name       string             `json:"name"`
+	HitNum     int                `json:"hit_num"`
+	NeverMatch bool               `json:"never_match"`
+	Reason     SimulateRuleReason `json:"reason"`
+}
+
+func (h *HttpHandle) PriceRuleSimulate(ctx *gin.Context) {
+	var (
+		funcName               = "PriceRuleSimulate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqPriceRuleSimulate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, len(req.List), len(req.SubAccountList), req.UseExisting, ctx.Request.Context())
+
+	if err = h.doPriceRuleSimulate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doPriceRuleSimulate err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doPriceRuleSimulate(ctx context.Context, req *ReqPriceRuleSimulate, apiResp *api_code.ApiResp) error {
+	var resp RespPriceRuleSimulate
+	resp.List = make([]SimulateAccount, 0)
+	resp.ChangedList = make([]string, 0)
+	req.Account = strings.ToLower(req.Account)
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+
+	draft := witness.NewSubAccountRuleEntity(req.Account)
+	draft.Rules = req.List
+	if err := draft.Check(); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeRuleFormatErr, err.Error())
+		return nil
+	}
+
+	// the names to simulate
+	var subAccounts []string
+	mapExist := make(map[string]struct{})
+	addSubAccount := func(v string) bool {
+		v = strings.ToLower(strings.TrimSpace(v))
+		if !strings.HasSuffix(v, "."+req.Account) {
+			v = v + "." + req.Account
+		}
+		if _, ok := mapExist[v]; ok {
+			return true
+		}
+		if strings.Count(v, ".") != strings.Count(req.Account, ".")+1 {
+			return false
+		}
+		mapExist[v] = struct{}{}
+		subAccounts = append(subAccounts, v)
+		return true
+	}
+	for _, v := range req.SubAccountList {
+		if !addSubAccount(v) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("sub-account[%s] invalid", v))
+			return nil
+		}
+	}
+	if req.UseExisting {
+		list, err := h.DbDao.GetSubAccountNamesByParentAccountId(parentAccountId, maxSimulateNum)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query sub-account")
+			return fmt.Errorf("GetSubAccountNamesByParentAccountId err: %s", err.Error())
+		}
+		for _, v := range list {
+			addSubAccount(v)
+		}
+	}
+	if len(subAccounts) == 0 || len(subAccounts) > maxSimulateNum {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("the number of sub-accounts must be between 1 and %d", maxSimulateNum))
+		return nil
+	}
+
+	// the current rules on chain, all the prices are new if not set
+	var current *witness.SubAccountRuleEntity
+	var rulesResp api_code.ApiResp
+	if _, rulePrice, err := h.getSubAccountRules(req.Account, parentAccountId, &rulesResp); err != nil {
+		*apiResp = rulesResp
+		return err
+	} else if rulesResp.ErrNo == api_code.ApiCodeSuccess {
+		current = rulePrice
+	}
+
+	for _, v := range subAccounts {
+		item := SimulateAccount{SubAccount: v, RuleIndex: -1, CurrentRuleIndex: -1}
+		if hit, index, err := draft.Hit(v); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeRuleFormatErr, err.Error())
+			return nil
+		} else if hit {
+			item.RuleIndex, item.RuleName = index, draft.Rules[index].Name
+			item.Price = decimal.NewFromFloat(draft.Rules[index].Price)
+		}
+		if current != nil {
+			if hit, index, err := current.Hit(v); err == nil && hit {
+				item.CurrentRuleIndex, item.CurrentRuleName = index, current.Rules[index].Name
+				item.CurrentPrice = decimal.NewFromInt(int64(current.Rules[index].Price)).Div(decimal.NewFromFloat(math.Pow10(6)))
+			}
+		}
+		item.Changed = !item.Price.Equal(item.CurrentPrice)
+		if item.Changed {
+			resp.ChangedList = append(resp.ChangedList, item.SubAccount)
+		}
+		resp.List = append(resp.List, item)
+	}
+
+	resp.Rules = simulateRules(draft, resp.List)
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// simulateRules flags the rules never matched by the simulated names and the probes from the rules,
+// a rule matching the names only when evaluated alone is shadowed by the rules before it
+func simulateRules(draft *witness.SubAccountRuleEntity, list []SimulateAccount) []SimulateRule {
+	rules := make([]SimulateRule, len(draft.Rules))
+	for i, v := range draft.Rules {
+		rules[i] = SimulateRule{Index: i, Name: v.Name}
+	}
+	for _, v := range list {
+		if v.RuleIndex >= 0 {
+			rules[v.RuleIndex].HitNum++
+		}
+	}
+
+	names := genSimulateProbes(draft)
+	for _, v := range list {
+		names = append(names, v.SubAccount)
+	}
+	reached := make(map[int]struct{})
+	for _, v := range names {
+		if hit, index, err := draft.Hit(v); err == nil && hit {
+			reached[index] = struct{}{}
+		}
+	}
+
+	for i, v := range draft.Rules {
+		if v.Status == 0 {
+			rules[i].NeverMatch, rules[i].Reason = true, SimulateRuleReasonDisabled
+			continue
+		}
+		if _, ok := reached[i]; ok {
+			continue
+		}
+		rules[i].NeverMatch, rules[i].Reason = true, SimulateRuleReasonNoMatch
+		alone := witness.NewSubAccountRuleEntity(draft.ParentAccount)
+		alone.Rules = witness.SubAccountRuleSlice{v}
+		for _, name := range names {
+			if hit, _, err := alone.Hit(name); err == nil && hit {
+				rules[i].Reason = SimulateRuleReasonShadowed
+				break
+			}
+		}
+	}
+	return rules
+}
+
+// genSimulateProbes the words in the rules, and the names of each length made of letters, digits or both
+func genSimulateProbes(draft *witness.SubAccountRuleEntity) (list []string) {
+	var walk func(exp *witness.AstExpression)
+	walk = func(exp *witness.AstExpression) {
+		if exp == nil {
+			return
+		}
+		if exp.Type == witness.Value {
+			switch exp.ValueType {
+			case witness.String:
+				list = append(list, gconv.String(exp.Value))
+			case witness.StringArray:
+				list = append(list, gconv.Strings(exp.Value)...)
+			}
+		}
+		for _, v := range exp.Arguments {
+			walk(v)
+		}
+		for _, v := range exp.Expressions {
+			walk(v)
+		}
+	}
+	for _, v := range draft.Rules {
+		walk(&v.Ast)
+	}
+	for _, v := range append([]string{}, list...) {
+		list = append(list, "a"+v, v+"a", "1"+v, v+"1")
+	}
+	for i := 1; i <= maxSimulateProbeLen; i++ {
+		list = append(list, strings.Repeat("a", i), strings.Repeat("1", i), strings.Repeat("a1", i)[:i])
+	}
+	for i, v := range list {
+		list[i] = v + "." + draft.ParentAccount
+	}
+	return
+}
@@ -25,6 +25,9 @@ import (
 	"strings"
 )
 
+// maxRuleWitnessSize the size of all the rule witnesses of a tx
+const maxRuleWitnessSize = 441 * 1e3
+
 type ReqPriceRuleUpdate struct {
 	core.ChainTypeAddress
 	Account string                      `json:"account" binding:"required"`
@@ -105,6 +108,7 @@ func (h *HttpHandle) doPriceRuleUpdate(ctx context.Context, req *ReqPriceRuleUpd
 		address:   res.AddressHex,
 		action:    action,
 		account:   req.Account,
+		audit:     newAuditInfo(tables.AuditActionPriceRuleUpdate, h.getAuditRules(req.Account, common.ActionDataTypeSubAccountPriceRules), req.List),
 	})
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "buildTx err: "+err.Error())
@@ -118,7 +122,18 @@ func (h *HttpHandle) doPriceRuleUpdate(ctx context.Context, req *ReqPriceRuleUpd
 	resp.SignList = signList.List[0].SignList
 	log.Info(ctx, "doPriceRuleUpdate:", toolib.JsonString(resp))
 
-	if err := h.DbDao.Transaction(func(tx *gorm.DB) error {
+	if err := h.createRuleWhitelist(txHash, req.Account, parentAccountId, tables.RuleTypePriceRules, whiteListMap); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return err
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// createRuleWhitelist the in_list accounts of the rules, confirmed by the parser with the tx
+func (h *HttpHandle) createRuleWhitelist(txHash, account, parentAccountId string, ruleType tables.RuleType, whiteListMap map[string]Whitelist) error {
+	return h.DbDao.Transaction(func(tx *gorm.DB) error {
 		if err := tx.Where("tx_hash=? and tx_status=?", txHash, tables.TxStatusPending).
 			Delete(&tables.RuleWhitelist{}).Error; err != nil && err != gorm.ErrRecordNotFound {
 			return err
@@ -126,9 +141,9 @@ func (h *HttpHandle) doPriceRuleUpdate(ctx context.Context, req *ReqPriceRuleUpd
 		for accountId, whiteList := range whiteListMap {
 			if err := tx.Create(&tables.RuleWhitelist{
 				TxHash:          txHash,
-				ParentAccount:   req.Account,
+				ParentAccount:   account,
 				ParentAccountId: parentAccountId,
-				RuleType:        tables.RuleTypePriceRules,
+				RuleType:        ruleType,
 				RuleIndex:       whiteList.Index,
 				Account:         whiteList.Account,
 				AccountId:       accountId,
@@ -138,13 +153,7 @@ func (h *HttpHandle) doPriceRuleUpdate(ctx context.Context, req *ReqPriceRuleUpd
 			}
 		}
 		return nil
-	}); err != nil {
-		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
-		return err
-	}
-
-	apiResp.ApiRespOK(resp)
-	return nil
+	})
 }
 
 type Whitelist struct {
@@ -269,92 +278,13 @@ func (h *HttpHandle) rulesTxAssemble(ctx context.Context, params RulesTxAssemble
 	if params.InputActionDataType != "" {
 		ruleEntity := witness.NewSubAccountRuleEntity(params.Req.Account)
 		ruleEntity.Rules = params.Req.List
-		if err := ruleEntity.Check(); err != nil {
-			params.ApiResp.ApiRespErr(api_code.ApiCodeRuleFormatErr, err.Error())
+		if whiteListMap, err = h.checkRules(ctx, ruleEntity, params.InputActionDataType, params.ApiResp); err != nil {
 			return nil, nil, err
 		}
-
-		//token, err := h.DbDao.GetTokenById(tables.TokenIdCkb)
-		//if err != nil {
-		//	params.ApiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
-		//	return nil, nil, err
-		//}
-
-		builder, err := h.DasCore.ConfigCellDataBuilderByTypeArgsList(common.ConfigCellTypeArgsSubAccount)
-		if err != nil {
-			params.ApiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
-			return nil, nil, fmt.Errorf("ConfigCellDataBuilderByTypeArgsList err: %s", err.Error())
-		}
-		newSubAccountPrice, _ := molecule.Bytes2GoU64(builder.ConfigCellSubAccount.NewSubAccountPrice().RawData())
-
-		preservedInList := 0
-
-		for idx, v := range ruleEntity.Rules {
-			if params.InputActionDataType == common.ActionDataTypeSubAccountPriceRules {
-				if v.Price <= 0 {
-					err = fmt.Errorf("price not be less than min %d", newSubAccountPrice)
-					params.ApiResp.ApiRespErr(api_code.ApiCodePriceRulePriceNotBeLessThanMin, err.Error())
-					return nil, nil, err
-				}
-
-				if math.Round(v.Price*10000)/10000 != v.Price {
-					err = errors.New("price most be four decimal places")
-					params.ApiResp.ApiRespErr(api_code.ApiCodePriceMostReserveTwoDecimal, err.Error())
-					return nil, nil, err
-				}
-
-				// check min price 0.99$
-				price := decimal.NewFromInt(int64(newSubAccountPrice)).DivRound(decimal.NewFromInt(common.UsdRateBase), 2)
-				//price := decimal.NewFromInt(int64(newSubAccountPrice)).Mul(token.Price).Div(decimal.NewFromFloat(math.Pow10(int(token.Decimals))))
-				if price.GreaterThan(decimal.NewFromFloat(v.Price)) {
-					err = fmt.Errorf("price not be less than min: %s$", price.String())
-					params.ApiResp.ApiRespErr(api_code.ApiCodePriceRulePriceNotBeLessThanMin, err.Error())
-					return nil, nil, err
-				}
+		if params.InputActionDataType == common.ActionDataTypeSubAccountPriceRules {
+			for idx := range ruleEntity.Rules {
 				ruleEntity.Rules[idx].Price *= math.Pow10(6)
 			}
-
-			if v.Ast.Type == witness.Function &&
-				v.Ast.Name == string(witness.FunctionInList) &&
-				v.Ast.Arguments[0].Type == witness.Variable &&
-				v.Ast.Arguments[0].Name == string(witness.Account) &&
-				v.Ast.Arguments[1].Type == witness.Value {
-
-				accWhitelist := gconv.Strings(v.Ast.Arguments[1].Value)
-
-				if len(accWhitelist) > 999 {
-					err = errors.New("account list most be less than 1000")
-					params.ApiResp.ApiRespErr(api_code.ApiCodeInListMostBeLessThan1000, err.Error())
-					return nil, nil, err
-				}
-
-				if params.InputActionDataType == common.ActionDataTypeSubAccountPreservedRules {
-					preservedInList += 1
-					if preservedInList > 1 {
-						err = errors.New("preserved in_list rules most be one")
-						params.ApiResp.ApiRespErr(api_code.ApiCodePreservedRulesMostBeOne, err.Error())
-						return nil, nil, err
-					}
-				}
-
-				for _, v := range accWhitelist {
-					accountName := v + "." + params.Req.Account
-					h.checkSubAccountName(ctx, params.ApiResp, accountName)
-					if params.ApiResp.ErrNo != api_code.ApiCodeSuccess {
-						return nil, nil, errors.New("account name invalid")
-					}
-					accId := common.Bytes2Hex(common.GetAccountIdByAccount(accountName))
-					if _, ok := whiteListMap[accId]; ok {
-						err = fmt.Errorf("account: %s repeat", accountName)
-						params.ApiResp.ApiRespErr(api_code.ApiCodeAccountRepeat, err.Error())
-						return nil, nil, err
-					}
-					whiteListMap[accId] = Whitelist{
-						Index:   idx,
-						Account: accountName,
-					}
-				}
-			}
 		}
 
 		reqRuleData, err = ruleEntity.GenData()
@@ -433,7 +363,7 @@ func (h *HttpHandle) rulesTxAssemble(ctx context.Context, params RulesTxAssemble
 	txParams.OutputsData = append(txParams.OutputsData, newSubAccountCellOutputData)
 
 	// rule witness most size check
-	if ruleWitnessSize > 441*1e3 {
+	if ruleWitnessSize > maxRuleWitnessSize {
 		err = errors.New("rule size exceeds limit")
 		params.ApiResp.ApiRespErr(api_code.ApiCodeRuleSizeExceedsLimit, err.Error())
 		return nil, nil, err
@@ -441,6 +371,98 @@ func (h *HttpHandle) rulesTxAssemble(ctx context.Context, params RulesTxAssemble
 	return txParams, whiteListMap, nil
 }
 
+// checkRules the rules of the price in USD and the in_list of the sub-account names, returns the in_list accounts
+func (h *HttpHandle) checkRules(ctx context.Context, ruleEntity *witness.SubAccountRuleEntity, actionDataType common.ActionDataType, apiResp *api_code.ApiResp) (map[string]Whitelist, error) {
+	whiteListMap := make(map[string]Whitelist)
+	if err := ruleEntity.Check(); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeRuleFormatErr, err.Error())
+		return nil, err
+	}
+
+	//token, err := h.DbDao.GetTokenById(tables.TokenIdCkb)
+	//if err != nil {
+	//	apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
+	//	return nil, err
+	//}
+
+	builder, err := h.DasCore.ConfigCellDataBuilderByTypeArgsList(common.ConfigCellTypeArgsSubAccount)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
+		return nil, fmt.Errorf("ConfigCellDataBuilderByTypeArgsList err: %s", err.Error())
+	}
+	newSubAccountPrice, _ := molecule.Bytes2GoU64(builder.ConfigCellSubAccount.NewSubAccountPrice().RawData())
+
+	preservedInList := 0
+
+	for idx, v := range ruleEntity.Rules {
+		if actionDataType == common.ActionDataTypeSubAccountPriceRules {
+			if v.Price <= 0 {
+				err = fmt.Errorf("price not be less than min %d", newSubAccountPrice)
+				apiResp.ApiRespErr(api_code.ApiCodePriceRulePriceNotBeLessThanMin, err.Error())
+				return nil, err
+			}
+
+			if math.Round(v.Price*10000)/10000 != v.Price {
+				err = errors.New("price most be four decimal places")
+				apiResp.ApiRespErr(api_code.ApiCodePriceMostReserveTwoDecimal, err.Error())
+				return nil, err
+			}
+
+			// check min price 0.99$
+			price := decimal.NewFromInt(int64(newSubAccountPrice)).DivRound(decimal.NewFromInt(common.UsdRateBase), 2)
+			//price := decimal.NewFromInt(int64(newSubAccountPrice)).Mul(token.Price).Div(decimal.NewFromFloat(math.Pow10(int(token.Decimals))))
+			if price.GreaterThan(decimal.NewFromFloat(v.Price)) {
+				err = fmt.Errorf("price not be less than min: %s$", price.String())
+				apiResp.ApiRespErr(api_code.ApiCodePriceRulePriceNotBeLessThanMin, err.Error())
+				return nil, err
+			}
+		}
+
+		if v.Ast.Type == witness.Function &&
+			v.Ast.Name == string(witness.FunctionInList) &&
+			v.Ast.Arguments[0].Type == witness.Variable &&
+			v.Ast.Arguments[0].Name == string(witness.Account) &&
+			v.Ast.Arguments[1].Type == witness.Value {
+
+			accWhitelist := gconv.Strings(v.Ast.Arguments[1].Value)
+
+			if len(accWhitelist) > maxInListAccountNum {
+				err = errors.New("account list most be less than 1000")
+				apiResp.ApiRespErr(api_code.ApiCodeInListMostBeLessThan1000, err.Error())
+				return nil, err
+			}
+
+			if actionDataType == common.ActionDataTypeSubAccountPreservedRules {
+				preservedInList += 1
+				if preservedInList > 1 {
+					err = errors.New("preserved in_list rules most be one")
+					apiResp.ApiRespErr(api_code.ApiCodePreservedRulesMostBeOne, err.Error())
+					return nil, err
+				}
+			}
+
+			for _, v := range accWhitelist {
+				accountName := v + "." + ruleEntity.ParentAccount
+				h.checkSubAccountName(ctx, apiResp, accountName)
+				if apiResp.ErrNo != api_code.ApiCodeSuccess {
+					return nil, errors.New("account name invalid")
+				}
+				accId := common.Bytes2Hex(common.GetAccountIdByAccount(accountName))
+				if _, ok := whiteListMap[accId]; ok {
+					err = fmt.Errorf("account: %s repeat", accountName)
+					apiResp.ApiRespErr(api_code.ApiCodeAccountRepeat, err.Error())
+					return nil, err
+				}
+				whiteListMap[accId] = Whitelist{
+					Index:   idx,
+					Account: accountName,
+				}
+			}
+		}
+	}
+	return whiteListMap, nil
+}
+
 func ruleWitnessHash(ruleData [][]byte) ([]byte, error) {
 	hash := make([]byte, 10)
 	if len(ruleData) > 0 {
@@ -9,11 +9,13 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/scorpiotzh/toolib"
 	"net/http"
+	"strings"
 	"time"
 )
 
 type ReqRecycleAccount struct {
 	SubAccountIds []string `json:"sub_account_ids"`
+	Operator      string   `json:"-"`
 }
 
 type RespRecycleAccount struct {
@@ -34,7 +36,8 @@ func (h *HttpHandle) RecycleAccount(ctx *gin.Context) {
 		ctx.JSON(http.StatusOK, apiResp)
 		return
 	}
-	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), ctx.Request.Context())
+	req.Operator = getCtxInternalCaller(ctx)
+	log.Info("ApiReq:", funcName, clientIp, toolib.JsonString(req), req.Operator, ctx.Request.Context())
 
 	//time.Sleep(time.Minute * 3)
 	if err = h.doRecycleAccount(ctx.Request.Context(), &req, &apiResp); err != nil {
@@ -112,6 +115,16 @@ func (h *HttpHandle) doRecycleAccount(ctx context.Context, req *ReqRecycleAccoun
 			apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to create smt record")
 			return fmt.Errorf("CreateRecycleSmtRecordList err: %s", err.Error())
 		}
+		// the recycled sub-accounts of each parent account
+		mapRecycle := make(map[string][]string)
+		for _, v := range smtRecordList {
+			if index := strings.Index(v.Account, "."); index > 0 {
+				mapRecycle[v.Account[index+1:]] = append(mapRecycle[v.Account[index+1:]], v.Account)
+			}
+		}
+		for account, subAccounts := range mapRecycle {
+			h.addAuditLog(ctx, account, req.Operator, tables.AuditRoleInternal, newAuditInfo(tables.AuditActionRecycle, nil, subAccounts), "")
+		}
 	}
 
 	apiResp.ApiRespOK(resp)
@@ -0,0 +1,101 @@
+package handle
+
+import (
+	"context"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strings"
+)
+
+type ReqReferralDashboard struct {
+	Referrer string `json:"referrer" binding:"required"`
+}
+
+type RespReferralDashboard struct {
+	List     []ReferralInfo        `json:"list"`
+	Payments []ReferralPaymentInfo `json:"payments"`
+}
+
+type ReferralPaymentInfo struct {
+	Account     string `json:"account"`
+	TokenId     string `json:"token_id"`
+	Symbol      string `json:"symbol"`
+	Amount      string `json:"amount"`
+	Address     string `json:"address"`
+	PaymentDate int64  `json:"payment_date"`
+}
+
+func (h *HttpHandle) ReferralDashboard(ctx *gin.Context) {
+	var (
+		funcName               = "ReferralDashboard"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqReferralDashboard
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doReferralDashboard(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doReferralDashboard err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doReferralDashboard(ctx context.Context, req *ReqReferralDashboard, apiResp *api_code.ApiResp) error {
+	resp := RespReferralDashboard{
+		List:     make([]ReferralInfo, 0),
+		Payments: make([]ReferralPaymentInfo, 0),
+	}
+
+	referrerId := common.Bytes2Hex(common.GetAccountIdByAccount(strings.ToLower(req.Referrer)))
+	list, err := h.DbDao.GetReferralListByReferrer(referrerId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query referral")
+		return fmt.Errorf("GetReferralListByReferrer err: %s", err.Error())
+	}
+	if resp.List, err = h.getReferralInfoList(list, referrerId, apiResp); err != nil {
+		return err
+	}
+
+	payments, err := h.DbDao.GetReferralPaymentListByReferrer(referrerId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query referral payment")
+		return fmt.Errorf("GetReferralPaymentListByReferrer err: %s", err.Error())
+	}
+	if len(payments) > 0 {
+		tokens, err := h.DbDao.FindTokens()
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query token")
+			return fmt.Errorf("FindTokens err: %s", err.Error())
+		}
+		for _, v := range payments {
+			info := ReferralPaymentInfo{
+				Account:     v.Account,
+				TokenId:     v.TokenId,
+				Amount:      v.Amount.String(),
+				Address:     v.Address,
+				PaymentDate: v.PaymentDate.UnixMilli(),
+			}
+			if token, ok := tokens[v.TokenId]; ok {
+				info.Symbol = token.Symbol
+				info.Amount = v.Amount.DivRound(decimal.New(1, token.Decimals), token.Decimals).String()
+			}
+			resp.Payments = append(resp.Payments, info)
+		}
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -0,0 +1,120 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strings"
+)
+
+type ReqReferralList struct {
+	core.ChainTypeAddress
+	Account string `json:"account" binding:"required"`
+}
+
+type RespReferralList struct {
+	List []ReferralInfo `json:"list"`
+}
+
+type ReferralInfo struct {
+	Account   string                `json:"account"`
+	Code      string                `json:"code"`
+	Referrer  string                `json:"referrer"`
+	Share     decimal.Decimal       `json:"share"`
+	Status    tables.ReferralStatus `json:"status"`
+	MintNum   int64                 `json:"mint_num"`
+	RenewNum  int64                 `json:"renew_num"`
+	UsdAmount decimal.Decimal       `json:"usd_amount"`
+	// the share of the order amount in USD before the fees and the coupons, the payments are the actual earnings
+	GrossEarnings decimal.Decimal `json:"gross_earnings"`
+	CreatedAt     int64           `json:"created_at"`
+}
+
+func (h *HttpHandle) ReferralList(ctx *gin.Context) {
+	var (
+		funcName               = "ReferralList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqReferralList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, ctx.Request.Context())
+
+	if err = h.doReferralList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doReferralList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doReferralList(ctx context.Context, req *ReqReferralList, apiResp *api_code.ApiResp) error {
+	resp := RespReferralList{List: make([]ReferralInfo, 0)}
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(strings.ToLower(req.Account)))
+	list, err := h.DbDao.GetReferralList(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query referral")
+		return fmt.Errorf("GetReferralList err: %s", err.Error())
+	}
+	if resp.List, err = h.getReferralInfoList(list, "", apiResp); err != nil {
+		return err
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// getReferralInfoList the referral codes with the successful orders of them, only the orders of the referrer if referrerId is not empty
+func (h *HttpHandle) getReferralInfoList(list []tables.TableReferral, referrerId string, apiResp *api_code.ApiResp) ([]ReferralInfo, error) {
+	res := make([]ReferralInfo, 0, len(list))
+	if len(list) == 0 {
+		return res, nil
+	}
+	stats, err := h.DbDao.GetReferralOrderStats(list)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query order")
+		return nil, fmt.Errorf("GetReferralOrderStats err: %s", err.Error())
+	}
+	for _, v := range list {
+		info := ReferralInfo{
+			Account:   v.Account,
+			Code:      v.Code,
+			Referrer:  v.Referrer,
+			Share:     v.Share,
+			Status:    v.Status,
+			CreatedAt: v.CreatedAt.UnixMilli(),
+		}
+		for _, stat := range stats {
+			if stat.ParentAccountId != v.ParentAccountId || stat.ReferralCode != v.Code {
+				continue
+			}
+			if referrerId != "" && stat.ReferrerId != "" && stat.ReferrerId != referrerId {
+				continue
+			}
+			switch stat.ActionType {
+			case tables.ActionTypeMint:
+				info.MintNum += stat.Num
+			case tables.ActionTypeRenew:
+				info.RenewNum += stat.Num
+			}
+			info.UsdAmount = info.UsdAmount.Add(stat.UsdAmount)
+			info.GrossEarnings = info.GrossEarnings.Add(stat.ShareUsdAmount)
+		}
+		info.GrossEarnings = info.GrossEarnings.Round(2)
+		res = append(res, info)
+	}
+	return res, nil
+}
@@ -0,0 +1,324 @@
+package handle
+
+import (
+	"context"
+	"crypto/md5"
+	"das_sub_account/config"
+	"das_sub_account/consts"
+	sub_api_code "das_sub_account/http_server/api_code"
+	"das_sub_account/internal"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// payee of the rows of the payment exports
+const (
+	PayeeOwner    = "owner"
+	PayeeReferral = "referral:"
+)
+
+var (
+	referralCodeReg  = regexp.MustCompile(`^[a-z0-9_-]{4,32}$`)
+	maxReferralShare = decimal.NewFromFloat(0.5)
+)
+
+type ReqReferralUpdate struct {
+	core.ChainTypeAddress
+	Account   string         `json:"account" binding:"required"`
+	List      []ReferralItem `json:"list" binding:"required,min=1,max=100"`
+	Timestamp int64          `json:"timestamp" binding:"required"`
+}
+
+type ReferralItem struct {
+	Code     string                `json:"code"`
+	Referrer string                `json:"referrer"`
+	Share    decimal.Decimal       `json:"share"`
+	Status   tables.ReferralStatus `json:"status"`
+}
+
+type RespReferralUpdate struct {
+	SignInfoList
+}
+
+func (r *ReqReferralUpdate) GetSignInfo() (signKey, signMsg, reqDataStr string) {
+	reqData, _ := json.Marshal(r)
+	reqDataStr = string(reqData)
+	signKey = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%d", reqDataStr, time.Now().UnixNano()))))
+	signMsg = common.DotBitPrefix + hex.EncodeToString(common.Blake2b(reqData))
+	return
+}
+
+func (h *HttpHandle) ReferralUpdate(ctx *gin.Context) {
+	var (
+		funcName               = "ReferralUpdate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqReferralUpdate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	// requests authenticated by api key are applied without wallet signature
+	if getCtxApiKey(ctx) != nil {
+		if err = h.doReferralUpdateByApiKey(ctx.Request.Context(), getCtxApiKey(ctx), &req, &apiResp); err != nil {
+			log.Error("doReferralUpdateByApiKey err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		}
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	if err = h.doReferralUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doReferralUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doReferralUpdateByApiKey(ctx context.Context, apiKey *tables.TableApiKey, req *ReqReferralUpdate, apiResp *api_code.ApiResp) error {
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	req.Account = strings.ToLower(req.Account)
+	if !checkReferrals(req.Account, req.List, apiResp) {
+		return nil
+	}
+	auditInfo, err := h.saveReferrals(req, apiResp)
+	if err != nil {
+		return err
+	}
+	if auditInfo == nil {
+		return nil
+	}
+	h.addAuditLogByApiKey(ctx, req.Account, apiKey, auditInfo)
+	apiResp.ApiRespOK(nil)
+	return nil
+}
+
+func (h *HttpHandle) doReferralUpdate(ctx context.Context, req *ReqReferralUpdate, apiResp *api_code.ApiResp) error {
+	var resp RespReferralUpdate
+	resp.List = make([]SignInfo, 0)
+
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+	res, err := req.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return err
+	}
+	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+
+	action := consts.ActionReferralUpdate
+	req.Account = strings.ToLower(req.Account)
+	if err := h.check(address, req.Account, action, apiResp); err != nil {
+		return err
+	}
+
+	if time.UnixMilli(req.Timestamp).Add(time.Minute * 10).Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params timestamp invalid")
+		return nil
+	}
+	if !checkReferrals(req.Account, req.List, apiResp) {
+		return nil
+	}
+
+	//
+	signKey, signMsg, reqDataStr := req.GetSignInfo()
+
+	// cache
+	if err = h.RC.SetSignTxCache(signKey, reqDataStr); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("SetSignTxCache err: %s", err.Error())
+	}
+
+	//
+	signType := res.DasAlgorithmId
+	if signType == common.DasAlgorithmIdEth712 {
+		signType = common.DasAlgorithmIdEth
+	}
+	resp.Action = action
+	resp.SignKey = signKey
+	resp.List = append(resp.List, SignInfo{
+		SignList: []txbuilder.SignData{{
+			SignType: signType,
+			SignMsg:  signMsg,
+		}},
+	})
+	resp.SignList = []txbuilder.SignData{{
+		SignType: signType,
+		SignMsg:  signMsg,
+	}}
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// checkReferrals the codes and referrers are formatted to lower case
+func checkReferrals(account string, list []ReferralItem, apiResp *api_code.ApiResp) bool {
+	codes := make(map[string]struct{})
+	for i := range list {
+		list[i].Code = strings.ToLower(list[i].Code)
+		list[i].Referrer = strings.ToLower(list[i].Referrer)
+		v := list[i]
+		if !referralCodeReg.MatchString(v.Code) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("code [%s] must be 4-32 characters of a-z, 0-9, _ and -", v.Code))
+			return false
+		}
+		if _, ok := codes[v.Code]; ok {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("code [%s] is duplicated", v.Code))
+			return false
+		}
+		codes[v.Code] = struct{}{}
+		if !strings.HasSuffix(v.Referrer, common.DasAccountSuffix) || v.Referrer == account {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("referrer [%s] of code [%s] invalid", v.Referrer, v.Code))
+			return false
+		}
+		if v.Share.LessThanOrEqual(decimal.Zero) || v.Share.GreaterThan(maxReferralShare) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("share of code [%s] must be more than 0 and not more than %s", v.Code, maxReferralShare))
+			return false
+		}
+		if v.Status != tables.ReferralStatusNormal && v.Status != tables.ReferralStatusDisabled {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("status of code [%s] invalid", v.Code))
+			return false
+		}
+	}
+	return true
+}
+
+func (h *HttpHandle) doActionReferralUpdate(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp) error {
+	var data ReqReferralUpdate
+	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
+		if err == redis.Nil {
+			apiResp.ApiRespErr(api_code.ApiCodeTxExpired, "sign key not exist(tx expired)")
+		} else {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		}
+		return fmt.Errorf("GetSignTxCache err: %s", err.Error())
+	} else if err = json.Unmarshal([]byte(txStr), &data); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Unmarshal err")
+		return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	res, err := data.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	_, signMsg, _ := data.GetSignInfo()
+	address := ""
+	var signType common.DasAlgorithmId
+	var signature string
+	if len(req.List) != 0 {
+		signType = req.List[0].SignList[0].SignType
+		signature = req.List[0].SignList[0].SignMsg
+	} else {
+		signType = req.SignList[0].SignType
+		signature = req.SignList[0].SignMsg
+	}
+	if signType == common.DasAlgorithmIdWebauthn {
+		address = req.SignAddress
+	} else {
+		address = res.AddressHex
+	}
+	verifyRes, _, err := api_code.VerifySignature(signType, signMsg, signature, address)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "VerifySignature err")
+		return fmt.Errorf("VerifySignature err: %s", err.Error())
+	}
+	if !verifyRes {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
+		return nil
+	}
+	auditInfo, err := h.saveReferrals(&data, apiResp)
+	if err != nil || auditInfo == nil {
+		return err
+	}
+	h.addAuditLogByAddress(ctx, data.Account, address, auditInfo, "")
+	return nil
+}
+
+// saveReferrals the codes not in the list are kept as they are, disable them with status
+func (h *HttpHandle) saveReferrals(data *ReqReferralUpdate, apiResp *api_code.ApiResp) (*AuditInfo, error) {
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
+	before, err := h.DbDao.GetReferralList(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return nil, fmt.Errorf("GetReferralList err: %s", err.Error())
+	}
+
+	list := make([]tables.TableReferral, 0, len(data.List))
+	for _, v := range data.List {
+		referrerId := common.Bytes2Hex(common.GetAccountIdByAccount(v.Referrer))
+		acc, err := h.DbDao.GetAccountInfoByAccountId(referrerId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
+			return nil, fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+		}
+		if acc.Id == 0 {
+			apiResp.ApiRespErr(api_code.ApiCodeAccountNotExist, fmt.Sprintf("referrer [%s] not exist", v.Referrer))
+			return nil, nil
+		}
+		list = append(list, tables.TableReferral{
+			ParentAccountId: accountId,
+			Account:         data.Account,
+			Code:            v.Code,
+			Referrer:        v.Referrer,
+			ReferrerId:      referrerId,
+			Share:           v.Share,
+			Status:          v.Status,
+		})
+	}
+	if err := h.DbDao.UpsertReferrals(list); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update referral")
+		return nil, fmt.Errorf("UpsertReferrals err: %s", err.Error())
+	}
+	return newAuditInfo(tables.AuditActionReferralUpdate, before, data.List), nil
+}
+
+// getOrderReferral the referral code is ignored when it's empty, and the referrer can't refer the orders of itself
+func (h *HttpHandle) getOrderReferral(parentAccountId, code string, hexAddr *core.DasAddressHex, apiResp *api_code.ApiResp) (*tables.TableReferral, error) {
+	if code == "" {
+		return nil, nil
+	}
+	referral, err := h.DbDao.GetReferral(parentAccountId, strings.ToLower(code))
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query referral")
+		return nil, fmt.Errorf("GetReferral err: %s", err.Error())
+	}
+	if referral.Id == 0 || referral.Status != tables.ReferralStatusNormal {
+		apiResp.ApiRespErr(sub_api_code.ApiCodeReferralCodeInvalid, "referral code invalid")
+		return nil, nil
+	}
+	acc, err := h.DbDao.GetAccountInfoByAccountId(referral.ReferrerId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
+		return nil, fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	}
+	if strings.EqualFold(acc.Owner, hexAddr.AddressHex) {
+		apiResp.ApiRespErr(sub_api_code.ApiCodeReferralCodeInvalid, "referral code can't be used by the referrer")
+		return nil, nil
+	}
+	return &referral, nil
+}
@@ -0,0 +1,87 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+type ReqRoleList struct {
+	core.ChainTypeAddress
+	Account string `json:"account" binding:"required"`
+}
+
+type RespRoleList struct {
+	List []RoleInfo `json:"list"`
+}
+
+type RoleInfo struct {
+	ChainType common.ChainType     `json:"chain_type"`
+	Address   string               `json:"address"`
+	Roles     []tables.AccountRole `json:"roles"`
+}
+
+func (h *HttpHandle) RoleList(ctx *gin.Context) {
+	var (
+		funcName               = "RoleList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqRoleList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, ctx.Request.Context())
+
+	if err = h.doRoleList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doRoleList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doRoleList(ctx context.Context, req *ReqRoleList, apiResp *api_code.ApiResp) error {
+	resp := RespRoleList{List: make([]RoleInfo, 0)}
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	list, err := h.DbDao.GetAccountRoleList(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query account role")
+		return fmt.Errorf("GetAccountRoleList err: %s", err.Error())
+	}
+	acc, err := h.DbDao.GetAccountInfoByAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query account")
+		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	}
+	var mapAddress = make(map[string]int)
+	for _, v := range list {
+		// the roles granted by a former owner are not valid any more
+		if !v.IsIssuedByOwner(acc) {
+			continue
+		}
+		idx, ok := mapAddress[v.Address]
+		if !ok {
+			idx = len(resp.List)
+			mapAddress[v.Address] = idx
+			resp.List = append(resp.List, RoleInfo{
+				ChainType: v.ChainType,
+				Address:   v.Address,
+			})
+		}
+		resp.List[idx].Roles = append(resp.List[idx].Roles, v.Role)
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -0,0 +1,66 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+type ReqRoleLogList struct {
+	core.ChainTypeAddress
+	Pagination
+	Account string `json:"account" binding:"required"`
+}
+
+type RespRoleLogList struct {
+	Total int64                        `json:"total"`
+	List  []tables.TableAccountRoleLog `json:"list"`
+}
+
+func (h *HttpHandle) RoleLogList(ctx *gin.Context) {
+	var (
+		funcName               = "RoleLogList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqRoleLogList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, ctx.Request.Context())
+
+	if err = h.doRoleLogList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doRoleLogList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doRoleLogList(ctx context.Context, req *ReqRoleLogList, apiResp *api_code.ApiResp) error {
+	var resp RespRoleLogList
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	list, total, err := h.DbDao.GetAccountRoleLogList(parentAccountId, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query account role log")
+		return fmt.Errorf("GetAccountRoleLogList err: %s", err.Error())
+	}
+	resp.Total = total
+	resp.List = list
+	if resp.List == nil {
+		resp.List = make([]tables.TableAccountRoleLog, 0)
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
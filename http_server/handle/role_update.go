@@ -0,0 +1,271 @@
+package handle
+
+import (
+	"context"
+	"crypto/md5"
+	"das_sub_account/config"
+	"das_sub_account/consts"
+	"das_sub_account/internal"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ReqRoleUpdate struct {
+	core.ChainTypeAddress
+	Account   string                `json:"account" binding:"required"`
+	Grantee   core.ChainTypeAddress `json:"grantee" binding:"required"`
+	Roles     []string              `json:"roles" binding:"required"`
+	Revoke    bool                  `json:"revoke"`
+	Timestamp int64                 `json:"timestamp" binding:"required"`
+}
+
+type RespRoleUpdate struct {
+	SignInfoList
+}
+
+func (r *ReqRoleUpdate) GetSignInfo() (signKey, signMsg, reqDataStr string) {
+	reqData, _ := json.Marshal(r)
+	reqDataStr = string(reqData)
+	signKey = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%d", reqDataStr, time.Now().UnixNano()))))
+	signMsg = common.DotBitPrefix + hex.EncodeToString(common.Blake2b(reqData))
+	return
+}
+
+func (h *HttpHandle) RoleUpdate(ctx *gin.Context) {
+	var (
+		funcName               = "RoleUpdate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqRoleUpdate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doRoleUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doRoleUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doRoleUpdate(ctx context.Context, req *ReqRoleUpdate, apiResp *api_code.ApiResp) error {
+	var resp RespRoleUpdate
+	resp.List = make([]SignInfo, 0)
+
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+
+	res, err := req.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return err
+	}
+	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+
+	action := consts.ActionRoleUpdate
+	if err := h.check(address, req.Account, action, apiResp); err != nil {
+		return err
+	}
+
+	if time.UnixMilli(req.Timestamp).Add(time.Minute * 10).Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params timestamp invalid")
+		return nil
+	}
+	if _, err := h.checkRoleUpdate(req, address, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	//
+	signKey, signMsg, reqDataStr := req.GetSignInfo()
+
+	// cache
+	if err = h.RC.SetSignTxCache(signKey, reqDataStr); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("SetSignTxCache err: %s", err.Error())
+	}
+
+	//
+	signType := res.DasAlgorithmId
+	if signType == common.DasAlgorithmIdEth712 {
+		signType = common.DasAlgorithmIdEth
+	}
+	resp.Action = action
+	resp.SignKey = signKey
+	resp.List = append(resp.List, SignInfo{
+		SignList: []txbuilder.SignData{{
+			SignType: signType,
+			SignMsg:  signMsg,
+		}},
+	})
+	resp.SignList = []txbuilder.SignData{{
+		SignType: signType,
+		SignMsg:  signMsg,
+	}}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+// checkRoleUpdate only the owner can grant or revoke roles
+func (h *HttpHandle) checkRoleUpdate(req *ReqRoleUpdate, address string, apiResp *api_code.ApiResp) (*core.DasAddressHex, error) {
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	acc, err := h.DbDao.GetAccountInfoByAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "search account err")
+		return nil, fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	}
+	if !strings.EqualFold(acc.Owner, address) {
+		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "only the owner can manage roles")
+		return nil, nil
+	}
+
+	grantee, err := req.Grantee.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "grantee invalid")
+		return nil, nil
+	}
+	if strings.EqualFold(grantee.AddressHex, acc.Owner) || strings.EqualFold(grantee.AddressHex, acc.Manager) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "the owner and the manager have all roles")
+		return nil, nil
+	}
+	if len(req.Roles) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "roles is empty")
+		return nil, nil
+	}
+	for _, v := range req.Roles {
+		if !tables.IsAccountRole(v) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("role [%s] not supported", v))
+			return nil, nil
+		}
+	}
+	return grantee, nil
+}
+
+func (h *HttpHandle) doActionRoleUpdate(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp) error {
+	var data ReqRoleUpdate
+	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
+		if err == redis.Nil {
+			apiResp.ApiRespErr(api_code.ApiCodeTxExpired, "sign key not exist(tx expired)")
+		} else {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		}
+		return fmt.Errorf("GetSignTxCache err: %s", err.Error())
+	} else if err = json.Unmarshal([]byte(txStr), &data); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Unmarshal err")
+		return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	res, err := data.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	_, signMsg, _ := data.GetSignInfo()
+	address := ""
+	var signType common.DasAlgorithmId
+	var signature string
+	if len(req.List) != 0 {
+		signType = req.List[0].SignList[0].SignType
+		signature = req.List[0].SignList[0].SignMsg
+	} else {
+		signType = req.SignList[0].SignType
+		signature = req.SignList[0].SignMsg
+	}
+	if signType == common.DasAlgorithmIdWebauthn {
+		address = req.SignAddress
+	} else {
+		address = res.AddressHex
+	}
+	verifyRes, _, err := api_code.VerifySignature(signType, signMsg, signature, address)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "VerifySignature err")
+		return fmt.Errorf("VerifySignature err: %s", err.Error())
+	}
+	if !verifyRes {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
+		return nil
+	}
+
+	// the owner may be changed since the sign info was returned
+	operator := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+	grantee, err := h.checkRoleUpdate(&data, operator, apiResp)
+	if err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+	defer func() {
+		if apiResp.ErrNo == api_code.ApiCodeSuccess {
+			h.addAuditLogByAddress(ctx, data.Account, address, newAuditInfo(tables.AuditActionRoleUpdate, nil, data), "")
+		}
+	}()
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
+	granteeAddress := strings.ToLower(grantee.AddressHex)
+	var roles []tables.AccountRole
+	var list []tables.TableAccountRole
+	var logs []tables.TableAccountRoleLog
+	for _, v := range data.Roles {
+		role := tables.AccountRole(v)
+		roles = append(roles, role)
+		list = append(list, tables.TableAccountRole{
+			ParentAccountId: parentAccountId,
+			Account:         strings.ToLower(data.Account),
+			ChainType:       grantee.ChainType,
+			Address:         granteeAddress,
+			Role:            role,
+			IssuerChainType: res.ChainType,
+			Issuer:          operator,
+		})
+		operation := tables.AccountRoleOperationGrant
+		if data.Revoke {
+			operation = tables.AccountRoleOperationRevoke
+		}
+		logs = append(logs, tables.TableAccountRoleLog{
+			ParentAccountId: parentAccountId,
+			Account:         strings.ToLower(data.Account),
+			ChainType:       grantee.ChainType,
+			Address:         granteeAddress,
+			Role:            role,
+			Operation:       operation,
+			Operator:        strings.ToLower(res.AddressHex),
+		})
+	}
+
+	if data.Revoke {
+		if err := h.DbDao.RevokeAccountRoles(parentAccountId, granteeAddress, roles, logs); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to revoke roles")
+			return fmt.Errorf("RevokeAccountRoles err: %s", err.Error())
+		}
+		return nil
+	}
+	if err := h.DbDao.GrantAccountRoles(list, logs); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to grant roles")
+		return fmt.Errorf("GrantAccountRoles err: %s", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package handle
+
+import (
+	"das_sub_account/tables"
+	"encoding/csv"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"github.com/shopspring/decimal"
+	"net/http"
+	"strings"
+)
+
+const (
+	RuleFormatJson = "json"
+	RuleFormatCsv  = "csv"
+)
+
+var ruleCsvHeader = []string{"rule_type", "index", "name", "note", "price", "status", "ast"}
+
+type ReqRuleExport struct {
+	Account string `json:"account" binding:"required"`
+	Format  string `json:"format"`
+}
+
+// RespRuleExport the same format as the data of rule/import/preview
+type RespRuleExport struct {
+	PriceRules     witness.SubAccountRuleSlice `json:"price_rules"`
+	PreservedRules witness.SubAccountRuleSlice `json:"preserved_rules"`
+}
+
+// RuleExport the price and preserved rules of the parent account as json or csv
+func (h *HttpHandle) RuleExport(ctx *gin.Context) {
+	var (
+		funcName               = "RuleExport"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqRuleExport
+		apiResp                api_code.ApiResp
+		resp                   RespRuleExport
+		err                    error
+	)
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+	req.Account = strings.ToLower(req.Account)
+	if req.Format != "" && req.Format != RuleFormatJson && req.Format != RuleFormatCsv {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "format invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	if resp.PriceRules, err = h.getRuleList(ctx.Request.Context(), req.Account, common.ActionDataTypeSubAccountPriceRules, &apiResp); err != nil || apiResp.ErrNo != api_code.ApiCodeSuccess {
+		log.Error("getRuleList err:", err, funcName, req.Account, ctx.Request.Context())
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	if resp.PreservedRules, err = h.getRuleList(ctx.Request.Context(), req.Account, common.ActionDataTypeSubAccountPreservedRules, &apiResp); err != nil || apiResp.ErrNo != api_code.ApiCodeSuccess {
+		log.Error("getRuleList err:", err, funcName, req.Account, ctx.Request.Context())
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	if resp.PriceRules == nil {
+		resp.PriceRules = make(witness.SubAccountRuleSlice, 0)
+	}
+	if resp.PreservedRules == nil {
+		resp.PreservedRules = make(witness.SubAccountRuleSlice, 0)
+	}
+
+	if req.Format != RuleFormatCsv {
+		apiResp.ApiRespOK(resp)
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	var records [][]string
+	records = append(records, genRuleCsvRecords(tables.RuleTypePriceRules, resp.PriceRules)...)
+	records = append(records, genRuleCsvRecords(tables.RuleTypePreservedRules, resp.PreservedRules)...)
+
+	ctx.Header("Content-Description", "File Transfer")
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s-rules.csv", req.Account))
+	ctx.Header("Content-Type", "text/csv")
+
+	w := csv.NewWriter(ctx.Writer)
+	if err := w.Write(ruleCsvHeader); err != nil {
+		log.Error(err)
+		_ = ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	if err := w.WriteAll(records); err != nil {
+		log.Error(err)
+		_ = ctx.AbortWithError(http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Status(http.StatusOK)
+}
+
+// genRuleCsvRecords one rule a line, the ast is kept as json
+func genRuleCsvRecords(ruleType tables.RuleType, rules witness.SubAccountRuleSlice) (records [][]string) {
+	for i, v := range rules {
+		price := ""
+		if ruleType == tables.RuleTypePriceRules {
+			price = decimal.NewFromFloat(v.Price).String()
+		}
+		records = append(records, []string{
+			string(ruleType),
+			fmt.Sprintf("%d", i),
+			v.Name,
+			v.Note,
+			price,
+			fmt.Sprintf("%d", v.Status),
+			toolib.JsonString(v.Ast),
+		})
+	}
+	return
+}
@@ -0,0 +1,377 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gogf/gf/v2/util/gconv"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxRuleImportSize   = 2 * 1024 * 1024
+	maxInListAccountNum = 999
+)
+
+type ReqRuleImportPreview struct {
+	core.ChainTypeAddress
+	Account string `json:"account" binding:"required"`
+	Format  string `json:"format"`
+	Data    string `json:"data" binding:"required"` // the content of the file of rule/export
+}
+
+type RespRuleImportPreview struct {
+	List []RuleImportPreview `json:"list"`
+}
+
+// RuleImportPreview pass the list to price/rule/update or preserved/rule/update
+type RuleImportPreview struct {
+	RuleType    tables.RuleType             `json:"rule_type"`
+	List        witness.SubAccountRuleSlice `json:"list"`
+	Diff        RuleDiff                    `json:"diff"`
+	SplitList   []string                    `json:"split_list"`
+	WitnessNum  int                         `json:"witness_num"`
+	WitnessSize int                         `json:"witness_size"`
+}
+
+type RuleDiff struct {
+	Added            []string `json:"added"`
+	Removed          []string `json:"removed"`
+	Changed          []string `json:"changed"`
+	WhitelistAdded   []string `json:"whitelist_added"`
+	WhitelistRemoved []string `json:"whitelist_removed"`
+}
+
+func (h *HttpHandle) RuleImportPreview(ctx *gin.Context) {
+	var (
+		funcName               = "RuleImportPreview"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqRuleImportPreview
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, req.Format, len(req.Data), ctx.Request.Context())
+
+	if err = h.doRuleImportPreview(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doRuleImportPreview err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doRuleImportPreview(ctx context.Context, req *ReqRuleImportPreview, apiResp *api_code.ApiResp) error {
+	var resp RespRuleImportPreview
+	resp.List = make([]RuleImportPreview, 0)
+	req.Account = strings.ToLower(req.Account)
+
+	if len(req.Data) > maxRuleImportSize {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "data is too large")
+		return nil
+	}
+	var imported RespRuleExport
+	var err error
+	switch req.Format {
+	case "", RuleFormatJson:
+		err = json.Unmarshal([]byte(req.Data), &imported)
+	case RuleFormatCsv:
+		imported, err = parseRuleCsv(req.Data)
+	default:
+		err = errors.New("format invalid")
+	}
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, err.Error())
+		return nil
+	}
+
+	for _, v := range []struct {
+		ruleType       tables.RuleType
+		actionDataType common.ActionDataType
+		rules          witness.SubAccountRuleSlice
+	}{
+		{tables.RuleTypePriceRules, common.ActionDataTypeSubAccountPriceRules, imported.PriceRules},
+		{tables.RuleTypePreservedRules, common.ActionDataTypeSubAccountPreservedRules, imported.PreservedRules},
+	} {
+		if v.rules == nil {
+			continue
+		}
+		preview, err := h.previewRuleImport(ctx, req.Account, v.actionDataType, v.rules, apiResp)
+		if err != nil {
+			return err
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+		preview.RuleType = v.ruleType
+		resp.List = append(resp.List, *preview)
+	}
+	if len(resp.List) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "no rules")
+		return nil
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) previewRuleImport(ctx context.Context, account string, actionDataType common.ActionDataType, rules witness.SubAccountRuleSlice, apiResp *api_code.ApiResp) (*RuleImportPreview, error) {
+	preview := RuleImportPreview{SplitList: make([]string, 0)}
+
+	entity := witness.NewSubAccountRuleEntity(account)
+	entity.Rules = rules
+	if err := entity.Check(); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeRuleFormatErr, err.Error())
+		return nil, nil
+	}
+	for _, v := range rules {
+		list, err := splitRule(account, v)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeRuleSizeExceedsLimit, err.Error())
+			return nil, nil
+		}
+		if len(list) > 1 {
+			// only one in_list rule is allowed in the preserved rules
+			if actionDataType == common.ActionDataTypeSubAccountPreservedRules && v.Ast.Name == string(witness.FunctionInList) {
+				apiResp.ApiRespErr(api_code.ApiCodePreservedRulesMostBeOne, fmt.Sprintf("preserved rule [%s]: in_list can't be split, keep it within %d accounts and one witness", v.Name, maxInListAccountNum))
+				return nil, nil
+			}
+			preview.SplitList = append(preview.SplitList, v.Name)
+		}
+		preview.List = append(preview.List, list...)
+	}
+	if preview.List == nil {
+		preview.List = make(witness.SubAccountRuleSlice, 0)
+	}
+	for i, v := range preview.List {
+		v.Index = uint32(i)
+	}
+
+	// the same checks as the update
+	entity.Rules = preview.List
+	if _, err := h.checkRules(ctx, entity, actionDataType, apiResp); err != nil {
+		return nil, nil
+	}
+	ruleData, err := entity.GenData()
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeRuleSizeExceedsLimit, err.Error())
+		return nil, nil
+	}
+	witnessData, err := entity.GenDasData(actionDataType, ruleData)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
+		return nil, fmt.Errorf("GenDasData err: %s", err.Error())
+	}
+	preview.WitnessNum = len(witnessData)
+	for _, v := range witnessData {
+		preview.WitnessSize += len(v)
+	}
+	if preview.WitnessSize > maxRuleWitnessSize {
+		apiResp.ApiRespErr(api_code.ApiCodeRuleSizeExceedsLimit, "rule size exceeds limit")
+		return nil, nil
+	}
+
+	current, err := h.getRuleList(ctx, account, actionDataType, apiResp)
+	if err != nil {
+		return nil, err
+	}
+	preview.Diff = diffRules(current, preview.List)
+	return &preview, nil
+}
+
+// parseRuleCsv the csv of rule/export, the lines of one rule type are in the order of the rules
+func parseRuleCsv(data string) (res RespRuleExport, err error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return res, fmt.Errorf("csv invalid: %s", err.Error())
+	} else if len(records) == 0 {
+		return res, errors.New("csv is empty")
+	}
+	cols := make(map[string]int)
+	for i, v := range records[0] {
+		cols[strings.TrimSpace(v)] = i
+	}
+	for _, v := range ruleCsvHeader {
+		if _, ok := cols[v]; !ok {
+			return res, fmt.Errorf("csv column [%s] missing", v)
+		}
+	}
+
+	for i, record := range records[1:] {
+		line := i + 2
+		rule := witness.SubAccountRule{
+			Name: record[cols["name"]],
+			Note: record[cols["note"]],
+		}
+		if status, err := strconv.ParseUint(record[cols["status"]], 10, 8); err != nil {
+			return res, fmt.Errorf("line %d status invalid", line)
+		} else {
+			rule.Status = uint8(status)
+		}
+		if err := json.Unmarshal([]byte(record[cols["ast"]]), &rule.Ast); err != nil {
+			return res, fmt.Errorf("line %d ast invalid: %s", line, err.Error())
+		}
+
+		switch tables.RuleType(record[cols["rule_type"]]) {
+		case tables.RuleTypePriceRules:
+			if rule.Price, err = strconv.ParseFloat(record[cols["price"]], 64); err != nil {
+				return res, fmt.Errorf("line %d price invalid", line)
+			}
+			res.PriceRules = append(res.PriceRules, &rule)
+		case tables.RuleTypePreservedRules:
+			res.PreservedRules = append(res.PreservedRules, &rule)
+		default:
+			return res, fmt.Errorf("line %d rule_type invalid", line)
+		}
+	}
+	return res, nil
+}
+
+// splitRule the list of in_list, include_words and include_chars is split into the rules of the same price,
+// in_list most 999 accounts a rule, and each rule fits in one witness.
+// The preserved in_list rule can't be split, the caller rejects it
+func splitRule(parentAccount string, rule *witness.SubAccountRule) (witness.SubAccountRuleSlice, error) {
+	if rule.Ast.Type != witness.Function || len(rule.Ast.Arguments) != 2 || rule.Ast.Arguments[1].Type != witness.Value {
+		return witness.SubAccountRuleSlice{rule}, nil
+	}
+	limit := 0
+	switch witness.FunctionType(rule.Ast.Name) {
+	case witness.FunctionInList:
+		limit = maxInListAccountNum
+	case witness.FunctionIncludeWords, witness.FunctionIncludeCharts:
+	default:
+		return witness.SubAccountRuleSlice{rule}, nil
+	}
+	values := gconv.Strings(rule.Ast.Arguments[1].Value)
+
+	var chunks [][]string
+	var split func(list []string) error
+	split = func(list []string) error {
+		if limit > 0 && len(list) > limit {
+			if err := split(list[:limit]); err != nil {
+				return err
+			}
+			return split(list[limit:])
+		}
+		entity := witness.NewSubAccountRuleEntity(parentAccount)
+		entity.Rules = witness.SubAccountRuleSlice{newSplitRule(rule, rule.Name, list)}
+		if err := entity.Check(); err != nil {
+			return err
+		}
+		if _, err := entity.GenData(); err == nil {
+			chunks = append(chunks, list)
+			return nil
+		} else if len(list) == 1 {
+			return fmt.Errorf("rule [%s]: %s", rule.Name, err.Error())
+		}
+		if err := split(list[:len(list)/2]); err != nil {
+			return err
+		}
+		return split(list[len(list)/2:])
+	}
+	if err := split(values); err != nil {
+		return nil, err
+	}
+	if len(chunks) == 1 {
+		return witness.SubAccountRuleSlice{rule}, nil
+	}
+
+	res := make(witness.SubAccountRuleSlice, 0, len(chunks))
+	for i, v := range chunks {
+		res = append(res, newSplitRule(rule, fmt.Sprintf("%s (%d/%d)", rule.Name, i+1, len(chunks)), v))
+	}
+	return res, nil
+}
+
+func newSplitRule(rule *witness.SubAccountRule, name string, values []string) *witness.SubAccountRule {
+	value := *rule.Ast.Arguments[1]
+	value.Value = values
+	res := *rule
+	res.Name = name
+	res.Ast.Arguments = witness.AstExpressions{rule.Ast.Arguments[0], &value}
+	return &res
+}
+
+// diffRules the rules of the same name are compared, and the accounts of in_list
+func diffRules(current, imported witness.SubAccountRuleSlice) RuleDiff {
+	diff := RuleDiff{
+		Added:            make([]string, 0),
+		Removed:          make([]string, 0),
+		Changed:          make([]string, 0),
+		WhitelistAdded:   make([]string, 0),
+		WhitelistRemoved: make([]string, 0),
+	}
+	mapCurrent := make(map[string]int)
+	for i, v := range current {
+		mapCurrent[v.Name] = i
+	}
+	mapImported := make(map[string]struct{})
+	for i, v := range imported {
+		mapImported[v.Name] = struct{}{}
+		idx, ok := mapCurrent[v.Name]
+		if !ok {
+			diff.Added = append(diff.Added, v.Name)
+			continue
+		}
+		old := current[idx]
+		if idx != i || old.Note != v.Note || old.Price != v.Price || old.Status != v.Status || !sameRuleAst(old.Ast, v.Ast) {
+			diff.Changed = append(diff.Changed, v.Name)
+		}
+	}
+	for _, v := range current {
+		if _, ok := mapImported[v.Name]; !ok {
+			diff.Removed = append(diff.Removed, v.Name)
+		}
+	}
+
+	currentWhitelist, importedWhitelist := getRuleWhitelist(current), getRuleWhitelist(imported)
+	for k := range importedWhitelist {
+		if _, ok := currentWhitelist[k]; !ok {
+			diff.WhitelistAdded = append(diff.WhitelistAdded, k)
+		}
+	}
+	for k := range currentWhitelist {
+		if _, ok := importedWhitelist[k]; !ok {
+			diff.WhitelistRemoved = append(diff.WhitelistRemoved, k)
+		}
+	}
+	sort.Strings(diff.WhitelistAdded)
+	sort.Strings(diff.WhitelistRemoved)
+	return diff
+}
+
+func sameRuleAst(a, b witness.AstExpression) bool {
+	aBys, _ := json.Marshal(a)
+	bBys, _ := json.Marshal(b)
+	return string(aBys) == string(bBys)
+}
+
+func getRuleWhitelist(rules witness.SubAccountRuleSlice) map[string]struct{} {
+	res := make(map[string]struct{})
+	for _, v := range rules {
+		if v.Ast.Type == witness.Function &&
+			v.Ast.Name == string(witness.FunctionInList) &&
+			len(v.Ast.Arguments) == 2 &&
+			v.Ast.Arguments[1].Type == witness.Value {
+			for _, acc := range gconv.Strings(v.Ast.Arguments[1].Value) {
+				res[strings.ToLower(acc)] = struct{}{}
+			}
+		}
+	}
+	return res
+}
@@ -0,0 +1,116 @@
+package handle
+
+import (
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gogf/gf/v2/util/gconv"
+	"testing"
+)
+
+func newTestListRule(name string, function witness.FunctionType, valueType witness.ValueType, values []string) *witness.SubAccountRule {
+	return &witness.SubAccountRule{
+		Name:   name,
+		Price:  10,
+		Status: 1,
+		Ast: witness.AstExpression{
+			Type: witness.Function,
+			Name: string(function),
+			Arguments: witness.AstExpressions{
+				{Type: witness.Variable, Name: string(witness.Account)},
+				{Type: witness.Value, ValueType: valueType, Value: values},
+			},
+		},
+	}
+}
+
+func newTestAccountIds(parentAccount string, num int) []string {
+	list := make([]string, 0, num)
+	for i := 0; i < num; i++ {
+		list = append(list, common.Bytes2Hex(common.GetAccountIdByAccount(fmt.Sprintf("a%d.%s", i, parentAccount))))
+	}
+	return list
+}
+
+func TestSplitRule(t *testing.T) {
+	parentAccount := "test.bit"
+	tests := []struct {
+		name      string
+		rule      *witness.SubAccountRule
+		wantNames []string
+		wantNums  []int
+	}{
+		{
+			name: "not a function",
+			rule: &witness.SubAccountRule{Name: "all", Price: 10, Ast: witness.AstExpression{
+				Type:      witness.Value,
+				ValueType: witness.Bool,
+				Value:     true,
+			}},
+			wantNames: []string{"all"},
+		},
+		{
+			name:      "in_list within the limit",
+			rule:      newTestListRule("vip", witness.FunctionInList, witness.BinaryArray, newTestAccountIds(parentAccount, 10)),
+			wantNames: []string{"vip"},
+			wantNums:  []int{10},
+		},
+		{
+			name:      "in_list of the limit",
+			rule:      newTestListRule("vip", witness.FunctionInList, witness.BinaryArray, newTestAccountIds(parentAccount, maxInListAccountNum)),
+			wantNames: []string{"vip"},
+			wantNums:  []int{maxInListAccountNum},
+		},
+		{
+			name:      "in_list over the limit",
+			rule:      newTestListRule("vip", witness.FunctionInList, witness.BinaryArray, newTestAccountIds(parentAccount, maxInListAccountNum*2+2)),
+			wantNames: []string{"vip (1/3)", "vip (2/3)", "vip (3/3)"},
+			wantNums:  []int{maxInListAccountNum, maxInListAccountNum, 2},
+		},
+		{
+			name:      "include_words",
+			rule:      newTestListRule("words", witness.FunctionIncludeWords, witness.StringArray, []string{"abc", "def"}),
+			wantNames: []string{"words"},
+			wantNums:  []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := splitRule(parentAccount, tt.rule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != len(tt.wantNames) {
+				t.Fatal(len(list))
+			}
+			for i, v := range list {
+				if v.Name != tt.wantNames[i] || v.Price != tt.rule.Price {
+					t.Fatal(i, v.Name, v.Price)
+				}
+				if len(tt.wantNums) > 0 {
+					if num := len(gconv.Strings(v.Ast.Arguments[1].Value)); num != tt.wantNums[i] {
+						t.Fatal(i, num)
+					}
+				}
+			}
+		})
+	}
+
+	// the split rules keep the accounts in order and leave the original rule unchanged
+	accountIds := newTestAccountIds(parentAccount, maxInListAccountNum+1)
+	rule := newTestListRule("vip", witness.FunctionInList, witness.BinaryArray, accountIds)
+	list, err := splitRule(parentAccount, rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var joined []string
+	for _, v := range list {
+		joined = append(joined, gconv.Strings(v.Ast.Arguments[1].Value)...)
+	}
+	if len(joined) != len(accountIds) || joined[0] != accountIds[0] || joined[len(joined)-1] != accountIds[len(accountIds)-1] {
+		t.Fatal(len(joined))
+	}
+	if rule.Name != "vip" || len(gconv.Strings(rule.Ast.Arguments[1].Value)) != len(accountIds) {
+		t.Fatal(rule.Name)
+	}
+}
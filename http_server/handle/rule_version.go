@@ -0,0 +1,269 @@
+package handle
+
+import (
+	"context"
+	sub_api_code "das_sub_account/http_server/api_code"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/witness"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+)
+
+var mapRuleTypeActionDataType = map[tables.RuleType]common.ActionDataType{
+	tables.RuleTypePriceRules:     common.ActionDataTypeSubAccountPriceRules,
+	tables.RuleTypePreservedRules: common.ActionDataTypeSubAccountPreservedRules,
+}
+
+type ReqRuleVersionList struct {
+	core.ChainTypeAddress
+	Pagination
+	Account  string          `json:"account" binding:"required"`
+	RuleType tables.RuleType `json:"rule_type" binding:"required"`
+}
+
+type RespRuleVersionList struct {
+	Total int64         `json:"total"`
+	List  []RuleVersion `json:"list"`
+}
+
+type RuleVersion struct {
+	VersionId      uint64          `json:"version_id"`
+	RuleType       tables.RuleType `json:"rule_type"`
+	TxHash         string          `json:"tx_hash"`
+	BlockNumber    uint64          `json:"block_number"`
+	BlockTimestamp int64           `json:"block_timestamp"`
+	RuleNum        int             `json:"rule_num"`
+	Current        bool            `json:"current"`
+	Rollbackable   bool            `json:"rollbackable"`
+}
+
+func (h *HttpHandle) RuleVersionList(ctx *gin.Context) {
+	var (
+		funcName               = "RuleVersionList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqRuleVersionList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doRuleVersionList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doRuleVersionList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doRuleVersionList(ctx context.Context, req *ReqRuleVersionList, apiResp *api_code.ApiResp) error {
+	var resp RespRuleVersionList
+	resp.List = make([]RuleVersion, 0)
+	if _, ok := mapRuleTypeActionDataType[req.RuleType]; !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "rule_type invalid")
+		return nil
+	}
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(strings.ToLower(req.Account)))
+	latest, err := h.DbDao.GetLatestRuleVersion(parentAccountId, req.RuleType)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query rule version")
+		return fmt.Errorf("GetLatestRuleVersion err: %s", err.Error())
+	}
+	list, total, err := h.DbDao.GetRuleVersionList(parentAccountId, req.RuleType, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query rule version")
+		return fmt.Errorf("GetRuleVersionList err: %s", err.Error())
+	}
+	resp.Total = total
+	for _, v := range list {
+		resp.List = append(resp.List, RuleVersion{
+			VersionId:      v.Id,
+			RuleType:       v.RuleType,
+			TxHash:         v.TxHash,
+			BlockNumber:    v.BlockNumber,
+			BlockTimestamp: v.BlockTimestamp,
+			RuleNum:        v.RuleNum,
+			Current:        v.Id == latest.Id,
+			Rollbackable:   v.IsRollbackable(),
+		})
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+type ReqRuleVersionDiff struct {
+	core.ChainTypeAddress
+	Account       string `json:"account" binding:"required"`
+	FromVersionId uint64 `json:"from_version_id"` // 0: the rules on chain
+	ToVersionId   uint64 `json:"to_version_id"`   // 0: the rules on chain
+}
+
+type RespRuleVersionDiff struct {
+	RuleType tables.RuleType             `json:"rule_type"`
+	FromList witness.SubAccountRuleSlice `json:"from_list"`
+	ToList   witness.SubAccountRuleSlice `json:"to_list"`
+	Diff     RuleDiff                    `json:"diff"`
+}
+
+func (h *HttpHandle) RuleVersionDiff(ctx *gin.Context) {
+	var (
+		funcName               = "RuleVersionDiff"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqRuleVersionDiff
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doRuleVersionDiff(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doRuleVersionDiff err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doRuleVersionDiff(ctx context.Context, req *ReqRuleVersionDiff, apiResp *api_code.ApiResp) error {
+	var resp RespRuleVersionDiff
+	req.Account = strings.ToLower(req.Account)
+	if req.FromVersionId == 0 && req.ToVersionId == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "version id invalid")
+		return nil
+	}
+
+	var versions []*tables.TableRuleVersion
+	for _, id := range []uint64{req.FromVersionId, req.ToVersionId} {
+		if id == 0 {
+			versions = append(versions, nil)
+			continue
+		}
+		version, err := h.getRuleVersion(id, req.Account, apiResp)
+		if err != nil || version == nil {
+			return err
+		}
+		if resp.RuleType != "" && resp.RuleType != version.RuleType {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "rule_type of the versions is different")
+			return nil
+		}
+		resp.RuleType = version.RuleType
+		versions = append(versions, version)
+	}
+
+	var lists []witness.SubAccountRuleSlice
+	for _, version := range versions {
+		var list witness.SubAccountRuleSlice
+		var err error
+		if version == nil {
+			if list, err = h.getRuleList(ctx, req.Account, mapRuleTypeActionDataType[resp.RuleType], apiResp); err != nil {
+				return err
+			}
+		} else if list, err = version.GetRules(); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "failed to parse rules")
+			return fmt.Errorf("GetRules err: %s", err.Error())
+		}
+		if list == nil {
+			list = make(witness.SubAccountRuleSlice, 0)
+		}
+		lists = append(lists, list)
+	}
+	resp.FromList, resp.ToList = lists[0], lists[1]
+	resp.Diff = diffRules(resp.FromList, resp.ToList)
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+type ReqRuleVersionRollback struct {
+	core.ChainTypeAddress
+	Account   string `json:"account" binding:"required"`
+	VersionId uint64 `json:"version_id" binding:"required"`
+}
+
+// RuleVersionRollback the same as price/rule/update or preserved/rule/update with the rules of the version
+func (h *HttpHandle) RuleVersionRollback(ctx *gin.Context) {
+	var (
+		funcName               = "RuleVersionRollback"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqRuleVersionRollback
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doRuleVersionRollback(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doRuleVersionRollback err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doRuleVersionRollback(ctx context.Context, req *ReqRuleVersionRollback, apiResp *api_code.ApiResp) error {
+	req.Account = strings.ToLower(req.Account)
+	version, err := h.getRuleVersion(req.VersionId, req.Account, apiResp)
+	if err != nil || version == nil {
+		return err
+	}
+	if !version.IsRollbackable() {
+		apiResp.ApiRespErr(sub_api_code.ApiCodeRuleVersionNotRollbackable, fmt.Sprintf("%d accounts of in_list are unknown, the version can't be rolled back", version.UndecodedNum))
+		return nil
+	}
+	rules, err := version.GetRules()
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "failed to parse rules")
+		return fmt.Errorf("GetRules err: %s", err.Error())
+	}
+
+	reqUpdate := ReqPriceRuleUpdate{
+		ChainTypeAddress: req.ChainTypeAddress,
+		Account:          req.Account,
+		List:             rules,
+	}
+	switch version.RuleType {
+	case tables.RuleTypePriceRules:
+		return h.doPriceRuleUpdate(ctx, &reqUpdate, apiResp)
+	case tables.RuleTypePreservedRules:
+		return h.doPreservedRuleUpdate(ctx, &reqUpdate, apiResp)
+	default:
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "rule_type invalid")
+		return nil
+	}
+}
+
+// getRuleVersion nil if the version is not of the parent account
+func (h *HttpHandle) getRuleVersion(versionId uint64, account string, apiResp *api_code.ApiResp) (*tables.TableRuleVersion, error) {
+	version, err := h.DbDao.GetRuleVersion(versionId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query rule version")
+		return nil, fmt.Errorf("GetRuleVersion err: %s", err.Error())
+	}
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(account))
+	if version.Id == 0 || version.ParentAccountId != parentAccountId {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "rule version not exist")
+		return nil, nil
+	}
+	return &version, nil
+}
@@ -72,6 +72,9 @@ func (h *HttpHandle) doServiceProviderWithdraw2(ctx context.Context, req *ReqSer
 		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
 		return fmt.Errorf("buildServiceProviderWithdraw2Tx err: %s", err.Error())
 	}
+	if req.Withdraw && resp.Hash != "" {
+		h.addAuditLog(ctx, req.Account, req.ServiceProviderAddress, tables.AuditRoleInternal, newAuditInfo(tables.AuditActionWithdraw, nil, resp), resp.Hash)
+	}
 	apiResp.ApiRespOK(resp)
 	return nil
 }
@@ -1,7 +1,7 @@
 package handle
 
 import (
-	"das_sub_account/config"
+	"das_sub_account/tables"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
@@ -9,7 +9,6 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"net/http"
-	"strings"
 	"time"
 )
 
@@ -31,6 +30,8 @@ type Claims struct {
 	SubAid    common.DasSubAlgorithmId `json:"sub_aid"`
 	Timestamp int64                    `json:"timestamp"`
 	Signature string                   `json:"signature"`
+	TokenType string                   `json:"token_type"`
+	SessionId string                   `json:"sid"`
 	jwt.RegisteredClaims
 }
 
@@ -123,35 +124,27 @@ func (h *HttpHandle) doSignIn(ctx *gin.Context, req *ReqSignIn, apiResp *api_cod
 		apiResp.ApiRespErr(api_code.ApiCodeParentAccountExpired, "account expired")
 		return nil
 	}
-	if !strings.EqualFold(accInfo.Manager, res.AddressHex) && !strings.EqualFold(accInfo.Owner, res.AddressHex) {
+	if ok, err := h.hasAccountPermission(accInfo, res.AddressHex, tables.AccountRoleList); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query account role")
+		return err
+	} else if !ok {
 		apiResp.ApiRespErr(api_code.ApiCodeNoAccountPermissions, "no account permissions")
 		return nil
 	}
 
-	claims := &Claims{
+	access, refresh, accessStr, refreshStr, err := newJwtTokens(Claims{
 		Account:   req.Account,
 		Address:   res.AddressHex,
 		Aid:       res.DasAlgorithmId,
 		SubAid:    res.DasSubAlgorithmId,
 		Timestamp: req.Timestamp,
 		Signature: req.Signature,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(timestamp.Add(time.Hour * 24)),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString([]byte(config.Cfg.Das.JwtKey))
+	})
 	if err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeError500, "internal error")
 		return err
 	}
-
-	if h.DasCore.NetType() == common.DasNetTypeMainNet {
-		ctx.SetCookie("token", tokenString, int(claims.ExpiresAt.Sub(now).Seconds()), "/", "topdid.com", true, true)
-	} else {
-		ctx.SetCookie("token", tokenString, int(claims.ExpiresAt.Sub(now).Seconds()), "/", "", false, false)
-	}
+	setJwtCookies(ctx, access, refresh, accessStr, refreshStr)
 	resp := &RespSignIn{}
 	apiResp.ApiRespOK(resp)
 	return nil
@@ -0,0 +1,82 @@
+package handle
+
+import (
+	"das_sub_account/tables"
+	"errors"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type RespSignInRefresh struct {
+	ExpiredAt int64 `json:"expired_at"`
+}
+
+func (h *HttpHandle) SignInRefresh(ctx *gin.Context) {
+	var (
+		funcName               = "SignInRefresh"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+
+	if err = h.doSignInRefresh(ctx, &apiResp); err != nil {
+		log.Error("doSignInRefresh err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+// doSignInRefresh the refresh token can be used only once, a new pair of tokens is issued for the same session
+func (h *HttpHandle) doSignInRefresh(ctx *gin.Context, apiResp *api_code.ApiResp) error {
+	tokenVal, err := ctx.Cookie(jwtCookieRefresh)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
+		return nil
+	}
+	claims, err := h.parseJwtToken(tokenVal, JwtTokenTypeRefresh)
+	if err != nil {
+		if errors.Is(err, errJwtUnauthorized) {
+			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
+			return nil
+		}
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return err
+	}
+
+	// the owner or manager may be changed since sign in
+	accInfo, err := h.DbDao.GetAccountInfoByAccountId(claimsAccountId(claims))
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query parent account")
+		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	}
+	if accInfo.Id == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeNoAccountPermissions, "no account permissions")
+		return nil
+	}
+	if ok, err := h.hasAccountPermission(accInfo, claims.Address, tables.AccountRoleList); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query account role")
+		return err
+	} else if !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeNoAccountPermissions, "no account permissions")
+		return nil
+	}
+
+	if err := h.RC.RevokeJwt(claims.ID, time.Until(claims.ExpiresAt.Time)); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("RevokeJwt err: %s", err.Error())
+	}
+	access, refresh, accessStr, refreshStr, err := newJwtTokens(*claims)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "internal error")
+		return err
+	}
+	setJwtCookies(ctx, access, refresh, accessStr, refreshStr)
+
+	apiResp.ApiRespOK(RespSignInRefresh{
+		ExpiredAt: access.ExpiresAt.UnixMilli(),
+	})
+	return nil
+}
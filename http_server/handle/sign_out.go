@@ -0,0 +1,75 @@
+package handle
+
+import (
+	"errors"
+	"fmt"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ReqSignOut struct {
+	All bool `json:"all"` // revoke all sessions of the address
+}
+
+func (h *HttpHandle) SignOut(ctx *gin.Context) {
+	var (
+		funcName               = "SignOut"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqSignOut
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+
+	if err = ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ctx.ShouldBindJSON err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+
+	if err = h.doSignOut(ctx, &req, &apiResp); err != nil {
+		log.Error("doSignOut err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doSignOut(ctx *gin.Context, req *ReqSignOut, apiResp *api_code.ApiResp) error {
+	var claims *Claims
+	for _, v := range []struct {
+		cookie    string
+		tokenType string
+	}{{jwtCookieAccess, JwtTokenTypeAccess}, {jwtCookieRefresh, JwtTokenTypeRefresh}} {
+		tokenVal, err := ctx.Cookie(v.cookie)
+		if err != nil {
+			continue
+		}
+		if claims, err = h.parseJwtToken(tokenVal, v.tokenType); err == nil {
+			break
+		} else if !errors.Is(err, errJwtUnauthorized) {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+			return err
+		}
+	}
+	clearJwtCookies(ctx)
+	if claims == nil {
+		apiResp.ApiRespOK(nil)
+		return nil
+	}
+
+	if err := h.RC.RevokeJwt(claims.SessionId, jwtRefreshExpiration); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("RevokeJwt err: %s", err.Error())
+	}
+	if req.All {
+		if err := h.RC.SetJwtRevokedBefore(strings.ToLower(claims.Address), time.Now().UnixMilli(), jwtRefreshExpiration); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+			return fmt.Errorf("SetJwtRevokedBefore err: %s", err.Error())
+		}
+	}
+	apiResp.ApiRespOK(nil)
+	return nil
+}
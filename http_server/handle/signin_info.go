@@ -1,15 +1,13 @@
 package handle
 
 import (
-	"das_sub_account/config"
+	"das_sub_account/tables"
 	"errors"
 	"github.com/dotbitHQ/das-lib/common"
 	"github.com/dotbitHQ/das-lib/core"
 	api_code "github.com/dotbitHQ/das-lib/http_api"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	"net/http"
-	"strings"
 )
 
 type ReqSignInInfo struct {
@@ -40,7 +38,7 @@ func (h *HttpHandle) SignInInfo(ctx *gin.Context) {
 }
 
 func (h *HttpHandle) doSignInInfo(ctx *gin.Context, req *ReqSignInInfo, apiResp *api_code.ApiResp) error {
-	tokenVal, err := ctx.Cookie("token")
+	tokenVal, err := ctx.Cookie(jwtCookieAccess)
 	if err != nil {
 		if errors.Is(err, http.ErrNoCookie) {
 			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
@@ -50,21 +48,14 @@ func (h *HttpHandle) doSignInInfo(ctx *gin.Context, req *ReqSignInInfo, apiResp
 		return nil
 	}
 
-	claims := &Claims{}
-	tkn, err := jwt.ParseWithClaims(tokenVal, claims, func(token *jwt.Token) (any, error) {
-		return []byte(config.Cfg.Das.JwtKey), nil
-	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Name}))
+	claims, err := h.parseJwtToken(tokenVal, JwtTokenTypeAccess)
 	if err != nil {
-		if errors.Is(err, jwt.ErrSignatureInvalid) {
+		if errors.Is(err, errJwtUnauthorized) {
 			apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
 			return nil
 		}
-		apiResp.ApiRespErr(api_code.ApiCodeError500, err.Error())
-		return nil
-	}
-	if !tkn.Valid {
-		apiResp.ApiRespErr(api_code.ApiCodeUnauthorized, "unauthorized")
-		return nil
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return err
 	}
 
 	addrHex, err := req.FormatChainTypeAddress(h.DasCore.NetType(), false)
@@ -88,7 +79,10 @@ func (h *HttpHandle) doSignInInfo(ctx *gin.Context, req *ReqSignInInfo, apiResp
 		apiResp.ApiRespErr(api_code.ApiCodeParentAccountExpired, "account expired")
 		return nil
 	}
-	if !strings.EqualFold(address, accInfo.Owner) && !strings.EqualFold(address, accInfo.Manager) {
+	if ok, err := h.hasAccountPermission(accInfo, address, tables.AccountRoleList); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query account role")
+		return err
+	} else if !ok {
 		apiResp.ApiRespErr(api_code.ApiCodePermissionDenied, "permission denied")
 		return nil
 	}
@@ -0,0 +1,240 @@
+package handle
+
+import (
+	"das_sub_account/cache"
+	"das_sub_account/tables"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	statusStreamHeartbeat = time.Second * 15
+	statusStreamTimeout   = time.Minute * 30
+)
+
+type ReqStatusStream struct {
+	TaskId     string `json:"task_id" form:"task_id"`
+	OrderId    string `json:"order_id" form:"order_id"`
+	SubAccount string `json:"sub_account" form:"sub_account"`
+	Address    string `json:"address" form:"address"`
+}
+
+func (r *ReqStatusStream) match(ev *cache.StatusEvent) bool {
+	if r.TaskId != "" && r.TaskId != ev.TaskId {
+		return false
+	}
+	if r.OrderId != "" && !statusStreamContains(ev.OrderIds, r.OrderId) {
+		return false
+	}
+	if r.SubAccount != "" && !statusStreamContains(ev.SubAccounts, r.SubAccount) {
+		return false
+	}
+	if r.Address != "" && !statusStreamContains(ev.Addresses, r.Address) {
+		return false
+	}
+	return true
+}
+
+func statusStreamContains(list []string, value string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// statusHub holds one redis subscription per process and fans the events out to the open streams
+type statusHub struct {
+	lock sync.RWMutex
+	subs map[chan cache.StatusEvent]*ReqStatusStream
+}
+
+var (
+	statusHubOnce sync.Once
+	statusHubIns  *statusHub
+)
+
+func (h *HttpHandle) getStatusHub() *statusHub {
+	statusHubOnce.Do(func() {
+		statusHubIns = &statusHub{subs: make(map[chan cache.StatusEvent]*ReqStatusStream)}
+		go statusHubIns.run(h)
+	})
+	return statusHubIns
+}
+
+func (s *statusHub) run(h *HttpHandle) {
+	for {
+		select {
+		case <-h.Ctx.Done():
+			return
+		default:
+		}
+		pubSub, err := h.RC.SubscribeStatusEvent()
+		if err != nil {
+			log.Error("SubscribeStatusEvent err:", err.Error())
+			time.Sleep(time.Second * 5)
+			continue
+		}
+		ch := pubSub.Channel()
+	loop:
+		for {
+			select {
+			case <-h.Ctx.Done():
+				_ = pubSub.Close()
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					break loop
+				}
+				var ev cache.StatusEvent
+				if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil {
+					log.Error("status event json.Unmarshal err:", err.Error())
+					continue
+				}
+				s.publish(ev)
+			}
+		}
+		_ = pubSub.Close()
+	}
+}
+
+func (s *statusHub) publish(ev cache.StatusEvent) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for ch, req := range s.subs {
+		if !req.match(&ev) {
+			continue
+		}
+		// slow clients drop events instead of blocking the hub
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+func (s *statusHub) subscribe(req *ReqStatusStream) chan cache.StatusEvent {
+	ch := make(chan cache.StatusEvent, 16)
+	s.lock.Lock()
+	s.subs[ch] = req
+	s.lock.Unlock()
+	return ch
+}
+
+func (s *statusHub) unsubscribe(ch chan cache.StatusEvent) {
+	s.lock.Lock()
+	delete(s.subs, ch)
+	s.lock.Unlock()
+}
+
+func (h *HttpHandle) StatusStream(ctx *gin.Context) {
+	var (
+		funcName               = "StatusStream"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqStatusStream
+		apiResp                api_code.ApiResp
+	)
+
+	if err := ctx.ShouldBindQuery(&req); err != nil {
+		log.Error("ShouldBindQuery err: ", err.Error(), funcName, clientIp, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if req.TaskId == "" && req.OrderId == "" && req.SubAccount == "" && req.Address == "" {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "task_id, order_id, sub_account or address is required")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	req.SubAccount = strings.ToLower(req.SubAccount)
+
+	hub := h.getStatusHub()
+	ch := hub.subscribe(&req)
+	defer hub.unsubscribe(ch)
+
+	ctx.Header("Cache-Control", "no-cache")
+	ctx.Header("Connection", "keep-alive")
+	ctx.Header("X-Accel-Buffering", "no")
+
+	// the current state first, so the client does not miss a change that happened before it connected
+	if ev, err := h.getStatusStreamSnapshot(&req); err != nil {
+		log.Error("getStatusStreamSnapshot err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	} else if ev != nil {
+		ctx.SSEvent("status", ev)
+		ctx.Writer.Flush()
+	}
+
+	heartbeat := time.NewTicker(statusStreamHeartbeat)
+	defer heartbeat.Stop()
+	timeout := time.NewTimer(statusStreamTimeout)
+	defer timeout.Stop()
+	ctx.Stream(func(w io.Writer) bool {
+		select {
+		case <-ctx.Request.Context().Done():
+			return false
+		case <-h.Ctx.Done():
+			return false
+		case <-timeout.C:
+			return false
+		case <-heartbeat.C:
+			_, _ = fmt.Fprint(w, ": ping\n\n")
+			return true
+		case ev := <-ch:
+			ctx.SSEvent("status", ev)
+			return true
+		}
+	})
+}
+
+func (h *HttpHandle) getStatusStreamSnapshot(req *ReqStatusStream) (*cache.StatusEvent, error) {
+	taskId := req.TaskId
+	if taskId == "" && req.SubAccount != "" {
+		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.SubAccount))
+		record, err := h.DbDao.GetLatestMintRecord(accountId, common.DasActionUpdateSubAccount, common.SubActionCreate)
+		if err != nil {
+			return nil, fmt.Errorf("GetLatestMintRecord err: %s", err.Error())
+		}
+		taskId = record.TaskId
+	}
+	if taskId == "" {
+		return nil, nil
+	}
+	task, err := h.DbDao.GetTaskByTaskId(taskId)
+	if err != nil {
+		return nil, fmt.Errorf("GetTaskByTaskId err: %s", err.Error())
+	} else if task.Id == 0 {
+		return nil, nil
+	}
+	records, err := h.DbDao.GetSmtRecordListByTaskId(taskId)
+	if err != nil {
+		return nil, fmt.Errorf("GetSmtRecordListByTaskId err: %s", err.Error())
+	}
+
+	status := cache.StatusEventPending
+	switch task.TxStatus {
+	case tables.TxStatusCommitted:
+		status = cache.StatusEventCommitted
+	case tables.TxStatusRejected:
+		status = cache.StatusEventRejected
+	}
+	if task.SmtStatus == tables.SmtStatusRollbackComplete {
+		status = cache.StatusEventRolledBack
+	}
+	ev := cache.NewStatusEvent(task, records, status, "")
+	if !req.match(&ev) {
+		return nil, nil
+	}
+	return &ev, nil
+}
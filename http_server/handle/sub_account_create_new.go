@@ -69,7 +69,6 @@ func (h *HttpHandle) SubAccountCreateNew(ctx *gin.Context) {
 }
 
 func (h *HttpHandle) doSubAccountCreateNew(ctx context.Context, req *ReqSubAccountCreate, apiResp *api_code.ApiResp) error {
-	var resp RespSubAccountCreate
 	req.Account = strings.ToLower(req.Account)
 	for i, v := range req.SubAccountList {
 		req.SubAccountList[i].Account = strings.ToLower(v.Account)
@@ -102,6 +101,12 @@ func (h *HttpHandle) doSubAccountCreateNew(ctx context.Context, req *ReqSubAccou
 		return nil
 	}
 
+	return h.doSubAccountCreateSignInfo(ctx, req, acc, "", nil, apiResp)
+}
+
+func (h *HttpHandle) doSubAccountCreateSignInfo(ctx context.Context, req *ReqSubAccountCreate, acc *tables.TableAccountInfo, bulkMintJobId string, bulkMintItemIds []uint64, apiResp *api_code.ApiResp) error {
+	var resp RespSubAccountCreate
+
 	// check custom-script
 	subAccountLiveCell, err := h.DasCore.GetSubAccountCell(acc.AccountId)
 	if err != nil {
@@ -122,8 +127,9 @@ func (h *HttpHandle) doSubAccountCreateNew(ctx context.Context, req *ReqSubAccou
 
 	// do distribution
 	parentAccountId := acc.AccountId
-	if _, ok := config.Cfg.SuspendMap[parentAccountId]; ok {
-		apiResp.ApiRespErr(api_code.ApiCodeSuspendOperation, "suspend operation")
+	if err := h.checkSuspension(parentAccountId, tables.SuspensionScopeMint, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
 		return nil
 	}
 
@@ -177,6 +183,8 @@ func (h *HttpHandle) doSubAccountCreateNew(ctx context.Context, req *ReqSubAccou
 		OldSignMsg:      "",
 		MinSignInfo:     minSignInfo,
 		ListSmtRecord:   listSmtRecord,
+		BulkMintJobId:   bulkMintJobId,
+		BulkMintItemIds: bulkMintItemIds,
 	}
 	signData := dataCache.GetCreateSignData(acc.ManagerAlgorithmId, apiResp)
 	if apiResp.ErrNo != api_code.ApiCodeSuccess {
@@ -170,8 +170,9 @@ func (h *HttpHandle) doSubAccountEditNew(ctx context.Context, req *ReqSubAccount
 	dataCache.ParentAccountId = subAcc.ParentAccountId
 	log.Info("doSubAccountEditNew:", toolib.JsonString(&dataCache))
 
-	if _, ok := config.Cfg.SuspendMap[subAcc.ParentAccountId]; ok {
-		apiResp.ApiRespErr(api_code.ApiCodeSuspendOperation, "suspend operation")
+	if err := h.checkSuspension(subAcc.ParentAccountId, tables.SuspensionScopeEdit, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
 		return nil
 	}
 	// ExpiredAt
@@ -240,6 +241,9 @@ type UpdateSubAccountCache struct {
 	SignData      txbuilder.SignData          `json:"sign_data"`
 	MinSignInfo   *tables.TableMintSignInfo   `json:"min_sign_info,omitempty"`
 	ListSmtRecord []tables.TableSmtRecordInfo `json:"list_smt_record"`
+
+	BulkMintJobId   string   `json:"bulk_mint_job_id,omitempty"`
+	BulkMintItemIds []uint64 `json:"bulk_mint_item_ids,omitempty"`
 }
 
 func (u *UpdateSubAccountCache) CacheKey() string {
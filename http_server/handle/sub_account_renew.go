@@ -83,6 +83,12 @@ func (h *HttpHandle) doSubAccountRenew(ctx context.Context, req *ReqSubAccountRe
 		return nil
 	}
 
+	if err := h.checkSuspension(acc.AccountId, tables.SuspensionScopeRenew, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
 	// check list
 	isOk, respCheck, err := h.doSubAccountRenewCheckList(req, apiResp)
 	if err != nil {
@@ -0,0 +1,217 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// checkSuspension the error message explains the suspension to the users
+func (h *HttpHandle) checkSuspension(parentAccountId string, scope tables.SuspensionScope, apiResp *api_code.ApiResp) error {
+	suspension, err := h.DbDao.GetActiveSuspension(parentAccountId, scope)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query suspension")
+		return fmt.Errorf("GetActiveSuspension err: %s", err.Error())
+	} else if suspension.Id > 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeSuspendOperation, suspension.Message())
+	}
+	return nil
+}
+
+type ReqSuspensionCreate struct {
+	Account  string                 `json:"account" binding:"required"`
+	Scope    tables.SuspensionScope `json:"scope" binding:"required"`
+	Reason   string                 `json:"reason" binding:"required"`
+	StartAt  int64                  `json:"start_at"`
+	EndAt    int64                  `json:"end_at"`
+	Operator string                 `json:"-"`
+}
+
+type RespSuspensionCreate struct {
+	Id uint64 `json:"id"`
+}
+
+func (h *HttpHandle) SuspensionCreate(ctx *gin.Context) {
+	var (
+		funcName               = "SuspensionCreate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqSuspensionCreate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	req.Operator = getCtxInternalCaller(ctx)
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), req.Operator, ctx.Request.Context())
+
+	if err = h.doSuspensionCreate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doSuspensionCreate err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doSuspensionCreate(ctx context.Context, req *ReqSuspensionCreate, apiResp *api_code.ApiResp) error {
+	var resp RespSuspensionCreate
+
+	req.Account = strings.ToLower(req.Account)
+	if !tables.IsSuspensionScope(req.Scope) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "scope invalid")
+		return nil
+	}
+	if req.StartAt <= 0 {
+		req.StartAt = time.Now().UnixMilli()
+	}
+	if req.EndAt > 0 && (req.EndAt <= req.StartAt || req.EndAt <= time.Now().UnixMilli()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "end_at invalid")
+		return nil
+	}
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	acc, err := h.DbDao.GetAccountInfoByAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query account")
+		return fmt.Errorf("GetAccountInfoByAccountId err: %s", err.Error())
+	} else if acc.Id == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeAccountNotExist, "account not exist")
+		return nil
+	}
+
+	suspension := tables.TableSuspension{
+		ParentAccountId: parentAccountId,
+		Account:         req.Account,
+		Scope:           req.Scope,
+		Reason:          req.Reason,
+		StartAt:         req.StartAt,
+		EndAt:           req.EndAt,
+		Operator:        req.Operator,
+	}
+	if err := h.DbDao.CreateSuspension(&suspension); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to create suspension")
+		return fmt.Errorf("CreateSuspension err: %s", err.Error())
+	}
+	h.addAuditLog(ctx, req.Account, req.Operator, tables.AuditRoleInternal, newAuditInfo(tables.AuditActionSuspensionUpdate, nil, suspension), "")
+	resp.Id = suspension.Id
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+type ReqSuspensionLift struct {
+	Id       uint64 `json:"id" binding:"required"`
+	Operator string `json:"-"`
+}
+
+func (h *HttpHandle) SuspensionLift(ctx *gin.Context) {
+	var (
+		funcName               = "SuspensionLift"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqSuspensionLift
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	req.Operator = getCtxInternalCaller(ctx)
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), req.Operator, ctx.Request.Context())
+
+	if err = h.doSuspensionLift(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doSuspensionLift err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doSuspensionLift(ctx context.Context, req *ReqSuspensionLift, apiResp *api_code.ApiResp) error {
+	suspension, err := h.DbDao.GetSuspension(req.Id)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query suspension")
+		return fmt.Errorf("GetSuspension err: %s", err.Error())
+	} else if suspension.Id == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "suspension not exist")
+		return nil
+	} else if suspension.Status == tables.SuspensionStatusLifted {
+		apiResp.ApiRespOK(nil)
+		return nil
+	}
+	if err := h.DbDao.LiftSuspension(req.Id, req.Operator); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to lift suspension")
+		return fmt.Errorf("LiftSuspension err: %s", err.Error())
+	}
+	before := suspension
+	suspension.Status, suspension.LiftedBy = tables.SuspensionStatusLifted, req.Operator
+	h.addAuditLog(ctx, suspension.Account, req.Operator, tables.AuditRoleInternal, newAuditInfo(tables.AuditActionSuspensionUpdate, before, suspension), "")
+
+	apiResp.ApiRespOK(nil)
+	return nil
+}
+
+type ReqSuspensionList struct {
+	Pagination
+	Account    string `json:"account"`
+	OnlyActive bool   `json:"only_active"`
+}
+
+type RespSuspensionList struct {
+	Total int64                    `json:"total"`
+	List  []tables.TableSuspension `json:"list"`
+}
+
+func (h *HttpHandle) SuspensionList(ctx *gin.Context) {
+	var (
+		funcName               = "SuspensionList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqSuspensionList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doSuspensionList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doSuspensionList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doSuspensionList(ctx context.Context, req *ReqSuspensionList, apiResp *api_code.ApiResp) error {
+	var resp RespSuspensionList
+
+	parentAccountId := ""
+	if req.Account != "" {
+		parentAccountId = common.Bytes2Hex(common.GetAccountIdByAccount(strings.ToLower(req.Account)))
+	}
+	list, total, err := h.DbDao.GetSuspensionList(parentAccountId, req.OnlyActive, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "Failed to query suspension")
+		return fmt.Errorf("GetSuspensionList err: %s", err.Error())
+	}
+	resp.Total = total
+	resp.List = list
+	if resp.List == nil {
+		resp.List = make([]tables.TableSuspension, 0)
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -25,7 +25,12 @@ type ReqTransactionSend struct {
 }
 
 type RespTransactionSend struct {
-	HashList []string `json:"hash_list"`
+	HashList      []string `json:"hash_list"`
+	KeyId         string   `json:"key_id,omitempty"`
+	ApiKey        string   `json:"api_key,omitempty"`
+	ScheduleId    uint64   `json:"schedule_id,omitempty"`
+	WebhookId     string   `json:"webhook_id,omitempty"`
+	WebhookSecret string   `json:"webhook_secret,omitempty"`
 }
 
 func (h *HttpHandle) TransactionSendNew(ctx *gin.Context) {
@@ -93,11 +98,59 @@ func (h *HttpHandle) doTransactionSendNew(ctx context.Context, req *ReqTransacti
 			return nil
 		}
 	case consts.ActionCurrencyUpdate, ActionMintConfigUpdate:
-		if err := h.doActionAutoMint(req, apiResp); err != nil {
+		if err := h.doActionAutoMint(ctx, req, apiResp); err != nil {
 			return fmt.Errorf("doActionNormal err: %s", err.Error())
 		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
 			return nil
 		}
+	case consts.ActionWebhookUpdate:
+		if err := h.doActionWebhookUpdate(ctx, req, apiResp, &resp); err != nil {
+			return fmt.Errorf("doActionWebhookUpdate err: %s", err.Error())
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+	case consts.ActionApiKeyUpdate:
+		if err := h.doActionApiKeyUpdate(ctx, req, apiResp, &resp); err != nil {
+			return fmt.Errorf("doActionApiKeyUpdate err: %s", err.Error())
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+	case consts.ActionRoleUpdate:
+		if err := h.doActionRoleUpdate(ctx, req, apiResp); err != nil {
+			return fmt.Errorf("doActionRoleUpdate err: %s", err.Error())
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+	case consts.ActionDiscountUpdate:
+		if err := h.doActionDiscountUpdate(ctx, req, apiResp); err != nil {
+			return fmt.Errorf("doActionDiscountUpdate err: %s", err.Error())
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+	case consts.ActionPresaleUpdate:
+		if err := h.doActionPresaleUpdate(ctx, req, apiResp); err != nil {
+			return fmt.Errorf("doActionPresaleUpdate err: %s", err.Error())
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+	case consts.ActionMintLimitUpdate:
+		if err := h.doActionMintLimitUpdate(ctx, req, apiResp); err != nil {
+			return fmt.Errorf("doActionMintLimitUpdate err: %s", err.Error())
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+	case consts.ActionReferralUpdate:
+		if err := h.doActionReferralUpdate(ctx, req, apiResp); err != nil {
+			return fmt.Errorf("doActionReferralUpdate err: %s", err.Error())
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
+	case consts.ActionRuleSchedule:
+		if err := h.doActionPriceRuleSchedule(ctx, req, apiResp, &resp); err != nil {
+			return fmt.Errorf("doActionPriceRuleSchedule err: %s", err.Error())
+		} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+			return nil
+		}
 	default:
 		apiResp.ApiRespErr(api_code.ApiCodeNotExistConfirmAction, fmt.Sprintf("not exist action[%s]", req.Action))
 		return nil
@@ -106,7 +159,7 @@ func (h *HttpHandle) doTransactionSendNew(ctx context.Context, req *ReqTransacti
 	return nil
 }
 
-func (h *HttpHandle) doActionAutoMint(req *ReqTransactionSend, apiResp *api_code.ApiResp) error {
+func (h *HttpHandle) doActionAutoMint(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp) error {
 	switch req.Action {
 	case consts.ActionCurrencyUpdate:
 		var data ReqCurrencyUpdate
@@ -151,22 +204,12 @@ func (h *HttpHandle) doActionAutoMint(req *ReqTransactionSend, apiResp *api_code
 			apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
 			return nil
 		}
-		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
-		paymentConfig, err := h.DbDao.GetUserPaymentConfig(accountId)
+		auditInfo, err := h.saveCurrencyConfig(&data, apiResp)
 		if err != nil {
-			apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
 			return err
 		}
-		paymentConfig.CfgMap[data.TokenId] = tables.PaymentConfigElement{
-			Enable: data.Enable,
-		}
-		if err := h.DbDao.CreateUserConfigWithPaymentConfig(tables.UserConfig{
-			Account:   data.Account,
-			AccountId: accountId,
-		}, paymentConfig); err != nil {
-			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update payment config")
-			return fmt.Errorf("CreateUserConfigWithMintConfig err: %s", err.Error())
-		}
+		h.addAuditLogByAddress(ctx, data.Account, address, auditInfo, "")
+		return nil
 	case ActionMintConfigUpdate:
 		var data ReqMintConfigUpdate
 		if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
@@ -210,27 +253,65 @@ func (h *HttpHandle) doActionAutoMint(req *ReqTransactionSend, apiResp *api_code
 			apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
 			return nil
 		}
-
-		accountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
-		if err := h.DbDao.CreateUserConfigWithMintConfig(tables.UserConfig{
-			Account:   data.Account,
-			AccountId: accountId,
-		}, tables.MintConfig{
-			Title:           data.Title,
-			Desc:            data.Desc,
-			Benefits:        data.Benefits,
-			Links:           data.Links,
-			BackgroundColor: data.BackgroundColor,
-			MintSuccessPage: data.MintSuccessPage,
-		}); err != nil {
-			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update mint config")
-			return fmt.Errorf("CreateUserConfigWithMintConfig err: %s", err.Error())
+		auditInfo, err := h.saveMintConfig(&data, apiResp)
+		if err != nil {
+			return err
 		}
+		h.addAuditLogByAddress(ctx, data.Account, address, auditInfo, "")
+		return nil
 	default:
 	}
 	return nil
 }
 
+func (h *HttpHandle) saveCurrencyConfig(data *ReqCurrencyUpdate, apiResp *api_code.ApiResp) (*AuditInfo, error) {
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
+	paymentConfig, err := h.DbDao.GetUserPaymentConfig(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return nil, err
+	}
+	before := paymentConfig.CfgMap[data.TokenId]
+	paymentConfig.CfgMap[data.TokenId] = tables.PaymentConfigElement{
+		Enable: data.Enable,
+	}
+	if err := h.DbDao.CreateUserConfigWithPaymentConfig(tables.UserConfig{
+		Account:   data.Account,
+		AccountId: accountId,
+	}, paymentConfig); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update payment config")
+		return nil, fmt.Errorf("CreateUserConfigWithMintConfig err: %s", err.Error())
+	}
+	return newAuditInfo(tables.AuditActionCurrencyUpdate,
+		map[string]interface{}{"token_id": data.TokenId, "enable": before.Enable},
+		map[string]interface{}{"token_id": data.TokenId, "enable": data.Enable}), nil
+}
+
+func (h *HttpHandle) saveMintConfig(data *ReqMintConfigUpdate, apiResp *api_code.ApiResp) (*AuditInfo, error) {
+	accountId := common.Bytes2Hex(common.GetAccountIdByAccount(data.Account))
+	before, err := h.DbDao.GetMintConfig(accountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "db error")
+		return nil, fmt.Errorf("GetMintConfig err: %s", err.Error())
+	}
+	after := tables.MintConfig{
+		Title:           data.Title,
+		Desc:            data.Desc,
+		Benefits:        data.Benefits,
+		Links:           data.Links,
+		BackgroundColor: data.BackgroundColor,
+		MintSuccessPage: data.MintSuccessPage,
+	}
+	if err := h.DbDao.CreateUserConfigWithMintConfig(tables.UserConfig{
+		Account:   data.Account,
+		AccountId: accountId,
+	}, after); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update mint config")
+		return nil, fmt.Errorf("CreateUserConfigWithMintConfig err: %s", err.Error())
+	}
+	return newAuditInfo(tables.AuditActionMintConfigUpdate, before, after), nil
+}
+
 func (h *HttpHandle) doActionNormal(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp, resp *RespTransactionSend) error {
 	var sic SignInfoCache
 	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
@@ -282,6 +363,7 @@ func (h *HttpHandle) doActionNormal(ctx context.Context, req *ReqTransactionSend
 		if err := h.DbDao.CreateTask(&taskInfo); err != nil {
 			log.Error(ctx, "CreateTask err: ", err.Error())
 		}
+		h.addAuditLogByAddress(ctx, sic.Account, sic.Address, sic.Audit, hash.Hex())
 	}
 	return nil
 }
@@ -321,6 +403,10 @@ func (h *HttpHandle) doApproval(ctx context.Context, req *ReqTransactionSend, ap
 	}
 	h.DasCache.AddCellInputByAction("", sic.BuilderTx.Transaction.Inputs)
 	resp.HashList = append(resp.HashList, hash.Hex())
+	h.addAuditLogByAddress(ctx, sic.Account, sic.Address, newAuditInfo(tables.AuditActionApproval, nil, map[string]string{
+		"action":  sic.Action,
+		"account": sic.Account,
+	}), hash.Hex())
 
 	if sic.Address != "" {
 		// pending tx
@@ -509,6 +595,13 @@ func (h *HttpHandle) doSubActionCreate(dataCache UpdateSubAccountCache, req *Req
 		dataCache.ListSmtRecord[i].SignAddress = req.SignAddress        //sign addr
 		dataCache.ListSmtRecord[i].Signature = signature
 	}
+	if dataCache.BulkMintJobId != "" {
+		if err := h.DbDao.CreateMinSignInfoWithBulkMintJob(*dataCache.MinSignInfo, dataCache.ListSmtRecord, dataCache.BulkMintJobId, dataCache.BulkMintItemIds); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "fail to create mint sign info")
+			return fmt.Errorf("CreateMinSignInfoWithBulkMintJob err:%s", err.Error())
+		}
+		return nil
+	}
 	if err := h.DbDao.CreateMinSignInfo(*dataCache.MinSignInfo, dataCache.ListSmtRecord); err != nil {
 		apiResp.ApiRespErr(api_code.ApiCodeDbError, "fail to create mint sign info")
 		return fmt.Errorf("CreateMinSignInfo err:%s", err.Error())
@@ -634,7 +727,8 @@ func (h *HttpHandle) doEditSignMsg(ctx context.Context, req *ReqTransactionSend,
 			return fmt.Errorf("json.Unmarshal err: %s", err.Error())
 		}
 		txAddr = dataCache.Address
-	case consts.ActionCurrencyUpdate, ActionMintConfigUpdate:
+	case consts.ActionCurrencyUpdate, ActionMintConfigUpdate, consts.ActionWebhookUpdate, consts.ActionApiKeyUpdate, consts.ActionRoleUpdate,
+		consts.ActionRuleSchedule, consts.ActionDiscountUpdate, consts.ActionPresaleUpdate, consts.ActionMintLimitUpdate, consts.ActionReferralUpdate:
 		chainTypeAddress := &core.ChainTypeAddress{}
 		txStr, err := h.RC.GetSignTxCache(req.SignKey)
 		if err != nil {
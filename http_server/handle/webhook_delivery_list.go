@@ -0,0 +1,67 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+type ReqWebhookDeliveryList struct {
+	core.ChainTypeAddress
+	Pagination
+	Account   string `json:"account" binding:"required"`
+	WebhookId string `json:"webhook_id"`
+}
+
+type RespWebhookDeliveryList struct {
+	Total int64                         `json:"total"`
+	List  []tables.TableWebhookDelivery `json:"list"`
+}
+
+func (h *HttpHandle) WebhookDeliveryList(ctx *gin.Context) {
+	var (
+		funcName               = "WebhookDeliveryList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqWebhookDeliveryList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, req.WebhookId, ctx.Request.Context())
+
+	if err = h.doWebhookDeliveryList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doWebhookDeliveryList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doWebhookDeliveryList(ctx context.Context, req *ReqWebhookDeliveryList, apiResp *api_code.ApiResp) error {
+	var resp RespWebhookDeliveryList
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	list, total, err := h.DbDao.GetWebhookDeliveryList(parentAccountId, req.WebhookId, req.GetLimit(), req.GetOffset())
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query webhook delivery")
+		return fmt.Errorf("GetWebhookDeliveryList err: %s", err.Error())
+	}
+	resp.Total = total
+	resp.List = list
+	if resp.List == nil {
+		resp.List = make([]tables.TableWebhookDelivery, 0)
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
@@ -0,0 +1,78 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+)
+
+type ReqWebhookList struct {
+	core.ChainTypeAddress
+	Account string `json:"account" binding:"required"`
+}
+
+type RespWebhookList struct {
+	List []WebhookInfo `json:"list"`
+}
+
+type WebhookInfo struct {
+	WebhookId string               `json:"webhook_id"`
+	Url       string               `json:"url"`
+	Secret    string               `json:"secret"` // masked, the full secret is only returned by Send Transaction when it is created
+	Events    []string             `json:"events"`
+	Status    tables.WebhookStatus `json:"status"`
+	CreatedAt int64                `json:"created_at"`
+}
+
+func (h *HttpHandle) WebhookList(ctx *gin.Context) {
+	var (
+		funcName               = "WebhookList"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqWebhookList
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, ctx.Request.Context())
+
+	if err = h.doWebhookList(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doWebhookList err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doWebhookList(ctx context.Context, req *ReqWebhookList, apiResp *api_code.ApiResp) error {
+	resp := RespWebhookList{List: make([]WebhookInfo, 0)}
+
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	list, err := h.DbDao.GetWebhookListByParentAccountId(parentAccountId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query webhook")
+		return fmt.Errorf("GetWebhookListByParentAccountId err: %s", err.Error())
+	}
+	for _, v := range list {
+		resp.List = append(resp.List, WebhookInfo{
+			WebhookId: v.WebhookId,
+			Url:       v.Url,
+			Secret:    maskWebhookSecret(v.Secret),
+			Events:    v.GetEvents(),
+			Status:    v.Status,
+			CreatedAt: v.CreatedAt.UnixMilli(),
+		})
+	}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
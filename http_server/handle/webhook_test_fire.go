@@ -0,0 +1,100 @@
+package handle
+
+import (
+	"context"
+	"das_sub_account/notify"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"net/http"
+	"time"
+)
+
+type ReqWebhookTestFire struct {
+	core.ChainTypeAddress
+	Account   string `json:"account" binding:"required"`
+	WebhookId string `json:"webhook_id" binding:"required"`
+}
+
+type RespWebhookTestFire struct {
+	DeliveryId   string                       `json:"delivery_id"`
+	Status       tables.WebhookDeliveryStatus `json:"status"`
+	ResponseCode int                          `json:"response_code"`
+	ErrMsg       string                       `json:"err_msg"`
+}
+
+func (h *HttpHandle) WebhookTestFire(ctx *gin.Context) {
+	var (
+		funcName               = "WebhookTestFire"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqWebhookTestFire
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindBodyWith(&req, binding.JSON); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, req.Account, req.WebhookId, ctx.Request.Context())
+
+	if err = h.doWebhookTestFire(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doWebhookTestFire err:", err.Error(), funcName, clientIp, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+// doWebhookTestFire sends a ping event at once without retry, the result is kept in the delivery log
+func (h *HttpHandle) doWebhookTestFire(ctx context.Context, req *ReqWebhookTestFire, apiResp *api_code.ApiResp) error {
+	var resp RespWebhookTestFire
+
+	webhook, err := h.DbDao.GetWebhook(req.WebhookId)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query webhook")
+		return fmt.Errorf("GetWebhook err: %s", err.Error())
+	}
+	if webhook.Id == 0 || webhook.ParentAccountId != common.Bytes2Hex(common.GetAccountIdByAccount(req.Account)) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "webhook not exist")
+		return nil
+	}
+
+	if err := notify.CheckWebhookUrl(ctx, webhook.Url); err != nil {
+		log.Warn("CheckWebhookUrl err:", err.Error(), req.Account, webhook.Url)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "url must be a public address")
+		return nil
+	}
+
+	delivery, err := tables.NewWebhookDelivery(webhook, tables.WebhookEventPing, map[string]string{
+		"webhook_id": webhook.WebhookId,
+	})
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "failed to create webhook delivery")
+		return fmt.Errorf("NewWebhookDelivery err: %s", err.Error())
+	}
+	delivery.Status = tables.WebhookDeliveryStatusSuccess
+	delivery.ResponseCode, err = notify.SendWebhook(webhook.Url, webhook.Secret, string(delivery.Event), delivery.DeliveryId, delivery.Payload)
+	if err != nil {
+		delivery.Status = tables.WebhookDeliveryStatusFailed
+		delivery.ErrMsg = err.Error()
+		if len(delivery.ErrMsg) > 255 {
+			delivery.ErrMsg = delivery.ErrMsg[:255]
+		}
+	}
+	delivery.NextTime = time.Now().UnixMilli()
+	if err := h.DbDao.CreateWebhookDelivery(&delivery); err != nil {
+		log.Error("CreateWebhookDelivery err:", err.Error(), delivery.DeliveryId)
+	}
+
+	resp.DeliveryId = delivery.DeliveryId
+	resp.Status = delivery.Status
+	resp.ResponseCode = delivery.ResponseCode
+	resp.ErrMsg = delivery.ErrMsg
+	apiResp.ApiRespOK(resp)
+	return nil
+}
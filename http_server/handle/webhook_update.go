@@ -0,0 +1,338 @@
+package handle
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"das_sub_account/config"
+	"das_sub_account/consts"
+	"das_sub_account/internal"
+	"das_sub_account/notify"
+	"das_sub_account/tables"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	api_code "github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis"
+	"github.com/scorpiotzh/toolib"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	maxWebhookNum       = 5
+	webhookSecretPrefix = "whsec_"
+)
+
+type ReqWebhookUpdate struct {
+	core.ChainTypeAddress
+	Account     string               `json:"account" binding:"required"`
+	WebhookId   string               `json:"webhook_id"` // empty: create
+	Url         string               `json:"url"`
+	Events      []string             `json:"events"`
+	Status      tables.WebhookStatus `json:"status"`
+	ResetSecret bool                 `json:"reset_secret"`
+	Delete      bool                 `json:"delete"`
+	Timestamp   int64                `json:"timestamp" binding:"required"`
+}
+
+type RespWebhookUpdate struct {
+	SignInfoList
+}
+
+func (r *ReqWebhookUpdate) GetSignInfo() (signKey, signMsg, reqDataStr string) {
+	reqData, _ := json.Marshal(r)
+	reqDataStr = string(reqData)
+	signKey = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s_%d", reqDataStr, time.Now().UnixNano()))))
+	signMsg = common.DotBitPrefix + hex.EncodeToString(common.Blake2b(reqData))
+	return
+}
+
+func (h *HttpHandle) WebhookUpdate(ctx *gin.Context) {
+	var (
+		funcName               = "WebhookUpdate"
+		clientIp, remoteAddrIP = GetClientIp(ctx)
+		req                    ReqWebhookUpdate
+		apiResp                api_code.ApiResp
+		err                    error
+	)
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		log.Error("ShouldBindJSON err: ", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		ctx.JSON(http.StatusOK, apiResp)
+		return
+	}
+	log.Info("ApiReq:", funcName, clientIp, remoteAddrIP, toolib.JsonString(req), ctx.Request.Context())
+
+	if err = h.doWebhookUpdate(ctx.Request.Context(), &req, &apiResp); err != nil {
+		log.Error("doWebhookUpdate err:", err.Error(), funcName, clientIp, remoteAddrIP, ctx.Request.Context())
+	}
+	ctx.JSON(http.StatusOK, apiResp)
+}
+
+func (h *HttpHandle) doWebhookUpdate(ctx context.Context, req *ReqWebhookUpdate, apiResp *api_code.ApiResp) error {
+	var resp RespWebhookUpdate
+	resp.List = make([]SignInfo, 0)
+
+	if err := h.checkSystemUpgrade(apiResp); err != nil {
+		return fmt.Errorf("checkSystemUpgrade err: %s", err.Error())
+	}
+	if ok := internal.IsLatestBlockNumber(config.Cfg.Server.ParserUrl); !ok {
+		apiResp.ApiRespErr(api_code.ApiCodeSyncBlockNumber, "sync block number")
+		return fmt.Errorf("sync block number")
+	}
+
+	res, err := req.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return err
+	}
+	address := common.FormatAddressPayload(res.AddressPayload, res.DasAlgorithmId)
+
+	action := consts.ActionWebhookUpdate
+	if err := h.check(address, req.Account, action, apiResp); err != nil {
+		return err
+	}
+
+	if time.UnixMilli(req.Timestamp).Add(time.Minute * 10).Before(time.Now()) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params timestamp invalid")
+		return nil
+	}
+	if err := h.checkWebhookUpdate(ctx, req, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	//
+	signKey, signMsg, reqDataStr := req.GetSignInfo()
+
+	// cache
+	if err = h.RC.SetSignTxCache(signKey, reqDataStr); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		return fmt.Errorf("SetSignTxCache err: %s", err.Error())
+	}
+
+	//
+	signType := res.DasAlgorithmId
+	if signType == common.DasAlgorithmIdEth712 {
+		signType = common.DasAlgorithmIdEth
+	}
+	resp.Action = action
+	resp.SignKey = signKey
+	resp.List = append(resp.List, SignInfo{
+		SignList: []txbuilder.SignData{{
+			SignType: signType,
+			SignMsg:  signMsg,
+		}},
+	})
+	resp.SignList = []txbuilder.SignData{{
+		SignType: signType,
+		SignMsg:  signMsg,
+	}}
+
+	apiResp.ApiRespOK(resp)
+	return nil
+}
+
+func (h *HttpHandle) checkWebhookUpdate(ctx context.Context, req *ReqWebhookUpdate, apiResp *api_code.ApiResp) error {
+	parentAccountId := common.Bytes2Hex(common.GetAccountIdByAccount(req.Account))
+	if req.WebhookId != "" {
+		webhook, err := h.DbDao.GetWebhook(req.WebhookId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query webhook")
+			return fmt.Errorf("GetWebhook err: %s", err.Error())
+		}
+		if webhook.Id == 0 || webhook.ParentAccountId != parentAccountId {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "webhook not exist")
+			return nil
+		}
+	} else {
+		if req.Delete {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "webhook_id is empty")
+			return nil
+		}
+		count, err := h.DbDao.CountWebhook(parentAccountId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to count webhook")
+			return fmt.Errorf("CountWebhook err: %s", err.Error())
+		}
+		if count >= maxWebhookNum {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("more than max webhook num %d", maxWebhookNum))
+			return nil
+		}
+	}
+	if req.Delete {
+		return nil
+	}
+
+	u, err := url.Parse(req.Url)
+	if err != nil || u.Host == "" {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "url invalid")
+		return nil
+	}
+	if u.Scheme != "https" && (u.Scheme != "http" || config.Cfg.Server.Net == common.DasNetTypeMainNet) {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "url must be https")
+		return nil
+	}
+	if err := notify.CheckWebhookUrl(ctx, req.Url); err != nil {
+		log.Warn("CheckWebhookUrl err:", err.Error(), req.Account, req.Url)
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "url must be a public address")
+		return nil
+	}
+	if len(req.Events) == 0 {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "events is empty")
+		return nil
+	}
+	for _, v := range req.Events {
+		if !tables.IsWebhookEvent(v) {
+			apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, fmt.Sprintf("event [%s] not supported", v))
+			return nil
+		}
+	}
+	if req.Status != tables.WebhookStatusEnable && req.Status != tables.WebhookStatusDisable {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "status invalid")
+		return nil
+	}
+	return nil
+}
+
+func (h *HttpHandle) doActionWebhookUpdate(ctx context.Context, req *ReqTransactionSend, apiResp *api_code.ApiResp, resp *RespTransactionSend) error {
+	var data ReqWebhookUpdate
+	if txStr, err := h.RC.GetSignTxCache(req.SignKey); err != nil {
+		if err == redis.Nil {
+			apiResp.ApiRespErr(api_code.ApiCodeTxExpired, "sign key not exist(tx expired)")
+		} else {
+			apiResp.ApiRespErr(api_code.ApiCodeCacheError, "cache err")
+		}
+		return fmt.Errorf("GetSignTxCache err: %s", err.Error())
+	} else if err = json.Unmarshal([]byte(txStr), &data); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeError500, "json.Unmarshal err")
+		return fmt.Errorf("json.Unmarshal err: %s", err.Error())
+	}
+	res, err := data.ChainTypeAddress.FormatChainTypeAddress(h.DasCore.NetType(), false)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeParamsInvalid, "params invalid")
+		return fmt.Errorf("FormatChainTypeAddress err: %s", err.Error())
+	}
+	_, signMsg, _ := data.GetSignInfo()
+	address := ""
+	var signType common.DasAlgorithmId
+	var signature string
+	if len(req.List) != 0 {
+		signType = req.List[0].SignList[0].SignType
+		signature = req.List[0].SignList[0].SignMsg
+	} else {
+		signType = req.SignList[0].SignType
+		signature = req.SignList[0].SignMsg
+	}
+	if signType == common.DasAlgorithmIdWebauthn {
+		address = req.SignAddress
+	} else {
+		address = res.AddressHex
+	}
+	verifyRes, _, err := api_code.VerifySignature(signType, signMsg, signature, address)
+	if err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "VerifySignature err")
+		return fmt.Errorf("VerifySignature err: %s", err.Error())
+	}
+	if !verifyRes {
+		apiResp.ApiRespErr(api_code.ApiCodeSignError, "res sign error")
+		return nil
+	}
+
+	// the webhook may be changed since the sign info was returned
+	if err := h.checkWebhookUpdate(ctx, &data, apiResp); err != nil {
+		return err
+	} else if apiResp.ErrNo != api_code.ApiCodeSuccess {
+		return nil
+	}
+
+	var before interface{}
+	if data.WebhookId != "" {
+		old, err := h.DbDao.GetWebhook(data.WebhookId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query webhook")
+			return fmt.Errorf("GetWebhook err: %s", err.Error())
+		}
+		before = map[string]interface{}{"webhook_id": old.WebhookId, "url": old.Url, "events": old.Events, "status": old.Status}
+	}
+	defer func() {
+		if apiResp.ErrNo == api_code.ApiCodeSuccess {
+			h.addAuditLogByAddress(ctx, data.Account, address, newAuditInfo(tables.AuditActionWebhookUpdate, before, map[string]interface{}{
+				"webhook_id": data.WebhookId, "url": data.Url, "events": data.Events, "status": data.Status,
+				"delete": data.Delete, "reset_secret": data.ResetSecret,
+			}), "")
+		}
+	}()
+
+	if data.Delete {
+		if err := h.DbDao.DeleteWebhook(data.WebhookId); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to delete webhook")
+			return fmt.Errorf("DeleteWebhook err: %s", err.Error())
+		}
+		return nil
+	}
+
+	webhook := tables.TableWebhook{
+		WebhookId:       data.WebhookId,
+		ParentAccountId: common.Bytes2Hex(common.GetAccountIdByAccount(data.Account)),
+		Account:         strings.ToLower(data.Account),
+		Url:             data.Url,
+		Events:          strings.Join(data.Events, ","),
+		Status:          data.Status,
+	}
+	if webhook.WebhookId == "" || data.ResetSecret {
+		if webhook.Secret, err = newWebhookSecret(); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeError500, "failed to create webhook secret")
+			return fmt.Errorf("newWebhookSecret err: %s", err.Error())
+		}
+	} else {
+		old, err := h.DbDao.GetWebhook(data.WebhookId)
+		if err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to query webhook")
+			return fmt.Errorf("GetWebhook err: %s", err.Error())
+		}
+		webhook.Secret = old.Secret
+	}
+
+	if webhook.WebhookId == "" {
+		webhook.InitWebhookId()
+		if err := h.DbDao.CreateWebhook(&webhook); err != nil {
+			apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to create webhook")
+			return fmt.Errorf("CreateWebhook err: %s", err.Error())
+		}
+	} else if err := h.DbDao.UpdateWebhook(webhook); err != nil {
+		apiResp.ApiRespErr(api_code.ApiCodeDbError, "failed to update webhook")
+		return fmt.Errorf("UpdateWebhook err: %s", err.Error())
+	}
+	// the secret is only returned when it is created
+	resp.WebhookId = webhook.WebhookId
+	if data.WebhookId == "" || data.ResetSecret {
+		resp.WebhookSecret = webhook.Secret
+	}
+	return nil
+}
+
+// maskWebhookSecret keeps the prefix and the last 4 characters of the secret
+func maskWebhookSecret(secret string) string {
+	if len(secret) <= len(webhookSecretPrefix)+4 {
+		return webhookSecretPrefix + "****"
+	}
+	return webhookSecretPrefix + "****" + secret[len(secret)-4:]
+}
+
+func newWebhookSecret() (string, error) {
+	bys := make([]byte, 32)
+	if _, err := rand.Read(bys); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + hex.EncodeToString(bys), nil
+}
@@ -13,6 +13,8 @@ func (h *LbHttpServer) initRouter() {
 		toolib.AllowOriginList = append(toolib.AllowOriginList, config.Cfg.Origins...)
 	}
 	h.engine.Use(toolib.MiddlewareCors())
+	h.engine.GET("/healthz", h.H.Healthz)
+	h.engine.GET("/readyz", h.H.Readyz)
 	v1 := h.engine.Group("v1")
 	{
 		v1.POST("/version", h.H.LBProxy)
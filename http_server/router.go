@@ -4,6 +4,7 @@ import (
 	"das_sub_account/config"
 	"das_sub_account/http_server/api_code"
 	"das_sub_account/internal/static_files"
+	"das_sub_account/tables"
 	"encoding/json"
 	"github.com/dotbitHQ/das-lib/http_api"
 	sentrygin "github.com/getsentry/sentry-go/gin"
@@ -22,35 +23,42 @@ func (h *HttpServer) initRouter() {
 		toolib.AllowOriginList = append(toolib.AllowOriginList, config.Cfg.Origins...)
 	}
 	h.internalEngine.Use(toolib.MiddlewareCors())
+	h.internalEngine.Use(http_api.ReqIdMiddleware())
 	h.engine.Use(toolib.MiddlewareCors())
 	h.engine.Use(sentrygin.New(sentrygin.Options{
 		Repanic: true,
 	}))
 	h.engine.Use(http_api.ReqIdMiddleware())
+	h.engine.GET("/healthz", h.H.Healthz)
+	h.engine.GET("/readyz", h.H.Readyz)
 	v1 := h.engine.Group("v1")
 	{
 		v1.POST("/version", cacheHandleShort, h.H.Version)
 		v1.POST("/config/info", api_code.DoMonitorLog("config"), cacheHandleShort, h.H.ConfigInfo)
 		v1.POST("/account/list", api_code.DoMonitorLog("account_list"), cacheHandleShort, h.H.AccountList)
-		v1.POST("/account/detail", api_code.DoMonitorLog("account_detail"), cacheHandleShort, h.H.AccountDetail)
-		v1.POST("/sub/account/list", api_code.DoMonitorLog("sub_account_list"), cacheHandleShort, h.H.SubAccountList)
+		v1.POST("/account/detail", api_code.DoMonitorLog("account_detail"), h.H.CacheTag(shortDataTime), cacheHandleShort, h.H.AccountDetail)
+		v1.POST("/sub/account/list", api_code.DoMonitorLog("sub_account_list"), h.H.CacheTag(shortDataTime), cacheHandleShort, h.H.SubAccountList)
 		v1.POST("/transaction/status", api_code.DoMonitorLog("tx_status"), cacheHandleShort, h.H.TransactionStatus)
-		v1.POST("/sub/account/mint/status", api_code.DoMonitorLog("mint_status"), cacheHandleShort, h.H.SubAccountMintStatus)
-		v1.POST("/statistical/info", api_code.DoMonitorLog("statistical_info"), cacheHandleShort, h.H.StatisticalInfo)
-		v1.POST("/distribution/list", api_code.DoMonitorLog("distribution_list"), cacheHandleShort, h.H.DistributionList)
+		v1.POST("/sub/account/mint/status", api_code.DoMonitorLog("mint_status"), h.H.CacheTag(shortDataTime), cacheHandleShort, h.H.SubAccountMintStatus)
+		v1.POST("/statistical/info", api_code.DoMonitorLog("statistical_info"), h.H.CheckApiKey(tables.ApiKeyScopeStatsRead), h.H.CheckPermissionsWithRoles(tables.AccountRoleFinance), h.H.CacheTag(shortDataTime), cacheHandleShort, h.H.StatisticalInfo)
+		v1.POST("/distribution/list", api_code.DoMonitorLog("distribution_list"), h.H.CheckApiKey(tables.ApiKeyScopeStatsRead), h.H.CheckPermissionsWithRoles(tables.AccountRoleSupport, tables.AccountRoleFinance), h.H.CacheTag(shortDataTime), cacheHandleShort, h.H.DistributionList)
 		v1.POST("/currency/list", api_code.DoMonitorLog("currency_list"), cacheHandleShort, h.H.CurrencyList)
 		v1.POST("/config/auto_mint/get", api_code.DoMonitorLog("config_auto_mint_get"), cacheHandleShort, h.H.ConfigAutoMintGet)
 		v1.POST("/price/rule/list", api_code.DoMonitorLog("price_rule_list"), cacheHandleShort, h.H.PriceRuleList)
 		v1.POST("/preserved/rule/list", api_code.DoMonitorLog("preserved_rule_list"), cacheHandleShort, h.H.PreservedRuleList)
-		v1.POST("/auto/payment/list", api_code.DoMonitorLog("auto_payment_list"), cacheHandleShort, h.H.AutoPaymentList)
+		v1.POST("/rule/export", api_code.DoMonitorLog("rule_export"), h.H.RuleExport)
+		v1.POST("/auto/payment/list", api_code.DoMonitorLog("auto_payment_list"), h.H.CheckApiKey(tables.ApiKeyScopeStatsRead), h.H.CheckPermissionsWithRoles(tables.AccountRoleFinance), cacheHandleShort, h.H.AutoPaymentList)
+		v1.POST("/auto/payment/export", api_code.DoMonitorLog("auto_payment_export"), h.H.CheckApiKey(tables.ApiKeyScopeStatsRead), h.H.CheckPermissionsWithRoles(tables.AccountRoleFinance), h.H.AutoPaymentExport)
 		v1.POST("/auto/order/info", api_code.DoMonitorLog("auto_order_info"), cacheHandleShort, h.H.AutoOrderInfo)
 		v1.POST("/mint/config/get", api_code.DoMonitorLog("mint_config_get"), cacheHandleShort, h.H.MintConfigGet)
+		v1.POST("/referral/dashboard", api_code.DoMonitorLog("referral_dashboard"), h.H.CheckReferrerPermissions, h.H.ReferralDashboard)
 		v1.POST("/coupon/order/info", api_code.DoMonitorLog("coupon_order_info"), h.H.CouponOrderInfo)
 		v1.POST("/coupon/set/list", api_code.DoMonitorLog("coupon_set_list"), cacheHandleShort, h.H.CouponSetList)
-		v1.POST("/coupon/code/list", api_code.DoMonitorLog("coupon_code_list"), cacheHandleShort, h.H.CheckPermissions, h.H.CouponCodeList)
+		v1.POST("/coupon/code/list", api_code.DoMonitorLog("coupon_code_list"), h.H.CheckApiKey(tables.ApiKeyScopeCouponManage), h.H.CheckPermissionsWithRoles(tables.AccountRoleCouponManager), cacheHandleShort, h.H.CouponCodeList)
 		v1.POST("/coupon/info", api_code.DoMonitorLog("coupon_info"), cacheHandleShort, h.H.CouponInfo)
-		v1.POST("/coupon/download", api_code.DoMonitorLog("coupon_download"), cacheHandleShort, h.H.CheckPermissions, h.H.CouponDownload)
+		v1.POST("/coupon/download", api_code.DoMonitorLog("coupon_download"), h.H.CheckApiKey(tables.ApiKeyScopeCouponManage), h.H.CheckPermissionsWithRoles(tables.AccountRoleCouponManager), cacheHandleShort, h.H.CouponDownload)
 		v1.POST("/signin/info", api_code.DoMonitorLog("signin_info"), h.H.SignInInfo)
+		v1.GET("/status/stream", h.H.StatusStream)
 		v1.StaticFS("/static", http.FS(static_files.MintJs))
 
 		//v1.POST("/sub/account/init", api_code.DoMonitorLog("account_init"), h.H.SubAccountInit)               // enable_sub_account
@@ -60,47 +68,79 @@ func (h *HttpServer) initRouter() {
 		v1.POST("/sub/account/renew", api_code.DoMonitorLog("account_renew"), h.H.SubAccountRenew)                  // renew_sub_account
 		v1.POST("/sub/account/renew/check", api_code.DoMonitorLog("account_renew_check"), h.H.SubAccountRenewCheck) // renew_sub_account_check
 		v1.POST("/sub/account/edit", api_code.DoMonitorLog("account_edit"), h.H.SubAccountEditNew)                  // edit_sub_account
-		v1.POST("/owner/profit", api_code.DoMonitorLog("owner_profit"), h.H.OwnerProfit)
+		v1.POST("/owner/profit", api_code.DoMonitorLog("owner_profit"), h.H.CheckApiKey(tables.ApiKeyScopeStatsRead), h.H.CheckPermissionsWithRoles(tables.AccountRoleFinance), h.H.OwnerProfit)
 		v1.POST("/profit/withdraw", api_code.DoMonitorLog("profit_withdraw"), h.H.ProfitWithdraw)
 		//v1.POST("/custom/script/set", api_code.DoMonitorLog("custom_script"), h.H.CustomScript)
 		//v1.POST("/custom/script/info", api_code.DoMonitorLog("custom_script_info"), h.H.CustomScriptInfo)
 		//v1.POST("/custom/script/price", api_code.DoMonitorLog("mint_price"), cacheHandleShort, h.H.CustomScriptPrice)
 		v1.POST("/transaction/send", api_code.DoMonitorLog("tx_send"), h.H.TransactionSendNew)
-		v1.POST("/mint/config/update", api_code.DoMonitorLog("mint_config_update"), h.H.MintConfigUpdate)
+		v1.POST("/mint/config/update", api_code.DoMonitorLog("mint_config_update"), h.H.CheckApiKey(tables.ApiKeyScopeMintConfigManage), h.H.MintConfigUpdate)
 		v1.POST("/config/auto_mint/update", api_code.DoMonitorLog("config_auto_mint_update"), h.H.ConfigAutoMintUpdate)
 		v1.POST("/price/rule/update", api_code.DoMonitorLog("price_rule_update"), h.H.PriceRuleUpdate)
+		v1.POST("/price/rule/simulate", api_code.DoMonitorLog("price_rule_simulate"), h.H.CheckPermissions, h.H.PriceRuleSimulate)
+		v1.POST("/price/rule/schedule", api_code.DoMonitorLog("price_rule_schedule"), h.H.PriceRuleSchedule)
+		v1.POST("/price/rule/schedule/list", api_code.DoMonitorLog("price_rule_schedule_list"), h.H.CheckPermissions, h.H.PriceRuleScheduleList)
 		v1.POST("/preserved/rule/update", api_code.DoMonitorLog("preserved_rule_update"), h.H.PreservedRuleUpdate)
+		v1.POST("/rule/import/preview", api_code.DoMonitorLog("rule_import_preview"), h.H.CheckPermissions, h.H.RuleImportPreview)
+		v1.POST("/rule/version/list", api_code.DoMonitorLog("rule_version_list"), h.H.CheckPermissions, h.H.RuleVersionList)
+		v1.POST("/rule/version/diff", api_code.DoMonitorLog("rule_version_diff"), h.H.CheckPermissions, h.H.RuleVersionDiff)
+		v1.POST("/rule/version/rollback", api_code.DoMonitorLog("rule_version_rollback"), h.H.RuleVersionRollback)
 		v1.POST("/auto/account/search", api_code.DoMonitorLog("auto_acc_search"), h.H.AutoAccountSearch)
+		v1.POST("/auto/account/suggest", api_code.DoMonitorLog("auto_acc_suggest"), h.H.AutoAccountSuggest)
+		v1.POST("/auto/account/quote", api_code.DoMonitorLog("auto_acc_quote"), h.H.AutoAccountQuote)
 		v1.POST("/auto/order/create", api_code.DoMonitorLog("auto_order_create"), h.H.AutoOrderCreate)
 		v1.POST("/auto/order/hash", api_code.DoMonitorLog("auto_order_hash"), h.H.AutoOrderHash)
-		v1.POST("/currency/update", api_code.DoMonitorLog("currency_update"), h.H.CurrencyUpdate)
+		v1.POST("/currency/update", api_code.DoMonitorLog("currency_update"), h.H.CheckApiKey(tables.ApiKeyScopeMintConfigManage), h.H.CurrencyUpdate)
+		v1.POST("/discount/config/update", api_code.DoMonitorLog("discount_config_update"), h.H.CheckApiKey(tables.ApiKeyScopeMintConfigManage), h.H.DiscountConfigUpdate)
+		v1.POST("/presale/config/update", api_code.DoMonitorLog("presale_config_update"), h.H.CheckApiKey(tables.ApiKeyScopeMintConfigManage), h.H.PresaleConfigUpdate)
+		v1.POST("/mint/limit/update", api_code.DoMonitorLog("mint_limit_update"), h.H.CheckApiKey(tables.ApiKeyScopeMintConfigManage), h.H.MintLimitUpdate)
+		v1.POST("/referral/update", api_code.DoMonitorLog("referral_update"), h.H.CheckApiKey(tables.ApiKeyScopeMintConfigManage), h.H.ReferralUpdate)
 		//v1.POST("/mint/config/send", api_code.DoMonitorLog("mint_config_send"), h.H.MintConfigSend)
 		v1.POST("/approval/enable", api_code.DoMonitorLog("approval_enable"), h.H.ApprovalEnable)
 		v1.POST("/approval/delay", api_code.DoMonitorLog("approval_delay"), h.H.ApprovalDelay)
 		v1.POST("/approval/revoke", api_code.DoMonitorLog("approval_revoke"), h.H.ApprovalRevoke)
 		v1.POST("/approval/fulfill", api_code.DoMonitorLog("approval_fulfill"), h.H.ApprovalFulfill)
-		v1.POST("/coupon/order/create", api_code.DoMonitorLog("coupon_order_create"), h.H.CheckPermissions, h.H.CouponOrderCreate)
+		v1.POST("/coupon/order/create", api_code.DoMonitorLog("coupon_order_create"), h.H.CheckApiKey(tables.ApiKeyScopeCouponManage), h.H.CheckPermissionsWithRoles(tables.AccountRoleCouponManager), h.H.CouponOrderCreate)
 		v1.POST("/signin", api_code.DoMonitorLog("signin"), h.H.SignIn)
+		v1.POST("/signin/refresh", api_code.DoMonitorLog("signin_refresh"), h.H.SignInRefresh)
+		v1.POST("/signout", api_code.DoMonitorLog("signout"), h.H.SignOut)
+		v1.POST("/bulk/mint/job/create", api_code.DoMonitorLog("bulk_mint_job_create"), h.H.CheckApiKey(tables.ApiKeyScopeMintBatchCreate), h.H.BulkMintJobCreate)
+		v1.POST("/bulk/mint/job/sign", api_code.DoMonitorLog("bulk_mint_job_sign"), h.H.BulkMintJobSign) // create_sub_account
+		v1.POST("/bulk/mint/job/info", api_code.DoMonitorLog("bulk_mint_job_info"), h.H.BulkMintJobInfo)
+		v1.POST("/webhook/update", api_code.DoMonitorLog("webhook_update"), h.H.WebhookUpdate)
+		v1.POST("/referral/list", api_code.DoMonitorLog("referral_list"), h.H.CheckPermissions, h.H.ReferralList)
+		v1.POST("/webhook/list", api_code.DoMonitorLog("webhook_list"), h.H.CheckPermissions, h.H.WebhookList)
+		v1.POST("/webhook/delivery/list", api_code.DoMonitorLog("webhook_delivery_list"), h.H.CheckPermissions, h.H.WebhookDeliveryList)
+		v1.POST("/webhook/test", api_code.DoMonitorLog("webhook_test"), h.H.CheckPermissions, h.H.WebhookTestFire)
+		v1.POST("/api/key/update", api_code.DoMonitorLog("api_key_update"), h.H.ApiKeyUpdate)
+		v1.POST("/api/key/list", api_code.DoMonitorLog("api_key_list"), h.H.CheckPermissions, h.H.ApiKeyList)
+		v1.POST("/role/update", api_code.DoMonitorLog("role_update"), h.H.RoleUpdate)
+		v1.POST("/role/list", api_code.DoMonitorLog("role_list"), h.H.CheckPermissions, h.H.RoleList)
+		v1.POST("/role/log/list", api_code.DoMonitorLog("role_log_list"), h.H.CheckPermissions, h.H.RoleLogList)
+		v1.POST("/audit/log/list", api_code.DoMonitorLog("audit_log_list"), h.H.CheckPermissions, h.H.AuditLogList)
 	}
 
 	internalV1 := h.internalEngine.Group("v1")
 	{
 		internalV1.POST("/internal/smt/info", h.H.SmtInfo)
 		internalV1.POST("/internal/smt/check", h.H.SmtCheck)
-		internalV1.POST("/internal/smt/update", h.H.SmtUpdate)
+		internalV1.POST("/internal/smt/update", h.H.CheckInternalAuth, h.H.SmtUpdate)
 		internalV1.POST("/internal/smt/syncTree", h.H.SmtSync)
 
 		//internalV1.POST("/internal/sub/account/mint", h.H.InternalSubAccountMintNew)
 		internalV1.POST("/owner/payment/export", h.H.OwnerPaymentExport)
-		internalV1.POST("/unipay/notice", h.H.UniPayNotice)
+		internalV1.POST("/unipay/notice", h.H.CheckInternalAuth, h.H.UniPayNotice)
 		internalV1.POST("/service/provider/withdraw", h.H.ServiceProviderWithdraw)
-		internalV1.POST("/service/provider/withdraw2", h.H.ServiceProviderWithdraw2)
-		internalV1.POST("/internal/recycle/account", h.H.RecycleAccount)
+		internalV1.POST("/service/provider/withdraw2", h.H.CheckInternalAuth, h.H.ServiceProviderWithdraw2)
+		internalV1.POST("/internal/recycle/account", h.H.CheckInternalAuth, h.H.RecycleAccount)
+		internalV1.POST("/internal/suspension/create", h.H.CheckInternalAuth, h.H.SuspensionCreate)
+		internalV1.POST("/internal/suspension/lift", h.H.CheckInternalAuth, h.H.SuspensionLift)
+		internalV1.POST("/internal/suspension/list", h.H.CheckInternalAuth, h.H.SuspensionList)
 		internalV1.POST("/coupon/statistical/info", h.H.CouponStatisticalInfo)
 		internalV1.GET("/debug/notify", h.H.DebugNotify)
 
 		// for padge edit record
-		internalV1.POST("/padge/record/edit", api_code.DoMonitorLog("padge_record_edit"), h.H.PadgeRecordEdit)
+		internalV1.POST("/padge/record/edit", api_code.DoMonitorLog("padge_record_edit"), h.H.CheckInternalAuth, h.H.PadgeRecordEdit)
 	}
 	// curl -X POST http://127.0.0.1:8127/v1/service/provider/withdraw2 -d'{"service_provider_address":"","account":"","withdraw":false}'
 }
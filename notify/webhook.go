@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// GetWebhookSignature receivers recompute hex(hmac-sha256(secret, "<t>.<body>")) and compare it with v1
+func GetWebhookSignature(secret string, timestamp int64, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(fmt.Sprintf("%d.%s", timestamp, body)))
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// the shared address space of the carrier-grade nat, not covered by net.IP.IsPrivate
+var cgnatNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// IsWebhookIP the webhooks must not reach the private, loopback, link-local (including the cloud metadata) or other non-public addresses
+func IsWebhookIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil && (cgnatNet.Contains(ip4) || ip4[0] == 0 || ip4.Equal(net.IPv4bcast)) {
+		return false
+	}
+	return true
+}
+
+// CheckWebhookUrl resolves the host of the url, every address of it must be public
+func CheckWebhookUrl(ctx context.Context, rawUrl string) error {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return fmt.Errorf("url.Parse err: %s", err.Error())
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("host is empty")
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if !IsWebhookIP(ip) {
+			return fmt.Errorf("address %s not allowed", ip.String())
+		}
+		return nil
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return fmt.Errorf("LookupIPAddr err: %s", err.Error())
+	} else if len(addrs) == 0 {
+		return fmt.Errorf("no address of %s", host)
+	}
+	for _, v := range addrs {
+		if !IsWebhookIP(v.IP) {
+			return fmt.Errorf("address %s of %s not allowed", v.IP.String(), host)
+		}
+	}
+	return nil
+}
+
+// webhookDialControl checks the address actually dialed, so the host can't be rebound to a private address after CheckWebhookUrl
+func webhookDialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); !IsWebhookIP(ip) {
+		return fmt.Errorf("address %s not allowed", host)
+	}
+	return nil
+}
+
+var webhookClient = &http.Client{
+	Timeout: time.Second * 10,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: time.Second * 5,
+			Control: webhookDialControl,
+		}).DialContext,
+		TLSHandshakeTimeout: time.Second * 5,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     time.Second * 90,
+	},
+	// the redirects are not followed, the receiver must answer the url itself
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// SendWebhook posts the payload as it is, the body must not be re-encoded after it was signed
+func SendWebhook(url, secret, event, deliveryId, payload string) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(payload))
+	if err != nil {
+		return 0, fmt.Errorf("http.NewRequest err: %s", err.Error())
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookHeaderEvent, event)
+	req.Header.Set(WebhookHeaderDelivery, deliveryId)
+	req.Header.Set(WebhookHeaderSignature, GetWebhookSignature(secret, time.Now().Unix(), payload))
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("req err: %s", err.Error())
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("http status code: %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
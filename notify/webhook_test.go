@@ -0,0 +1,18 @@
+package notify
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+)
+
+func TestGetWebhookSignature(t *testing.T) {
+	body := `{"id":"1","event":"ping"}`
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("1672211096." + body))
+	want := "t=1672211096,v1=" + hex.EncodeToString(mac.Sum(nil))
+	if got := GetWebhookSignature("secret", 1672211096, body); got != want {
+		t.Fatal(got, want)
+	}
+}
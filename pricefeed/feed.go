@@ -0,0 +1,217 @@
+package pricefeed
+
+import (
+	"context"
+	"das_sub_account/notify"
+	"errors"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/http_api/logger"
+	"github.com/shopspring/decimal"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = logger.NewLogger("pricefeed", logger.LevelDebug)
+
+// the prices away from the median are rejected only if there are enough sources
+const minDeviationSources = 3
+
+var (
+	ErrNotTracked = errors.New("token not tracked by the price feed")
+	ErrStale      = errors.New("token price is stale")
+)
+
+type FeedParams struct {
+	Sources      []Source
+	StaleAfter   time.Duration
+	MaxDeviation decimal.Decimal // 0.05 rejects the prices more than 5% away from the median
+	MinSources   int
+	IgnoreTokens []string
+	Alert        func(title, text string)
+}
+
+// Price the aggregated price of one token
+type Price struct {
+	Price     decimal.Decimal
+	Sources   int
+	UpdatedAt time.Time
+}
+
+type Feed struct {
+	p      FeedParams
+	ignore map[string]struct{}
+
+	lock   sync.RWMutex
+	prices map[string]Price
+	stale  map[string]struct{}
+}
+
+func NewFeed(p FeedParams) *Feed {
+	if p.MinSources <= 0 {
+		p.MinSources = 1
+	}
+	if p.Alert == nil {
+		p.Alert = notify.SendLarkErrNotify
+	}
+	f := Feed{
+		p:      p,
+		ignore: make(map[string]struct{}),
+		prices: make(map[string]Price),
+		stale:  make(map[string]struct{}),
+	}
+	for _, v := range p.IgnoreTokens {
+		f.ignore[v] = struct{}{}
+	}
+	return &f
+}
+
+func (f *Feed) Run(ctx context.Context, wg *sync.WaitGroup, interval time.Duration) {
+	f.Update(ctx)
+	tickerFeed := time.NewTicker(interval)
+	wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			select {
+			case <-tickerFeed.C:
+				f.Update(ctx)
+			case <-ctx.Done():
+				log.Debug("price feed done")
+				wg.Done()
+				return
+			}
+		}
+	}()
+}
+
+// GetPrice ErrNotTracked for the ignored tokens, which are priced by t_token_price_info
+func (f *Feed) GetPrice(tokenId string) (decimal.Decimal, error) {
+	if _, ok := f.ignore[tokenId]; ok {
+		return decimal.Zero, ErrNotTracked
+	}
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	price, ok := f.prices[tokenId]
+	if !ok || f.isStale(price, time.Now()) {
+		return decimal.Zero, ErrStale
+	}
+	return price.Price, nil
+}
+
+func (f *Feed) isStale(price Price, now time.Time) bool {
+	return now.Sub(price.UpdatedAt) > f.p.StaleAfter
+}
+
+// Update fetch all the sources and aggregate the prices
+func (f *Feed) Update(ctx context.Context) {
+	points := make(map[string]map[string]Point)
+	for _, s := range f.p.Sources {
+		res, err := s.GetPrices(ctx)
+		if err != nil {
+			log.Error("GetPrices err:", s.Name(), err.Error())
+			f.p.Alert("price feed", fmt.Sprintf("source %s: %s", s.Name(), err.Error()))
+			continue
+		}
+		points[s.Name()] = res
+	}
+	f.aggregate(points, time.Now())
+}
+
+func (f *Feed) aggregate(points map[string]map[string]Point, now time.Time) {
+	tokens := make(map[string]map[string]decimal.Decimal)
+	for source, res := range points {
+		for tokenId, v := range res {
+			if _, ok := f.ignore[tokenId]; ok {
+				continue
+			}
+			if _, ok := tokens[tokenId]; !ok {
+				tokens[tokenId] = make(map[string]decimal.Decimal)
+			}
+			if v.Price.LessThanOrEqual(decimal.Zero) || now.Sub(v.UpdatedAt) > f.p.StaleAfter {
+				continue
+			}
+			tokens[tokenId][source] = v.Price
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	var deviations []string
+	for tokenId, sourcePrices := range tokens {
+		if len(sourcePrices) == 0 {
+			if _, ok := f.prices[tokenId]; !ok {
+				f.prices[tokenId] = Price{}
+			}
+			continue
+		}
+		list := make([]decimal.Decimal, 0, len(sourcePrices))
+		for _, v := range sourcePrices {
+			list = append(list, v)
+		}
+		m := median(list)
+
+		// the outlier can only be told with 3 sources at least, the 2 sources are both kept and alerted
+		keep := make([]decimal.Decimal, 0, len(list))
+		for source, v := range sourcePrices {
+			if v.Sub(m).Abs().Div(m).GreaterThan(f.p.MaxDeviation) {
+				deviations = append(deviations, fmt.Sprintf("%s %s: %s, median: %s", tokenId, source, v, m))
+				if len(sourcePrices) >= minDeviationSources {
+					continue
+				}
+			}
+			keep = append(keep, v)
+		}
+		if len(keep) < f.p.MinSources {
+			if _, ok := f.prices[tokenId]; !ok {
+				f.prices[tokenId] = Price{}
+			}
+			continue
+		}
+		f.prices[tokenId] = Price{
+			Price:     median(keep),
+			Sources:   len(keep),
+			UpdatedAt: now,
+		}
+	}
+	if len(deviations) > 0 {
+		sort.Strings(deviations)
+		log.Warn("price feed deviation:", deviations)
+		f.p.Alert("price feed deviation", strings.Join(deviations, "\n"))
+	}
+
+	// alert once when the token becomes stale
+	var staleList []string
+	for tokenId, price := range f.prices {
+		_, wasStale := f.stale[tokenId]
+		if !f.isStale(price, now) {
+			if wasStale {
+				log.Info("price feed recovered:", tokenId)
+				delete(f.stale, tokenId)
+			}
+			continue
+		}
+		if !wasStale {
+			f.stale[tokenId] = struct{}{}
+			staleList = append(staleList, tokenId)
+		}
+	}
+	if len(staleList) > 0 {
+		sort.Strings(staleList)
+		log.Warn("price feed stale:", staleList)
+		f.p.Alert("price feed stale", strings.Join(staleList, ", "))
+	}
+}
+
+func median(list []decimal.Decimal) decimal.Decimal {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LessThan(list[j])
+	})
+	n := len(list)
+	if n%2 == 1 {
+		return list[n/2]
+	}
+	return list[n/2-1].Add(list[n/2]).Div(decimal.NewFromInt(2))
+}
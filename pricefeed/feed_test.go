@@ -0,0 +1,133 @@
+package pricefeed
+
+import (
+	"context"
+	"github.com/shopspring/decimal"
+	"testing"
+	"time"
+)
+
+type fakeSource struct {
+	name   string
+	prices map[string]Point
+}
+
+func (s *fakeSource) Name() string {
+	return s.name
+}
+
+func (s *fakeSource) GetPrices(ctx context.Context) (map[string]Point, error) {
+	return s.prices, nil
+}
+
+func newFakeSource(name string, price float64, updatedAt time.Time) *fakeSource {
+	return &fakeSource{name: name, prices: map[string]Point{
+		"eth_eth": {Price: decimal.NewFromFloat(price), UpdatedAt: updatedAt},
+	}}
+}
+
+func newTestFeed(alerts *[]string, sources ...Source) *Feed {
+	return NewFeed(FeedParams{
+		Sources:      sources,
+		StaleAfter:   time.Minute * 10,
+		MaxDeviation: decimal.NewFromFloat(0.05),
+		MinSources:   2,
+		IgnoreTokens: []string{"stripe_usd"},
+		Alert: func(title, text string) {
+			*alerts = append(*alerts, title)
+		},
+	})
+}
+
+func TestFeedMedian(t *testing.T) {
+	now := time.Now()
+	var alerts []string
+	f := newTestFeed(&alerts,
+		newFakeSource("a", 2000, now),
+		newFakeSource("b", 2010, now),
+		newFakeSource("c", 2030, now),
+	)
+	f.Update(context.Background())
+	price, err := f.GetPrice("eth_eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !price.Equal(decimal.NewFromInt(2010)) {
+		t.Fatal(price)
+	}
+	if len(alerts) != 0 {
+		t.Fatal(alerts)
+	}
+	if _, err := f.GetPrice("stripe_usd"); err != ErrNotTracked {
+		t.Fatal(err)
+	}
+}
+
+func TestFeedOutlier(t *testing.T) {
+	now := time.Now()
+	var alerts []string
+	f := newTestFeed(&alerts,
+		newFakeSource("a", 2000, now),
+		newFakeSource("b", 2020, now),
+		newFakeSource("c", 3000, now),
+	)
+	f.Update(context.Background())
+	price, err := f.GetPrice("eth_eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !price.Equal(decimal.NewFromInt(2010)) {
+		t.Fatal(price)
+	}
+	if len(alerts) != 1 || alerts[0] != "price feed deviation" {
+		t.Fatal(alerts)
+	}
+}
+
+func TestFeedTwoSourcesDeviation(t *testing.T) {
+	now := time.Now()
+	var alerts []string
+	f := newTestFeed(&alerts,
+		newFakeSource("a", 2000, now),
+		newFakeSource("b", 2400, now),
+	)
+	f.Update(context.Background())
+	price, err := f.GetPrice("eth_eth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !price.Equal(decimal.NewFromInt(2200)) {
+		t.Fatal(price)
+	}
+	if len(alerts) != 1 || alerts[0] != "price feed deviation" {
+		t.Fatal(alerts)
+	}
+}
+
+func TestFeedStale(t *testing.T) {
+	now := time.Now()
+	var alerts []string
+	a, b := newFakeSource("a", 2000, now), newFakeSource("b", 2000, now.Add(-time.Hour))
+	f := newTestFeed(&alerts, a, b)
+
+	// not enough fresh sources
+	f.Update(context.Background())
+	if _, err := f.GetPrice("eth_eth"); err != ErrStale {
+		t.Fatal(err)
+	}
+	if len(alerts) != 1 || alerts[0] != "price feed stale" {
+		t.Fatal(alerts)
+	}
+
+	// recovered, then stale again after the threshold
+	b.prices["eth_eth"] = Point{Price: decimal.NewFromInt(2000), UpdatedAt: now}
+	f.aggregate(map[string]map[string]Point{"a": a.prices, "b": b.prices}, now)
+	if _, err := f.GetPrice("eth_eth"); err != nil {
+		t.Fatal(err)
+	}
+	f.aggregate(map[string]map[string]Point{}, now.Add(time.Minute*11))
+	f.aggregate(map[string]map[string]Point{}, now.Add(time.Minute*12))
+	if len(alerts) != 2 {
+		t.Fatal(alerts)
+	}
+}
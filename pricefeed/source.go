@@ -0,0 +1,79 @@
+package pricefeed
+
+import (
+	"context"
+	"das_sub_account/dao"
+	"fmt"
+	"github.com/parnurzeal/gorequest"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// Point the USD price of one token from a source
+type Point struct {
+	Price     decimal.Decimal `json:"price"`
+	UpdatedAt time.Time       `json:"-"`
+}
+
+// Source one of the price feeds aggregated, the key of the prices is the token_id
+type Source interface {
+	Name() string
+	GetPrices(ctx context.Context) (map[string]Point, error)
+}
+
+// DbSource the prices in t_token_price_info synced by the parser
+type DbSource struct {
+	DbDao *dao.DbDao
+}
+
+func (s *DbSource) Name() string {
+	return "db"
+}
+
+func (s *DbSource) GetPrices(ctx context.Context) (map[string]Point, error) {
+	tokens, err := s.DbDao.FindTokens()
+	if err != nil {
+		return nil, fmt.Errorf("FindTokens err: %s", err.Error())
+	}
+	res := make(map[string]Point)
+	for k, v := range tokens {
+		res[k] = Point{
+			Price:     v.Price,
+			UpdatedAt: time.Unix(v.LastUpdatedAt, 0),
+		}
+	}
+	return res, nil
+}
+
+// HttpSource GET the url for {"token_id": {"price": "1.23", "updated_at": unix seconds}}
+type HttpSource struct {
+	SourceName string
+	Url        string
+}
+
+type httpPoint struct {
+	Price     decimal.Decimal `json:"price"`
+	UpdatedAt int64           `json:"updated_at"`
+}
+
+func (s *HttpSource) Name() string {
+	return s.SourceName
+}
+
+func (s *HttpSource) GetPrices(ctx context.Context) (map[string]Point, error) {
+	var data map[string]httpPoint
+	resp, _, errs := gorequest.New().Get(s.Url).Timeout(time.Second * 10).EndStruct(&data)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("GET %s err: %v", s.Name(), errs)
+	} else if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("GET %s status: %d", s.Name(), resp.StatusCode)
+	}
+	res := make(map[string]Point)
+	for k, v := range data {
+		res[k] = Point{
+			Price:     v.Price,
+			UpdatedAt: time.Unix(v.UpdatedAt, 0),
+		}
+	}
+	return res, nil
+}
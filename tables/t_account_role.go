@@ -0,0 +1,81 @@
+package tables
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"strings"
+	"time"
+)
+
+type AccountRole string
+
+const (
+	AccountRoleCouponManager AccountRole = "coupon_manager"
+	AccountRoleSupport       AccountRole = "support"
+	AccountRoleFinance       AccountRole = "finance"
+)
+
+var AccountRoleList = []AccountRole{
+	AccountRoleCouponManager,
+	AccountRoleSupport,
+	AccountRoleFinance,
+}
+
+func IsAccountRole(role string) bool {
+	for _, v := range AccountRoleList {
+		if string(v) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// TableAccountRole roles granted by the owner to other addresses, owner and manager have all roles
+type TableAccountRole struct {
+	Id              uint64           `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	ParentAccountId string           `json:"parent_account_id" gorm:"column:parent_account_id; uniqueIndex:uk_parent_address_role; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string           `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	ChainType       common.ChainType `json:"chain_type" gorm:"column:chain_type; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '';"`
+	Address         string           `json:"address" gorm:"column:address; uniqueIndex:uk_parent_address_role; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Role            AccountRole      `json:"role" gorm:"column:role; uniqueIndex:uk_parent_address_role; type:varchar(64) NOT NULL DEFAULT '' COMMENT '';"`
+	IssuerChainType common.ChainType `json:"issuer_chain_type" gorm:"column:issuer_chain_type; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '';"`
+	Issuer          string           `json:"issuer" gorm:"column:issuer; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'the owner who granted the role';"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time        `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameAccountRole    = "t_account_role"
+	TableNameAccountRoleLog = "t_account_role_log"
+)
+
+func (t *TableAccountRole) TableName() string {
+	return TableNameAccountRole
+}
+
+// IsIssuedByOwner the role is only valid while its issuer is still the owner of the parent account
+func (t *TableAccountRole) IsIssuedByOwner(acc TableAccountInfo) bool {
+	return t.Issuer != "" && t.IssuerChainType == acc.OwnerChainType && strings.EqualFold(t.Issuer, acc.Owner)
+}
+
+type AccountRoleOperation string
+
+const (
+	AccountRoleOperationGrant  AccountRoleOperation = "grant"
+	AccountRoleOperationRevoke AccountRoleOperation = "revoke"
+)
+
+type TableAccountRoleLog struct {
+	Id              uint64               `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	ParentAccountId string               `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string               `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	ChainType       common.ChainType     `json:"chain_type" gorm:"column:chain_type; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '';"`
+	Address         string               `json:"address" gorm:"column:address; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Role            AccountRole          `json:"role" gorm:"column:role; type:varchar(64) NOT NULL DEFAULT '' COMMENT '';"`
+	Operation       AccountRoleOperation `json:"operation" gorm:"column:operation; type:varchar(64) NOT NULL DEFAULT '' COMMENT 'grant, revoke';"`
+	Operator        string               `json:"operator" gorm:"column:operator; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'owner address';"`
+	CreatedAt       time.Time            `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+func (t *TableAccountRoleLog) TableName() string {
+	return TableNameAccountRoleLog
+}
@@ -0,0 +1,91 @@
+package tables
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"strings"
+	"time"
+)
+
+type ApiKeyScope string
+
+const (
+	ApiKeyScopeStatsRead        ApiKeyScope = "stats:read"
+	ApiKeyScopeCouponManage     ApiKeyScope = "coupon:manage"
+	ApiKeyScopeMintConfigManage ApiKeyScope = "mint_config:manage"
+	ApiKeyScopeMintBatchCreate  ApiKeyScope = "mint_batch:create"
+)
+
+var ApiKeyScopeList = []ApiKeyScope{
+	ApiKeyScopeStatsRead,
+	ApiKeyScopeCouponManage,
+	ApiKeyScopeMintConfigManage,
+	ApiKeyScopeMintBatchCreate,
+}
+
+func IsApiKeyScope(scope string) bool {
+	for _, v := range ApiKeyScopeList {
+		if string(v) == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type ApiKeyStatus int
+
+const (
+	ApiKeyStatusNormal  ApiKeyStatus = 0
+	ApiKeyStatusRevoked ApiKeyStatus = 1
+)
+
+type TableApiKey struct {
+	Id              uint64           `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	KeyId           string           `json:"key_id" gorm:"column:key_id; uniqueIndex:uk_key_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	KeyHash         string           `json:"-" gorm:"column:key_hash; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'sha256 of the key';"`
+	ParentAccountId string           `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string           `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	Name            string           `json:"name" gorm:"column:name; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Scopes          string           `json:"scopes" gorm:"column:scopes; type:varchar(1024) NOT NULL DEFAULT '' COMMENT 'comma separated';"`
+	RateLimit       int              `json:"rate_limit" gorm:"column:rate_limit; type:int(11) NOT NULL DEFAULT '0' COMMENT 'requests per minute';"`
+	ExpiredAt       int64            `json:"expired_at" gorm:"column:expired_at; type:bigint(20) NOT NULL DEFAULT '0' COMMENT '';"`
+	Status          ApiKeyStatus     `json:"status" gorm:"column:status; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-normal 1-revoked';"`
+	IssuerChainType common.ChainType `json:"issuer_chain_type" gorm:"column:issuer_chain_type; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '';"`
+	Issuer          string           `json:"issuer" gorm:"column:issuer; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'the owner who issued the key';"`
+	LastUsedAt      int64            `json:"last_used_at" gorm:"column:last_used_at; type:bigint(20) NOT NULL DEFAULT '0' COMMENT '';"`
+	LastUsedIp      string           `json:"last_used_ip" gorm:"column:last_used_ip; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time        `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameApiKey = "t_api_key"
+)
+
+func (t *TableApiKey) TableName() string {
+	return TableNameApiKey
+}
+
+func (t *TableApiKey) GetScopes() []string {
+	if t.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+func (t *TableApiKey) HasScope(scope ApiKeyScope) bool {
+	for _, v := range t.GetScopes() {
+		if v == string(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *TableApiKey) IsExpired() bool {
+	return t.ExpiredAt > 0 && time.Now().UnixMilli() > t.ExpiredAt
+}
+
+// IsIssuedByOwner the key is only valid while its issuer is still the owner of the parent account
+func (t *TableApiKey) IsIssuedByOwner(acc TableAccountInfo) bool {
+	return t.Issuer != "" && t.IssuerChainType == acc.OwnerChainType && strings.EqualFold(t.Issuer, acc.Owner)
+}
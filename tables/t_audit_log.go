@@ -0,0 +1,59 @@
+package tables
+
+import (
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditActionPriceRuleUpdate     AuditAction = "price_rule_update"
+	AuditActionPreservedRuleUpdate AuditAction = "preserved_rule_update"
+	AuditActionAutoMintUpdate      AuditAction = "auto_mint_update"
+	AuditActionMintConfigUpdate    AuditAction = "mint_config_update"
+	AuditActionCurrencyUpdate      AuditAction = "currency_update"
+	AuditActionDiscountUpdate      AuditAction = "discount_update"
+	AuditActionPresaleUpdate       AuditAction = "presale_update"
+	AuditActionMintLimitUpdate     AuditAction = "mint_limit_update"
+	AuditActionReferralUpdate      AuditAction = "referral_update"
+	AuditActionCouponCreate        AuditAction = "coupon_create"
+	AuditActionApproval            AuditAction = "approval"
+	AuditActionWebhookUpdate       AuditAction = "webhook_update"
+	AuditActionApiKeyUpdate        AuditAction = "api_key_update"
+	AuditActionRoleUpdate          AuditAction = "role_update"
+	AuditActionWithdraw            AuditAction = "withdraw"
+	AuditActionRecycle             AuditAction = "recycle"
+	AuditActionSuspensionUpdate    AuditAction = "suspension_update"
+	AuditActionPriceRuleSchedule   AuditAction = "price_rule_schedule"
+)
+
+// the role of the actor besides AccountRole
+const (
+	AuditRoleOwner    = "owner"
+	AuditRoleManager  = "manager"
+	AuditRoleApiKey   = "api_key"
+	AuditRoleInternal = "internal"
+)
+
+// TableAuditLog append-only, the rows are never updated or deleted
+type TableAuditLog struct {
+	Id              uint64      `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	ParentAccountId string      `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string      `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	Actor           string      `json:"actor" gorm:"column:actor; index:k_actor; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'address or api key id';"`
+	Role            string      `json:"role" gorm:"column:role; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'owner, manager, roles, api_key, internal';"`
+	Action          AuditAction `json:"action" gorm:"column:action; index:k_action; type:varchar(64) NOT NULL DEFAULT '' COMMENT '';"`
+	Before          string      `json:"before" gorm:"column:before; type:mediumtext NOT NULL COMMENT 'json';"`
+	After           string      `json:"after" gorm:"column:after; type:mediumtext NOT NULL COMMENT 'json';"`
+	RequestId       string      `json:"request_id" gorm:"column:request_id; type:varchar(64) NOT NULL DEFAULT '' COMMENT '';"`
+	TxHash          string      `json:"tx_hash" gorm:"column:tx_hash; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	CreatedAt       time.Time   `json:"created_at" gorm:"column:created_at; index:k_created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameAuditLog = "t_audit_log"
+)
+
+func (t *TableAuditLog) TableName() string {
+	return TableNameAuditLog
+}
@@ -0,0 +1,74 @@
+package tables
+
+import (
+	"crypto/md5"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"time"
+)
+
+type BulkMintJobStatus int
+
+const (
+	BulkMintJobStatusQueued   BulkMintJobStatus = 0
+	BulkMintJobStatusMinting  BulkMintJobStatus = 1
+	BulkMintJobStatusComplete BulkMintJobStatus = 2
+)
+
+type BulkMintItemStatus int
+
+const (
+	BulkMintItemStatusInvalid BulkMintItemStatus = -1
+	BulkMintItemStatusQueued  BulkMintItemStatus = 0
+	BulkMintItemStatusSigned  BulkMintItemStatus = 1
+	BulkMintItemStatusMinted  BulkMintItemStatus = 2
+	BulkMintItemStatusFailed  BulkMintItemStatus = 3
+)
+
+type TableBulkMintJob struct {
+	Id              uint64            `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	JobId           string            `json:"job_id" gorm:"column:job_id; uniqueIndex:uk_job_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	ParentAccountId string            `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string            `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	ChainType       common.ChainType  `json:"chain_type" gorm:"column:chain_type; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '';"`
+	Address         string            `json:"address" gorm:"column:address; index:k_address; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'manager address';"`
+	Format          string            `json:"format" gorm:"column:format; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'csv,json';"`
+	Total           int               `json:"total" gorm:"column:total; type:int(11) NOT NULL DEFAULT '0' COMMENT '';"`
+	ValidNum        int               `json:"valid_num" gorm:"column:valid_num; type:int(11) NOT NULL DEFAULT '0' COMMENT '';"`
+	Status          BulkMintJobStatus `json:"status" gorm:"column:status; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-queued 1-minting 2-complete';"`
+	CreatedAt       time.Time         `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time         `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameBulkMintJob     = "t_bulk_mint_job"
+	TableNameBulkMintJobItem = "t_bulk_mint_job_item"
+)
+
+func (t *TableBulkMintJob) TableName() string {
+	return TableNameBulkMintJob
+}
+
+func (t *TableBulkMintJob) InitJobId() {
+	jobId := fmt.Sprintf("%s%d%s%d", t.ParentAccountId, t.ChainType, t.Address, time.Now().UnixNano())
+	t.JobId = fmt.Sprintf("%x", md5.Sum([]byte(jobId)))
+}
+
+type TableBulkMintJobItem struct {
+	Id            uint64             `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	JobId         string             `json:"job_id" gorm:"column:job_id; uniqueIndex:uk_job_row; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	RowIndex      int                `json:"row_index" gorm:"column:row_index; uniqueIndex:uk_job_row; type:int(11) NOT NULL DEFAULT '0' COMMENT '';"`
+	Account       string             `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'sub account';"`
+	AccountId     string             `json:"account_id" gorm:"column:account_id; index:k_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Owner         string             `json:"owner" gorm:"column:owner; type:text NOT NULL COMMENT 'owner chain type address json';"`
+	RegisterYears uint64             `json:"register_years" gorm:"column:register_years; type:int(11) NOT NULL DEFAULT '0' COMMENT '';"`
+	Status        BulkMintItemStatus `json:"status" gorm:"column:status; index:k_status; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '-1-invalid 0-queued 1-signed 2-minted 3-failed';"`
+	Message       string             `json:"message" gorm:"column:message; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	MintSignId    string             `json:"mint_sign_id" gorm:"column:mint_sign_id; index:k_mint_sign_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	CreatedAt     time.Time          `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt     time.Time          `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+func (t *TableBulkMintJobItem) TableName() string {
+	return TableNameBulkMintJobItem
+}
@@ -18,6 +18,7 @@ type CouponInfo struct {
 	Cid       string       `gorm:"index:idx_cid;column:cid;default:;NOT NULL"`
 	Code      string       `gorm:"uniqueIndex:idx_code;column:code;default:;NOT NULL"`
 	Status    CouponStatus `gorm:"column:status;default:0;NOT NULL"`
+	UsedNum   int64        `gorm:"column:used_num;default:0;NOT NULL"`
 	CreatedAt time.Time    `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
 	UpdatedAt time.Time    `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
 }
@@ -1,12 +1,25 @@
 package tables
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
 	"github.com/google/uuid"
 	"github.com/shopspring/decimal"
 	"strings"
 	"time"
 )
 
+type CouponType int
+
+const (
+	CouponTypeWaive   CouponType = 0 // the order is free if the price is not more than Price, else Price off
+	CouponTypePercent CouponType = 1 // Discount of the price off, at most Price off
+	CouponTypeFixed   CouponType = 2 // Price off of each year
+	CouponTypeFloor   CouponType = 3 // the price of each year is reduced to Price
+)
+
 const (
 	CouponSetInfoStatusCreated = 0
 	CouponSetInfoStatusPaid    = 1
@@ -15,23 +28,28 @@ const (
 )
 
 type CouponSetInfo struct {
-	Id            int64           `gorm:"column:id;primary_key;AUTO_INCREMENT;NOT NULL"`
-	Cid           string          `gorm:"uniqueIndex:idx_cid;column:cid;default:;NOT NULL"`
-	OrderId       string          `gorm:"index:idx_order_id;column:order_id;default:;NOT NULL"`
-	AccountId     string          `gorm:"index:idx_acc_id;column:account_id;default:;NOT NULL"`
-	Account       string          `gorm:"column:account;default:;NOT NULL"`
-	ManagerAid    int             `gorm:"column:manager_aid;default:0;NOT NULL"`
-	ManagerSubAid int             `gorm:"column:manager_sub_aid;default:0;NOT NULL"`
-	Manager       string          `gorm:"index:idx_manager;column:manager;default:;NOT NULL"`
-	Name          string          `gorm:"column:name;default:;NOT NULL"`
-	Note          string          `gorm:"column:note;default:;NOT NULL"`
-	Price         decimal.Decimal `gorm:"price:amount; type:decimal(50,10) NOT NULL DEFAULT '0' COMMENT '';"`
-	Num           int64           `gorm:"column:num;default:0;NOT NULL"`
-	BeginAt       int64           `gorm:"column:begin_at;default:0;NOT NULL"`
-	ExpiredAt     int64           `gorm:"column:expired_at;default:0;NOT NULL"`
-	Status        int             `gorm:"column:status;default:0;NOT NULL"`
-	CreatedAt     time.Time       `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
-	UpdatedAt     time.Time       `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+	Id            int64              `gorm:"column:id;primary_key;AUTO_INCREMENT;NOT NULL"`
+	Cid           string             `gorm:"uniqueIndex:idx_cid;column:cid;default:;NOT NULL"`
+	OrderId       string             `gorm:"index:idx_order_id;column:order_id;default:;NOT NULL"`
+	AccountId     string             `gorm:"index:idx_acc_id;column:account_id;default:;NOT NULL"`
+	Account       string             `gorm:"column:account;default:;NOT NULL"`
+	ManagerAid    int                `gorm:"column:manager_aid;default:0;NOT NULL"`
+	ManagerSubAid int                `gorm:"column:manager_sub_aid;default:0;NOT NULL"`
+	Manager       string             `gorm:"index:idx_manager;column:manager;default:;NOT NULL"`
+	Name          string             `gorm:"column:name;default:;NOT NULL"`
+	Note          string             `gorm:"column:note;default:;NOT NULL"`
+	Price         decimal.Decimal    `gorm:"price:amount; type:decimal(50,10) NOT NULL DEFAULT '0' COMMENT '';"`
+	Num           int64              `gorm:"column:num;default:0;NOT NULL"`
+	Type          CouponType         `gorm:"column:type;default:0;NOT NULL"`
+	Discount      decimal.Decimal    `gorm:"column:discount; type:decimal(10,4) NOT NULL DEFAULT '0' COMMENT '0.2 is 20% off';"`
+	MaxRedemption int64              `gorm:"column:max_redemption;default:0;NOT NULL"`  // redemptions of each code, 0 is 1
+	MaxPerAddress int64              `gorm:"column:max_per_address;default:0;NOT NULL"` // redemptions of each code by an address, 0 is no limit
+	Restriction   *CouponRestriction `gorm:"column:restriction;type:mediumtext"`        // up to 10000 names
+	BeginAt       int64              `gorm:"column:begin_at;default:0;NOT NULL"`
+	ExpiredAt     int64              `gorm:"column:expired_at;default:0;NOT NULL"`
+	Status        int                `gorm:"column:status;default:0;NOT NULL"`
+	CreatedAt     time.Time          `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt     time.Time          `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
 }
 
 func (t *CouponSetInfo) TableName() string {
@@ -42,3 +60,137 @@ func (t *CouponSetInfo) InitCid() {
 	uid, _ := uuid.NewUUID()
 	t.Cid = strings.ReplaceAll(uid.String(), "-", "")
 }
+
+func (t *CouponSetInfo) GetMaxRedemption() int64 {
+	if t.MaxRedemption <= 0 {
+		return 1
+	}
+	return t.MaxRedemption
+}
+
+// GetActualPrice the usd price of the order of years after the coupon is redeemed
+func (t *CouponSetInfo) GetActualPrice(usdAmount decimal.Decimal, years uint64) decimal.Decimal {
+	off := t.Price
+	switch t.Type {
+	case CouponTypePercent:
+		off = decimal.Min(usdAmount.Mul(t.Discount).Round(2), t.Price)
+	case CouponTypeFixed:
+		off = t.Price.Mul(decimal.NewFromInt(int64(years)))
+	case CouponTypeFloor:
+		off = usdAmount.Sub(t.Price.Mul(decimal.NewFromInt(int64(years))))
+	}
+	if off.LessThanOrEqual(decimal.Zero) {
+		return usdAmount
+	}
+	if off.GreaterThanOrEqual(usdAmount) {
+		return decimal.Zero
+	}
+	return usdAmount.Sub(off)
+}
+
+// GetBillingNum the number of the redemption-years paid by the owner with the coupon price,
+// the years of a redemption are the max years of the restriction, 1 if not restricted
+func (t *CouponSetInfo) GetBillingNum() decimal.Decimal {
+	years := int64(1)
+	if t.Restriction != nil && t.Restriction.MaxYears > 0 {
+		years = int64(t.Restriction.MaxYears)
+	}
+	return decimal.NewFromInt(t.Num * t.GetMaxRedemption() * years)
+}
+
+// CouponRestriction the coupon only applies to the orders matching all the restrictions, the zero values are not restricted
+type CouponRestriction struct {
+	MinLength   uint64       `json:"min_length"`
+	MaxLength   uint64       `json:"max_length"`
+	PriceRules  []string     `json:"price_rules"` // names of the price rules
+	ActionTypes []ActionType `json:"action_types"`
+	MaxYears    uint64       `json:"max_years"`
+	Names       []string     `json:"names"` // the sub-accounts without the parent account
+}
+
+func (r *CouponRestriction) IsEmpty() bool {
+	return r == nil || (r.MinLength == 0 && r.MaxLength == 0 && len(r.PriceRules) == 0 &&
+		len(r.ActionTypes) == 0 && r.MaxYears == 0 && len(r.Names) == 0)
+}
+
+// Check returns why the coupon does not apply to the order, empty if it applies.
+// ruleName is the price rule hit by the sub-account, only used when PriceRules is set
+func (r *CouponRestriction) Check(subAccount string, actionType ActionType, years uint64, ruleName string) string {
+	if r.IsEmpty() {
+		return ""
+	}
+	if len(r.ActionTypes) > 0 {
+		find := false
+		for _, v := range r.ActionTypes {
+			if v == actionType {
+				find = true
+				break
+			}
+		}
+		if !find {
+			if actionType == ActionTypeRenew {
+				return "this coupon code can only be used to mint"
+			}
+			return "this coupon code can only be used to renew"
+		}
+	}
+	if r.MaxYears > 0 && years > r.MaxYears {
+		return fmt.Sprintf("this coupon code can only be used for orders of no more than %d years", r.MaxYears)
+	}
+	name := strings.Split(subAccount, ".")[0]
+	if r.MinLength > 0 || r.MaxLength > 0 {
+		_, accLen, _ := common.GetDotBitAccountLength(subAccount)
+		if r.MinLength > 0 && uint64(accLen) < r.MinLength {
+			return fmt.Sprintf("this coupon code can only be used for names of at least %d characters", r.MinLength)
+		}
+		if r.MaxLength > 0 && uint64(accLen) > r.MaxLength {
+			return fmt.Sprintf("this coupon code can only be used for names of no more than %d characters", r.MaxLength)
+		}
+	}
+	if len(r.Names) > 0 {
+		find := false
+		for _, v := range r.Names {
+			if strings.EqualFold(v, name) {
+				find = true
+				break
+			}
+		}
+		if !find {
+			return fmt.Sprintf("this coupon code can not be used for %s", name)
+		}
+	}
+	if len(r.PriceRules) > 0 {
+		find := false
+		for _, v := range r.PriceRules {
+			if ruleName != "" && v == ruleName {
+				find = true
+				break
+			}
+		}
+		if !find {
+			return fmt.Sprintf("this coupon code can only be used for names of the price rules: %s", strings.Join(r.PriceRules, ", "))
+		}
+	}
+	return ""
+}
+
+func (r *CouponRestriction) Value() (driver.Value, error) {
+	if r.IsEmpty() {
+		return nil, nil
+	}
+	marshal, _ := json.Marshal(r)
+	return marshal, nil
+}
+
+func (r *CouponRestriction) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	}
+	return nil
+}
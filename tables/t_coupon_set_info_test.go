@@ -0,0 +1,117 @@
+package tables
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func TestGetActualPrice(t *testing.T) {
+	tests := []struct {
+		name      string
+		coupon    CouponSetInfo
+		usdAmount float64
+		years     uint64
+		want      string
+	}{
+		{"waive free", CouponSetInfo{Type: CouponTypeWaive, Price: decimal.NewFromInt(10)}, 8, 1, "0"},
+		{"waive price off", CouponSetInfo{Type: CouponTypeWaive, Price: decimal.NewFromInt(10)}, 25, 2, "15"},
+		{"percent", CouponSetInfo{Type: CouponTypePercent, Price: decimal.NewFromInt(10), Discount: decimal.NewFromFloat(0.2)}, 30, 3, "24"},
+		{"percent at most price off", CouponSetInfo{Type: CouponTypePercent, Price: decimal.NewFromInt(5), Discount: decimal.NewFromFloat(0.5)}, 30, 3, "25"},
+		{"percent rounded", CouponSetInfo{Type: CouponTypePercent, Price: decimal.NewFromInt(10), Discount: decimal.NewFromFloat(0.3)}, 9.99, 1, "6.99"},
+		{"fixed of each year", CouponSetInfo{Type: CouponTypeFixed, Price: decimal.NewFromInt(2)}, 30, 3, "24"},
+		{"fixed free", CouponSetInfo{Type: CouponTypeFixed, Price: decimal.NewFromInt(12)}, 30, 3, "0"},
+		{"floor of each year", CouponSetInfo{Type: CouponTypeFloor, Price: decimal.NewFromInt(4)}, 30, 3, "12"},
+		{"floor above the price", CouponSetInfo{Type: CouponTypeFloor, Price: decimal.NewFromInt(12)}, 30, 3, "30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coupon.GetActualPrice(decimal.NewFromFloat(tt.usdAmount), tt.years); !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Fatal(got)
+			}
+		})
+	}
+}
+
+func TestGetBillingNum(t *testing.T) {
+	tests := []struct {
+		name   string
+		coupon CouponSetInfo
+		want   int64
+	}{
+		{"one redemption of one year", CouponSetInfo{Num: 10}, 10},
+		{"redemptions", CouponSetInfo{Num: 10, MaxRedemption: 5}, 50},
+		{"not restricted years", CouponSetInfo{Num: 10, MaxRedemption: 5, Restriction: &CouponRestriction{MinLength: 4}}, 50},
+		{"max years", CouponSetInfo{Num: 10, MaxRedemption: 5, Restriction: &CouponRestriction{MaxYears: 3}}, 150},
+		{"percent is not discounted", CouponSetInfo{Num: 10, Type: CouponTypePercent, Discount: decimal.NewFromFloat(0.2)}, 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coupon.GetBillingNum(); !got.Equal(decimal.NewFromInt(tt.want)) {
+				t.Fatal(got)
+			}
+		})
+	}
+}
+
+func TestCouponRestrictionCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		restriction *CouponRestriction
+		subAccount  string
+		actionType  ActionType
+		years       uint64
+		ruleName    string
+		wantOk      bool
+	}{
+		{"nil", nil, "abc.test.bit", ActionTypeMint, 10, "", true},
+		{"empty", &CouponRestriction{}, "abc.test.bit", ActionTypeRenew, 10, "", true},
+		{"mint only", &CouponRestriction{ActionTypes: []ActionType{ActionTypeMint}}, "abc.test.bit", ActionTypeRenew, 1, "", false},
+		{"renew only", &CouponRestriction{ActionTypes: []ActionType{ActionTypeRenew}}, "abc.test.bit", ActionTypeRenew, 1, "", true},
+		{"max years", &CouponRestriction{MaxYears: 2}, "abc.test.bit", ActionTypeMint, 2, "", true},
+		{"over max years", &CouponRestriction{MaxYears: 2}, "abc.test.bit", ActionTypeMint, 3, "", false},
+		{"min length", &CouponRestriction{MinLength: 4}, "abc.test.bit", ActionTypeMint, 1, "", false},
+		{"max length", &CouponRestriction{MaxLength: 4}, "abcde.test.bit", ActionTypeMint, 1, "", false},
+		{"length between", &CouponRestriction{MinLength: 3, MaxLength: 5}, "abcde.test.bit", ActionTypeMint, 1, "", true},
+		{"in names", &CouponRestriction{Names: []string{"Bob", "abc"}}, "bob.test.bit", ActionTypeMint, 1, "", true},
+		{"not in names", &CouponRestriction{Names: []string{"bob"}}, "bobby.test.bit", ActionTypeMint, 1, "", false},
+		{"price rule", &CouponRestriction{PriceRules: []string{"vip"}}, "abc.test.bit", ActionTypeMint, 1, "vip", true},
+		{"other price rule", &CouponRestriction{PriceRules: []string{"vip"}}, "abc.test.bit", ActionTypeMint, 1, "normal", false},
+		{"no price rule hit", &CouponRestriction{PriceRules: []string{"vip"}}, "abc.test.bit", ActionTypeMint, 1, "", false},
+		{"all matched", &CouponRestriction{MinLength: 3, MaxYears: 1, ActionTypes: []ActionType{ActionTypeMint}, Names: []string{"abc"}, PriceRules: []string{"vip"}},
+			"abc.test.bit", ActionTypeMint, 1, "vip", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if reason := tt.restriction.Check(tt.subAccount, tt.actionType, tt.years, tt.ruleName); (reason == "") != tt.wantOk {
+				t.Fatal(reason)
+			}
+		})
+	}
+}
+
+func TestCouponRestrictionValue(t *testing.T) {
+	var empty *CouponRestriction
+	if v, err := empty.Value(); err != nil || v != nil {
+		t.Fatal(v, err)
+	}
+	names := make([]string, 10000)
+	for i := range names {
+		names[i] = "abcdefghijklmnopqrstuvwxyz0123456789"
+	}
+	restriction := CouponRestriction{MaxYears: 1, Names: names}
+	v, err := restriction.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// more than text, fits in mediumtext
+	if size := len(v.([]byte)); size <= 1<<16-1 || size > 1<<24-1 {
+		t.Fatal(size)
+	}
+	var scanned CouponRestriction
+	if err := scanned.Scan(v); err != nil {
+		t.Fatal(err)
+	}
+	if scanned.MaxYears != 1 || len(scanned.Names) != len(names) {
+		t.Fatal(scanned.MaxYears, len(scanned.Names))
+	}
+}
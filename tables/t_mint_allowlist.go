@@ -0,0 +1,26 @@
+package tables
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"time"
+)
+
+// TableMintAllowlist the addresses allowed to mint in the presale window of the parent account
+type TableMintAllowlist struct {
+	Id              uint64           `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	ParentAccountId string           `json:"parent_account_id" gorm:"column:parent_account_id; uniqueIndex:uk_parent_address; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string           `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	ChainType       common.ChainType `json:"chain_type" gorm:"column:chain_type; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '';"`
+	Address         string           `json:"address" gorm:"column:address; uniqueIndex:uk_parent_address; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Quota           uint64           `json:"quota" gorm:"column:quota; type:int(11) unsigned NOT NULL DEFAULT '0' COMMENT 'max mint num in the presale, 0 means no limit';"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time        `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameMintAllowlist = "t_mint_allowlist"
+)
+
+func (t *TableMintAllowlist) TableName() string {
+	return TableNameMintAllowlist
+}
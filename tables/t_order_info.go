@@ -43,8 +43,14 @@ type OrderInfo struct {
 	PayAddress        string                `json:"pay_address" gorm:"column:pay_address; index:idx_pay_address; type:varchar(255) NOT NULL DEFAULT'' COMMENT '';"`
 	TokenId           string                `json:"token_id" gorm:"column:token_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
 	CouponCode        string                `json:"coupon_code" gorm:"column:coupon_code; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	QuoteId           string                `json:"quote_id" gorm:"column:quote_id; index:k_quote_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'the locked price quote';"`
+	ReferralCode      string                `json:"referral_code" gorm:"column:referral_code; type:varchar(64) NOT NULL DEFAULT '' COMMENT '';"`
+	ReferralShare     decimal.Decimal       `json:"referral_share" gorm:"column:referral_share; type:decimal(10,4) NOT NULL DEFAULT '0' COMMENT 'share of the referrer when ordered';"`
+	Referrer          string                `json:"referrer" gorm:"column:referrer; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'referrer when ordered';"`
+	ReferrerId        string                `json:"referrer_id" gorm:"column:referrer_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
 	Amount            decimal.Decimal       `json:"amount" gorm:"column:amount; type:decimal(60,0) NOT NULL DEFAULT '0' COMMENT '';"`
 	USDAmount         decimal.Decimal       `json:"usd_amount" gorm:"column:usd_amount; type:decimal(50,10) NOT NULL DEFAULT '0' COMMENT '';"`
+	Discount          decimal.Decimal       `json:"discount" gorm:"column:discount; type:decimal(10,4) NOT NULL DEFAULT '0' COMMENT 'multi-year discount when ordered';"`
 	PayStatus         PayStatus             `json:"pay_status" gorm:"column:pay_status; type:smallint(6) NOT NULL DEFAULT'0' COMMENT '0-unpaid 1-paid';"`
 	OrderStatus       OrderStatus           `json:"order_status" gorm:"column:order_status; type:smallint(6) NOT NULL DEFAULT'0' COMMENT '0-default 1-cancel';"`
 	Timestamp         int64                 `json:"timestamp" gorm:"column:timestamp; index:idx_timestamp; type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '';"`
@@ -62,6 +68,12 @@ func (t *OrderInfo) TableName() string {
 	return "t_order_info"
 }
 
+// GetUsdAmountAfterMinFee the usd income of the owner after the min fee of every year,
+// USDAmount is the total of the years, after the discount if discounted
+func (t *OrderInfo) GetUsdAmountAfterMinFee(minPriceFee decimal.Decimal) decimal.Decimal {
+	return t.USDAmount.Sub(minPriceFee.Mul(decimal.NewFromInt(int64(t.Years))))
+}
+
 func GetEfficientOrderTimestamp() int64 {
 	return time.Now().Add(-time.Hour * 24 * 3).UnixMilli()
 }
@@ -0,0 +1,35 @@
+package tables
+
+import (
+	"github.com/shopspring/decimal"
+	"testing"
+)
+
+func TestGetUsdAmountAfterMinFee(t *testing.T) {
+	minPriceFee := decimal.NewFromFloat(0.2)
+	tests := []struct {
+		name      string
+		usdAmount float64 // the total of the years, as stored by auto/order/create
+		years     uint64
+		discount  float64
+		want      string
+	}{
+		{"one year", 5, 1, 0, "4.8"},
+		{"the total of the years without discount", 15, 3, 0, "14.4"},
+		{"the total of the years after discount", 13.5, 3, 0.1, "12.9"},
+		{"the same total is paid the same", 13.5, 3, 0, "12.9"},
+		{"below the min fee", 0.5, 3, 0, "-0.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := OrderInfo{
+				USDAmount: decimal.NewFromFloat(tt.usdAmount),
+				Years:     tt.years,
+				Discount:  decimal.NewFromFloat(tt.discount),
+			}
+			if got := order.GetUsdAmountAfterMinFee(minPriceFee); !got.Equal(decimal.RequireFromString(tt.want)) {
+				t.Fatal(got)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package tables
+
+import (
+	"encoding/json"
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+// TablePriceQuote the price of the sub-account and the token prices locked by auto/account/search
+type TablePriceQuote struct {
+	Id              uint64          `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	QuoteId         string          `json:"quote_id" gorm:"column:quote_id; uniqueIndex:uk_quote_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	ParentAccountId string          `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	SubAccount      string          `json:"sub_account" gorm:"column:sub_account; index:k_sub_account; type:varchar(255) CHARACTER SET utf8mb4 COLLATE utf8mb4_0900_ai_ci NOT NULL DEFAULT '' COMMENT '';"`
+	ActionType      ActionType      `json:"action_type" gorm:"column:action_type; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-mint 1-renew';"`
+	Address         string          `json:"address" gorm:"column:address; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'who searched';"`
+	Price           decimal.Decimal `json:"price" gorm:"column:price; type:decimal(50,10) NOT NULL DEFAULT '0' COMMENT 'USD of one year';"`
+	TokenAmounts    string          `json:"token_amounts" gorm:"column:token_amounts; type:text NOT NULL COMMENT 'json of PriceQuoteToken';"`
+	ExpiredAt       int64           `json:"expired_at" gorm:"column:expired_at; index:k_expired_at; type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'ms';"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+type PriceQuoteToken struct {
+	TokenId  TokenId         `json:"token_id"`
+	Symbol   string          `json:"symbol"`
+	Decimals int32           `json:"decimals"`
+	Price    decimal.Decimal `json:"price"`  // USD of one token
+	Amount   decimal.Decimal `json:"amount"` // of one year
+}
+
+const (
+	TableNamePriceQuote = "t_price_quote"
+)
+
+func (t *TablePriceQuote) TableName() string {
+	return TableNamePriceQuote
+}
+
+func (t *TablePriceQuote) IsExpired() bool {
+	return time.Now().UnixMilli() > t.ExpiredAt
+}
+
+func (t *TablePriceQuote) GetTokenAmounts() (list []PriceQuoteToken, err error) {
+	err = json.Unmarshal([]byte(t.TokenAmounts), &list)
+	return
+}
@@ -0,0 +1,56 @@
+package tables
+
+import (
+	"github.com/shopspring/decimal"
+	"time"
+)
+
+type ReferralStatus int
+
+const (
+	ReferralStatusNormal   ReferralStatus = 0
+	ReferralStatusDisabled ReferralStatus = 1
+)
+
+// TableReferral the referral codes of the parent account, the referrer gets the share of the income of the owner
+type TableReferral struct {
+	Id              uint64          `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	ParentAccountId string          `json:"parent_account_id" gorm:"column:parent_account_id; uniqueIndex:uk_parent_code; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string          `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	Code            string          `json:"code" gorm:"column:code; uniqueIndex:uk_parent_code; type:varchar(64) NOT NULL DEFAULT '' COMMENT '';"`
+	Referrer        string          `json:"referrer" gorm:"column:referrer; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'account of the referrer';"`
+	ReferrerId      string          `json:"referrer_id" gorm:"column:referrer_id; index:k_referrer_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Share           decimal.Decimal `json:"share" gorm:"column:share; type:decimal(10,4) NOT NULL DEFAULT '0' COMMENT '0.1 is 10% of the income of the owner';"`
+	Status          ReferralStatus  `json:"status" gorm:"column:status; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-normal 1-disabled';"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+// TableReferralPayment the payouts to the referrers, paid with the payment of the owner of auto_payment_id
+type TableReferralPayment struct {
+	Id              uint64          `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	AutoPaymentId   string          `json:"auto_payment_id" gorm:"column:auto_payment_id; index:k_auto_payment_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'payment of the owner';"`
+	ParentAccountId string          `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string          `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	Referrer        string          `json:"referrer" gorm:"column:referrer; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	ReferrerId      string          `json:"referrer_id" gorm:"column:referrer_id; index:k_referrer_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	TokenId         string          `json:"token_id" gorm:"column:token_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Amount          decimal.Decimal `json:"amount" gorm:"column:amount; type:decimal(60,2) NOT NULL DEFAULT '0' COMMENT '';"`
+	Address         string          `json:"address" gorm:"column:address; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	PaymentDate     time.Time       `json:"payment_date" gorm:"column:payment_date; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	CreatedAt       time.Time       `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time       `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameReferral        = "t_referral"
+	TableNameReferralPayment = "t_referral_payment"
+)
+
+func (t *TableReferral) TableName() string {
+	return TableNameReferral
+}
+
+func (t *TableReferralPayment) TableName() string {
+	return TableNameReferralPayment
+}
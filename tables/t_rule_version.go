@@ -0,0 +1,42 @@
+package tables
+
+import (
+	"encoding/json"
+	"github.com/dotbitHQ/das-lib/witness"
+	"time"
+)
+
+// TableRuleVersion every confirmed rule set of the parent account, saved by the parser if the rule hash changed
+type TableRuleVersion struct {
+	Id              uint64    `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	ParentAccountId string    `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id_rule_type; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string    `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	RuleType        RuleType  `json:"rule_type" gorm:"column:rule_type; uniqueIndex:uk_tx_hash_rule_type; index:k_parent_account_id_rule_type; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'price_rules, preserved_rules';"`
+	TxHash          string    `json:"tx_hash" gorm:"column:tx_hash; uniqueIndex:uk_tx_hash_rule_type; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	BlockNumber     uint64    `json:"block_number" gorm:"column:block_number; type:bigint(20) unsigned NOT NULL DEFAULT '0' COMMENT '';"`
+	BlockTimestamp  int64     `json:"block_timestamp" gorm:"column:block_timestamp; type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'ms';"`
+	RuleHash        string    `json:"rule_hash" gorm:"column:rule_hash; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'of the sub_account cell';"`
+	RuleNum         int       `json:"rule_num" gorm:"column:rule_num; type:int(11) NOT NULL DEFAULT '0' COMMENT '';"`
+	Rules           string    `json:"rules" gorm:"column:rules; type:mediumtext NOT NULL COMMENT 'json of the rules, the price is in USD and in_list is of the sub-account names';"`
+	UndecodedNum    int       `json:"undecoded_num" gorm:"column:undecoded_num; type:int(11) NOT NULL DEFAULT '0' COMMENT 'the accounts of in_list not found by the parser, left out of rules';"`
+	CreatedAt       time.Time `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameRuleVersion = "t_rule_version"
+)
+
+func (t *TableRuleVersion) TableName() string {
+	return TableNameRuleVersion
+}
+
+func (t *TableRuleVersion) GetRules() (list witness.SubAccountRuleSlice, err error) {
+	err = json.Unmarshal([]byte(t.Rules), &list)
+	return
+}
+
+// IsRollbackable the rules are incomplete if some accounts of in_list can't be decoded to the names
+func (t *TableRuleVersion) IsRollbackable() bool {
+	return t.UndecodedNum == 0
+}
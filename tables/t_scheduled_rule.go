@@ -0,0 +1,42 @@
+package tables
+
+import (
+	"github.com/dotbitHQ/das-lib/common"
+	"time"
+)
+
+type ScheduledRuleStatus int
+
+const (
+	ScheduledRuleStatusPending   ScheduledRuleStatus = 0
+	ScheduledRuleStatusSent      ScheduledRuleStatus = 1
+	ScheduledRuleStatusFailed    ScheduledRuleStatus = 2
+	ScheduledRuleStatusCancelled ScheduledRuleStatus = 3
+	// the config tx is sent and not committed yet
+	ScheduledRuleStatusConfirming ScheduledRuleStatus = 4
+)
+
+// TableScheduledRule the price rules authorized by the owner or the manager, sent on chain at activate_at
+type TableScheduledRule struct {
+	Id              uint64              `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	ParentAccountId string              `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string              `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	Rules           string              `json:"rules" gorm:"column:rules; type:mediumtext NOT NULL COMMENT 'json of the price rules, the price is in USD';"`
+	ActivateAt      int64               `json:"activate_at" gorm:"column:activate_at; index:k_status_activate_at; type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'ms';"`
+	ChainType       common.ChainType    `json:"chain_type" gorm:"column:chain_type; type:smallint(6) NOT NULL DEFAULT '0' COMMENT 'of the authorizer';"`
+	Address         string              `json:"address" gorm:"column:address; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'the authorizer';"`
+	Signature       string              `json:"-" gorm:"column:signature; type:varchar(2048) NOT NULL DEFAULT '' COMMENT 'of the authorization';"`
+	Status          ScheduledRuleStatus `json:"status" gorm:"column:status; index:k_status_activate_at; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-pending 1-sent 2-failed 3-cancelled 4-confirming';"`
+	TxHash          string              `json:"tx_hash" gorm:"column:tx_hash; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Reason          string              `json:"reason" gorm:"column:reason; type:varchar(1024) NOT NULL DEFAULT '' COMMENT 'why failed';"`
+	CreatedAt       time.Time           `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time           `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameScheduledRule = "t_scheduled_rule"
+)
+
+func (t *TableScheduledRule) TableName() string {
+	return TableNameScheduledRule
+}
@@ -0,0 +1,93 @@
+package tables
+
+import (
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"time"
+)
+
+type SuspensionScope string
+
+const (
+	SuspensionScopeMint  SuspensionScope = "mint"
+	SuspensionScopeEdit  SuspensionScope = "edit"
+	SuspensionScopeRenew SuspensionScope = "renew"
+	SuspensionScopeAll   SuspensionScope = "all"
+)
+
+func IsSuspensionScope(scope SuspensionScope) bool {
+	switch scope {
+	case SuspensionScopeMint, SuspensionScopeEdit, SuspensionScopeRenew, SuspensionScopeAll:
+		return true
+	}
+	return false
+}
+
+func GetSuspensionScopeByActionType(actionType ActionType) SuspensionScope {
+	if actionType == ActionTypeRenew {
+		return SuspensionScopeRenew
+	}
+	return SuspensionScopeMint
+}
+
+// GetSuspensionScopeBySubAction the recycle is only suspended by scope all
+func GetSuspensionScopeBySubAction(subAction common.SubAction) SuspensionScope {
+	switch subAction {
+	case common.SubActionCreate:
+		return SuspensionScopeMint
+	case common.SubActionEdit:
+		return SuspensionScopeEdit
+	case common.SubActionRenew:
+		return SuspensionScopeRenew
+	}
+	return ""
+}
+
+type SuspensionStatus int
+
+const (
+	SuspensionStatusNormal SuspensionStatus = 0
+	SuspensionStatusLifted SuspensionStatus = 1
+)
+
+// SuspensionOperatorConfig the operator of the suspensions seeded from the deprecated suspend_map
+const SuspensionOperatorConfig = "config"
+
+// TableSuspension the operations of the scope of the parent account are suspended in [start_at, end_at), end_at 0 means no end
+type TableSuspension struct {
+	Id              uint64           `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	ParentAccountId string           `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string           `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	Scope           SuspensionScope  `json:"scope" gorm:"column:scope; type:varchar(64) NOT NULL DEFAULT '' COMMENT 'mint, edit, renew, all';"`
+	Reason          string           `json:"reason" gorm:"column:reason; type:varchar(1024) NOT NULL DEFAULT '' COMMENT '';"`
+	StartAt         int64            `json:"start_at" gorm:"column:start_at; type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'ms';"`
+	EndAt           int64            `json:"end_at" gorm:"column:end_at; type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'ms, 0 means no end';"`
+	Operator        string           `json:"operator" gorm:"column:operator; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Status          SuspensionStatus `json:"status" gorm:"column:status; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-normal 1-lifted';"`
+	LiftedBy        string           `json:"lifted_by" gorm:"column:lifted_by; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	CreatedAt       time.Time        `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time        `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameSuspension = "t_suspension"
+)
+
+func (t *TableSuspension) TableName() string {
+	return TableNameSuspension
+}
+
+// Message the reason shown to the users
+func (t *TableSuspension) Message() string {
+	msg := fmt.Sprintf("%s of %s is suspended", t.Scope, t.Account)
+	if t.Scope == SuspensionScopeAll {
+		msg = fmt.Sprintf("%s is suspended", t.Account)
+	}
+	if t.Reason != "" {
+		msg += ": " + t.Reason
+	}
+	if t.EndAt > 0 {
+		msg += ", until " + time.UnixMilli(t.EndAt).UTC().Format("2006-01-02 15:04:05 UTC")
+	}
+	return msg
+}
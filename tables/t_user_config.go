@@ -8,13 +8,16 @@ import (
 )
 
 type UserConfig struct {
-	Id            int64          `gorm:"column:id;AUTO_INCREMENT" json:"id"`
-	Account       string         `gorm:"column:account;type:varchar(255);comment:父账号;NOT NULL" json:"account"`
-	AccountId     string         `gorm:"column:account_id; uniqueIndex:uk_account_id;type:varchar(255);comment:父账号id;NOT NULL" json:"account_id"`
-	MintConfig    *MintConfig    `gorm:"column:mint_config;type:text;comment:mint设置内容" json:"mint_config"`
-	PaymentConfig *PaymentConfig `gorm:"column:payment_config;type:text;comment:用户收款配置" json:"payment_config"`
-	CreatedAt     time.Time      `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP;NOT NULL" json:"created_at"`
-	UpdatedAt     time.Time      `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP;NOT NULL" json:"updated_at"`
+	Id             int64           `gorm:"column:id;AUTO_INCREMENT" json:"id"`
+	Account        string          `gorm:"column:account;type:varchar(255);comment:父账号;NOT NULL" json:"account"`
+	AccountId      string          `gorm:"column:account_id; uniqueIndex:uk_account_id;type:varchar(255);comment:父账号id;NOT NULL" json:"account_id"`
+	MintConfig     *MintConfig     `gorm:"column:mint_config;type:text;comment:mint设置内容" json:"mint_config"`
+	PaymentConfig  *PaymentConfig  `gorm:"column:payment_config;type:text;comment:用户收款配置" json:"payment_config"`
+	DiscountConfig *DiscountConfig `gorm:"column:discount_config;type:text;comment:多年折扣配置" json:"discount_config"`
+	PresaleConfig  *PresaleConfig  `gorm:"column:presale_config;type:text;comment:预售窗口配置" json:"presale_config"`
+	MintLimit      *MintLimit      `gorm:"column:mint_limit;type:text;comment:每个地址的mint限制" json:"mint_limit"`
+	CreatedAt      time.Time       `gorm:"column:created_at;type:timestamp;default:CURRENT_TIMESTAMP;NOT NULL" json:"created_at"`
+	UpdatedAt      time.Time       `gorm:"column:updated_at;type:timestamp;default:CURRENT_TIMESTAMP;NOT NULL" json:"updated_at"`
 }
 
 type MintConfig struct {
@@ -47,6 +50,86 @@ type PaymentConfigElement struct {
 	Decimals   int32           `json:"decimals"`
 }
 
+// DiscountConfig the off-chain discounts of the orders by years
+type DiscountConfig struct {
+	Tiers []DiscountTier `json:"tiers"`
+}
+
+type DiscountTier struct {
+	Years    uint64          `json:"years"`
+	Discount decimal.Decimal `json:"discount"` // 0.1 is 10% off
+}
+
+// GetDiscount the discount of the tier with the most years not more than years
+func (d *DiscountConfig) GetDiscount(years uint64) decimal.Decimal {
+	discount, tierYears := decimal.Zero, uint64(0)
+	if d == nil {
+		return discount
+	}
+	for _, v := range d.Tiers {
+		if v.Years <= years && v.Years > tierYears {
+			discount, tierYears = v.Discount, v.Years
+		}
+	}
+	return discount
+}
+
+type PresaleStatus string
+
+const (
+	PresaleStatusNone     PresaleStatus = ""
+	PresaleStatusUpcoming PresaleStatus = "upcoming"
+	PresaleStatusPresale  PresaleStatus = "presale"
+	PresaleStatusPublic   PresaleStatus = "public"
+)
+
+type PresaleHolderRole string
+
+const (
+	PresaleHolderRoleOwner           PresaleHolderRole = "owner"
+	PresaleHolderRoleManager         PresaleHolderRole = "manager"
+	PresaleHolderRoleSubAccountOwner PresaleHolderRole = "sub_account_owner"
+)
+
+// PresaleConfig only the addresses in t_mint_allowlist or the holders can mint in [start_at, end_at), and anyone after end_at
+type PresaleConfig struct {
+	StartAt     int64           `json:"start_at"` // ms
+	EndAt       int64           `json:"end_at"`   // ms
+	Holders     []PresaleHolder `json:"holders"`
+	HolderQuota uint64          `json:"holder_quota"` // 0 means no limit
+}
+
+// PresaleHolder the owner or manager of the account, or the owner of any sub-account of it
+type PresaleHolder struct {
+	Account string            `json:"account"`
+	Role    PresaleHolderRole `json:"role"`
+}
+
+func (p *PresaleConfig) GetStatus(now int64) PresaleStatus {
+	if p == nil || p.StartAt == 0 {
+		return PresaleStatusNone
+	}
+	if now < p.StartAt {
+		return PresaleStatusUpcoming
+	}
+	if now < p.EndAt {
+		return PresaleStatusPresale
+	}
+	return PresaleStatusPublic
+}
+
+// MintLimit the limits of the auto-mint of each address, 0 means no limit
+type MintLimit struct {
+	MaxPerOwner     uint64 `json:"max_per_owner"`     // the sub-accounts owned and being minted
+	MaxPerDay       uint64 `json:"max_per_day"`       // the orders of the pay address in 24 hours
+	ShortNameLength uint64 `json:"short_name_length"` // the names not longer than it are short names
+	MaxShortName    uint64 `json:"max_short_name"`    // the short names owned and being minted
+}
+
+func (m *MintLimit) IsEnable() bool {
+	return m != nil && (m.MaxPerOwner > 0 || m.MaxPerDay > 0 || (m.ShortNameLength > 0 && m.MaxShortName > 0))
+}
+
 func (m *UserConfig) TableName() string {
 	return "t_user_config"
 }
@@ -94,3 +177,69 @@ func (u *PaymentConfig) Scan(src interface{}) error {
 	}
 	return nil
 }
+
+func (u *DiscountConfig) Value() (driver.Value, error) {
+	if u == nil {
+		return nil, nil
+	}
+	marshal, _ := json.Marshal(u)
+	if string(marshal) == "{}" {
+		return nil, nil
+	}
+	return marshal, nil
+}
+
+func (u *DiscountConfig) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	err := json.Unmarshal(src.([]byte), u)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *PresaleConfig) Value() (driver.Value, error) {
+	if u == nil {
+		return nil, nil
+	}
+	marshal, _ := json.Marshal(u)
+	if string(marshal) == "{}" {
+		return nil, nil
+	}
+	return marshal, nil
+}
+
+func (u *PresaleConfig) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	err := json.Unmarshal(src.([]byte), u)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (u *MintLimit) Value() (driver.Value, error) {
+	if u == nil {
+		return nil, nil
+	}
+	marshal, _ := json.Marshal(u)
+	if string(marshal) == "{}" {
+		return nil, nil
+	}
+	return marshal, nil
+}
+
+func (u *MintLimit) Scan(src interface{}) error {
+	if src == nil {
+		return nil
+	}
+	err := json.Unmarshal(src.([]byte), u)
+	if err != nil {
+		return err
+	}
+	return nil
+}
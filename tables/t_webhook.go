@@ -0,0 +1,181 @@
+package tables
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type WebhookEvent string
+
+const (
+	WebhookEventPing              WebhookEvent = "ping"
+	WebhookEventSubAccountMinted  WebhookEvent = "sub_account.minted"
+	WebhookEventSubAccountRenewed WebhookEvent = "sub_account.renewed"
+	WebhookEventSubAccountEdited  WebhookEvent = "sub_account.edited"
+	WebhookEventOrderPaid         WebhookEvent = "order.paid"
+	WebhookEventOrderRefunded     WebhookEvent = "order.refunded"
+	WebhookEventCouponRedeemed    WebhookEvent = "coupon.redeemed"
+	WebhookEventPriceRuleFailed   WebhookEvent = "price_rule.schedule_failed"
+)
+
+var WebhookEventList = []WebhookEvent{
+	WebhookEventSubAccountMinted,
+	WebhookEventSubAccountRenewed,
+	WebhookEventSubAccountEdited,
+	WebhookEventOrderPaid,
+	WebhookEventOrderRefunded,
+	WebhookEventCouponRedeemed,
+	WebhookEventPriceRuleFailed,
+}
+
+func IsWebhookEvent(event string) bool {
+	for _, v := range WebhookEventList {
+		if string(v) == event {
+			return true
+		}
+	}
+	return false
+}
+
+type WebhookStatus int
+
+const (
+	WebhookStatusEnable  WebhookStatus = 0
+	WebhookStatusDisable WebhookStatus = 1
+)
+
+type TableWebhook struct {
+	Id              uint64        `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	WebhookId       string        `json:"webhook_id" gorm:"column:webhook_id; uniqueIndex:uk_webhook_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	ParentAccountId string        `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Account         string        `json:"account" gorm:"column:account; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'parent account';"`
+	Url             string        `json:"url" gorm:"column:url; type:varchar(1024) NOT NULL DEFAULT '' COMMENT '';"`
+	Secret          string        `json:"secret" gorm:"column:secret; type:varchar(255) NOT NULL DEFAULT '' COMMENT 'hmac-sha256 key';"`
+	Events          string        `json:"events" gorm:"column:events; type:varchar(1024) NOT NULL DEFAULT '' COMMENT 'comma separated';"`
+	Status          WebhookStatus `json:"status" gorm:"column:status; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-enable 1-disable';"`
+	CreatedAt       time.Time     `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time     `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+const (
+	TableNameWebhook         = "t_webhook"
+	TableNameWebhookDelivery = "t_webhook_delivery"
+)
+
+func (t *TableWebhook) TableName() string {
+	return TableNameWebhook
+}
+
+func (t *TableWebhook) InitWebhookId() {
+	t.WebhookId = fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s%s%d", t.ParentAccountId, t.Url, time.Now().UnixNano()))))
+}
+
+func (t *TableWebhook) GetEvents() []string {
+	if t.Events == "" {
+		return []string{}
+	}
+	return strings.Split(t.Events, ",")
+}
+
+type WebhookDeliveryStatus int
+
+const (
+	WebhookDeliveryStatusPending WebhookDeliveryStatus = 0
+	WebhookDeliveryStatusSuccess WebhookDeliveryStatus = 1
+	WebhookDeliveryStatusFailed  WebhookDeliveryStatus = 2
+)
+
+type TableWebhookDelivery struct {
+	Id              uint64                `json:"id" gorm:"column:id; primaryKey; type:bigint(20) unsigned NOT NULL AUTO_INCREMENT COMMENT '';"`
+	DeliveryId      string                `json:"delivery_id" gorm:"column:delivery_id; uniqueIndex:uk_delivery_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	WebhookId       string                `json:"webhook_id" gorm:"column:webhook_id; index:k_webhook_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	ParentAccountId string                `json:"parent_account_id" gorm:"column:parent_account_id; index:k_parent_account_id; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Event           WebhookEvent          `json:"event" gorm:"column:event; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	Payload         string                `json:"payload" gorm:"column:payload; type:text NOT NULL COMMENT '';"`
+	Status          WebhookDeliveryStatus `json:"status" gorm:"column:status; index:k_status_next_time; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '0-pending 1-success 2-failed';"`
+	Retry           int                   `json:"retry" gorm:"column:retry; type:smallint(6) NOT NULL DEFAULT '0' COMMENT '';"`
+	NextTime        int64                 `json:"next_time" gorm:"column:next_time; index:k_status_next_time; type:bigint(20) NOT NULL DEFAULT '0' COMMENT 'next delivery time';"`
+	ResponseCode    int                   `json:"response_code" gorm:"column:response_code; type:int(11) NOT NULL DEFAULT '0' COMMENT '';"`
+	ErrMsg          string                `json:"err_msg" gorm:"column:err_msg; type:varchar(255) NOT NULL DEFAULT '' COMMENT '';"`
+	CreatedAt       time.Time             `json:"created_at" gorm:"column:created_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '';"`
+	UpdatedAt       time.Time             `json:"updated_at" gorm:"column:updated_at; type:timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '';"`
+}
+
+func (t *TableWebhookDelivery) TableName() string {
+	return TableNameWebhookDelivery
+}
+
+type WebhookPayload struct {
+	Id        string       `json:"id"`
+	Event     WebhookEvent `json:"event"`
+	Account   string       `json:"account"`
+	Timestamp int64        `json:"timestamp"`
+	Data      interface{}  `json:"data"`
+}
+
+func NewWebhookDelivery(webhook TableWebhook, event WebhookEvent, data interface{}) (TableWebhookDelivery, error) {
+	now := time.Now()
+	delivery := TableWebhookDelivery{
+		DeliveryId:      fmt.Sprintf("%x", md5.Sum([]byte(fmt.Sprintf("%s%s%d", webhook.WebhookId, event, now.UnixNano())))),
+		WebhookId:       webhook.WebhookId,
+		ParentAccountId: webhook.ParentAccountId,
+		Event:           event,
+		Status:          WebhookDeliveryStatusPending,
+		NextTime:        now.UnixMilli(),
+	}
+	payload, err := json.Marshal(WebhookPayload{
+		Id:        delivery.DeliveryId,
+		Event:     event,
+		Account:   webhook.Account,
+		Timestamp: now.UnixMilli(),
+		Data:      data,
+	})
+	if err != nil {
+		return delivery, fmt.Errorf("json.Marshal err: %s", err.Error())
+	}
+	delivery.Payload = string(payload)
+	return delivery, nil
+}
+
+type WebhookSubAccountData struct {
+	Account       string `json:"account"`
+	AccountId     string `json:"account_id"`
+	TaskId        string `json:"task_id"`
+	Outpoint      string `json:"outpoint"`
+	BlockNumber   uint64 `json:"block_number"`
+	RegisterYears uint64 `json:"register_years,omitempty"`
+	RenewYears    uint64 `json:"renew_years,omitempty"`
+	EditKey       string `json:"edit_key,omitempty"`
+	MintType      int    `json:"mint_type"`
+	OrderId       string `json:"order_id,omitempty"`
+}
+
+type WebhookOrderData struct {
+	OrderId    string     `json:"order_id"`
+	Account    string     `json:"account"`
+	ActionType ActionType `json:"action_type"`
+	Years      uint64     `json:"years"`
+	TokenId    string     `json:"token_id"`
+	Amount     string     `json:"amount"`
+	USDAmount  string     `json:"usd_amount"`
+	CouponCode string     `json:"coupon_code,omitempty"`
+	PayHash    string     `json:"pay_hash,omitempty"`
+	RefundHash string     `json:"refund_hash,omitempty"`
+}
+
+type WebhookCouponData struct {
+	Code    string `json:"code"`
+	Cid     string `json:"cid"`
+	OrderId string `json:"order_id"`
+	Account string `json:"account"`
+}
+
+type WebhookScheduledRuleData struct {
+	Id         uint64 `json:"id"`
+	Account    string `json:"account"`
+	ActivateAt int64  `json:"activate_at"`
+	Reason     string `json:"reason"`
+}
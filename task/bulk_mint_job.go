@@ -0,0 +1,112 @@
+package task
+
+import (
+	"das_sub_account/config"
+	"das_sub_account/notify"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"time"
+)
+
+// bulk mint job item: signed -> minted/failed
+func (t *SmtTask) RunBulkMintJob() {
+	tickerBulkMint := time.NewTicker(time.Second * 30)
+	t.Wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			select {
+			case <-tickerBulkMint.C:
+				log.Debug("doBulkMintJob start ...")
+				if err := t.doBulkMintJob(); err != nil {
+					log.Error("doBulkMintJob err:", err.Error())
+					notify.SendLarkErrNotify("doBulkMintJob", err.Error())
+				}
+				log.Debug("doBulkMintJob end ...")
+			case <-t.Ctx.Done():
+				log.Debug("task doBulkMintJob done")
+				t.Wg.Done()
+				return
+			}
+		}
+	}()
+}
+
+func (t *SmtTask) doBulkMintJob() error {
+	items, err := t.DbDao.GetBulkMintJobItemsByStatus(tables.BulkMintItemStatusSigned, 2000)
+	if err != nil {
+		return fmt.Errorf("GetBulkMintJobItemsByStatus err: %s", err.Error())
+	} else if len(items) == 0 {
+		return nil
+	}
+
+	var mintSignIds []string
+	var mapMintSignId = make(map[string]struct{})
+	for _, v := range items {
+		if _, ok := mapMintSignId[v.MintSignId]; !ok {
+			mapMintSignId[v.MintSignId] = struct{}{}
+			mintSignIds = append(mintSignIds, v.MintSignId)
+		}
+	}
+	recordList, err := t.DbDao.GetSmtRecordListByMintSignIds(mintSignIds)
+	if err != nil {
+		return fmt.Errorf("GetSmtRecordListByMintSignIds err: %s", err.Error())
+	}
+	var mapRecord = make(map[string]tables.TableSmtRecordInfo)
+	var taskIds []string
+	for _, v := range recordList {
+		mapRecord[v.MintSignId+v.AccountId] = v
+		if v.TaskId != "" {
+			taskIds = append(taskIds, v.TaskId)
+		}
+	}
+	taskList, err := t.DbDao.GetTaskListByTaskIds(taskIds)
+	if err != nil {
+		return fmt.Errorf("GetTaskListByTaskIds err: %s", err.Error())
+	}
+	var mapTask = make(map[string]tables.TableTaskInfo)
+	for _, v := range taskList {
+		mapTask[v.TaskId] = v
+	}
+
+	var mintedIds, failedIds []uint64
+	var mapJobId = make(map[string]struct{})
+	for _, v := range items {
+		record, ok := mapRecord[v.MintSignId+v.AccountId]
+		if !ok {
+			continue
+		}
+		if record.RecordType == tables.RecordTypeClosed {
+			failedIds = append(failedIds, v.Id)
+			mapJobId[v.JobId] = struct{}{}
+			continue
+		}
+		task, ok := mapTask[record.TaskId]
+		if !ok {
+			continue
+		}
+		switch {
+		case task.SmtStatus == tables.SmtStatusRollbackComplete, task.TaskType == tables.TaskTypeClosed,
+			task.TxStatus == tables.TxStatusRejected && task.TaskType == tables.TaskTypeDelegate && task.Retry >= config.Cfg.Das.MaxRetry:
+			failedIds = append(failedIds, v.Id)
+			mapJobId[v.JobId] = struct{}{}
+		case task.TxStatus == tables.TxStatusCommitted:
+			mintedIds = append(mintedIds, v.Id)
+			mapJobId[v.JobId] = struct{}{}
+		}
+	}
+
+	if err := t.DbDao.UpdateBulkMintJobItemStatus(mintedIds, tables.BulkMintItemStatusSigned, tables.BulkMintItemStatusMinted, "minted"); err != nil {
+		return fmt.Errorf("UpdateBulkMintJobItemStatus err: %s", err.Error())
+	}
+	if err := t.DbDao.UpdateBulkMintJobItemStatus(failedIds, tables.BulkMintItemStatusSigned, tables.BulkMintItemStatusFailed, "mint failed"); err != nil {
+		return fmt.Errorf("UpdateBulkMintJobItemStatus err: %s", err.Error())
+	}
+	for jobId := range mapJobId {
+		if err := t.DbDao.UpdateBulkMintJobToComplete(jobId); err != nil {
+			return fmt.Errorf("UpdateBulkMintJobToComplete err: %s", err.Error())
+		}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package task
 
 import (
+	"das_sub_account/cache"
 	"das_sub_account/config"
 	"fmt"
 	"github.com/dotbitHQ/das-lib/common"
@@ -13,6 +14,7 @@ func (t *SmtTask) doCheckTx() error {
 		return fmt.Errorf("GetNeedDoCheckTxTaskList err: %s", err.Error())
 	}
 	var rollbackList []uint64
+	var rollbackTaskIds = make(map[string]string)
 	var mapRejected = make(map[string]struct{})
 	for _, v := range list {
 		log.Info(v.TaskId, v.RefOutpoint, v.Outpoint)
@@ -20,6 +22,7 @@ func (t *SmtTask) doCheckTx() error {
 		// check with rejected outpoint map
 		if _, ok := mapRejected[v.RefOutpoint]; ok {
 			rollbackList = append(rollbackList, v.Id)
+			rollbackTaskIds[v.TaskId] = "ref tx rejected"
 			mapRejected[v.Outpoint] = struct{}{}
 			continue
 		}
@@ -33,11 +36,15 @@ func (t *SmtTask) doCheckTx() error {
 		log.Info("doCheckTx:", v.TaskId, v.Outpoint, res.TxStatus.Status)
 		if res.TxStatus.Status == types.TransactionStatusRejected {
 			rollbackList = append(rollbackList, v.Id)
+			rollbackTaskIds[v.TaskId] = "tx rejected"
 			mapRejected[v.Outpoint] = struct{}{}
 		}
 	}
 	if err := t.DbDao.UpdateTaskStatusToRejected(rollbackList); err != nil {
 		return fmt.Errorf("UpdateTaskStatusToRejected err: %s", err.Error())
 	}
+	for taskId, reason := range rollbackTaskIds {
+		t.doStatusEvent(taskId, cache.StatusEventRejected, reason)
+	}
 	return nil
 }
@@ -107,6 +107,8 @@ func (t *SmtTask) doTaskDetail(p *paramDoTaskDetail) error {
 			log.Info("SendTransaction:", hash.String(), p.taskList[i].TaskId)
 			if err := t.DbDao.UpdateTaskTxStatusToPending(p.taskList[i].TaskId); err != nil {
 				log.Error("UpdateTaskTxStatusToPending err: %s", err.Error())
+			} else {
+				t.doStatusEvent(p.taskList[i].TaskId, cache.StatusEventPending, "")
 			}
 		}
 		//time.Sleep(time.Second)
@@ -48,6 +48,10 @@ func (t *SmtTask) doParentAccountPayment() error {
 			amount := record.Amount.DivRound(decimal.NewFromInt(int64(math.Pow10(int(record.Decimals)))), record.Decimals)
 			buf.WriteString(fmt.Sprintf("-Account: %s\n", record.Account))
 			buf.WriteString(fmt.Sprintf("-%s: %s Amount: %s \n", record.TokenId, record.Address, amount))
+			for _, referral := range record.Referrals {
+				referralAmount := referral.Amount.DivRound(decimal.NewFromInt(int64(math.Pow10(int(record.Decimals)))), record.Decimals)
+				buf.WriteString(fmt.Sprintf("-Referral %s: %s Amount: %s \n", referral.Referrer, referral.Address, referralAmount))
+			}
 		}
 		buf.WriteString("\n")
 	}
@@ -0,0 +1,48 @@
+package task
+
+import (
+	"das_sub_account/notify"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"time"
+)
+
+// the expired quotes not used by any order are deleted a day later, the ones of the orders are kept for dispute review
+const priceQuoteRetention = time.Hour * 24
+
+func (t *SmtTask) RunPriceQuotePurge() {
+	tickerPurge := time.NewTicker(time.Minute * 30)
+	t.Wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			select {
+			case <-tickerPurge.C:
+				log.Debug("doPriceQuotePurge start ...")
+				if err := t.doPriceQuotePurge(); err != nil {
+					log.Error("doPriceQuotePurge err:", err.Error())
+					notify.SendLarkErrNotify("doPriceQuotePurge", err.Error())
+				}
+				log.Debug("doPriceQuotePurge end ...")
+			case <-t.Ctx.Done():
+				log.Debug("task doPriceQuotePurge done")
+				t.Wg.Done()
+				return
+			}
+		}
+	}()
+}
+
+func (t *SmtTask) doPriceQuotePurge() error {
+	before := time.Now().Add(-priceQuoteRetention).UnixMilli()
+	for {
+		num, err := t.DbDao.DeleteExpiredPriceQuote(before, 1000)
+		if err != nil {
+			return fmt.Errorf("DeleteExpiredPriceQuote err: %s", err.Error())
+		}
+		log.Info("doPriceQuotePurge:", num)
+		if num < 1000 {
+			return nil
+		}
+	}
+}
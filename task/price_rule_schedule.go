@@ -0,0 +1,166 @@
+package task
+
+import (
+	"context"
+	"das_sub_account/config"
+	"das_sub_account/dao"
+	"das_sub_account/notify"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/common"
+	"github.com/dotbitHQ/das-lib/core"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"github.com/dotbitHQ/das-lib/txbuilder"
+	"github.com/nervosnetwork/ckb-sdk-go/types"
+	"sync"
+	"time"
+)
+
+// the schedule is failed if it can't be sent or committed in time
+const scheduleActivateTimeout = time.Hour
+
+// ScheduledRuleSender build and send the config tx of the scheduled price rules, it's the http handle
+// the reason is returned if the schedule can never be sent, the err should be retried
+type ScheduledRuleSender interface {
+	SendScheduledRule(ctx context.Context, info *tables.TableScheduledRule, signFn func(signList []txbuilder.SignData) error) (txHash, reason string, err error)
+}
+
+type PriceRuleScheduleTask struct {
+	Ctx     context.Context
+	Wg      *sync.WaitGroup
+	DbDao   *dao.DbDao
+	DasCore *core.DasCore
+	Sender  ScheduledRuleSender
+}
+
+// RunPriceRuleSchedule send the config tx of the scheduled price rules at activate_at, status: 0->4->1
+func (t *PriceRuleScheduleTask) RunPriceRuleSchedule() {
+	tickerSchedule := time.NewTicker(time.Second * 30)
+	t.Wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			select {
+			case <-tickerSchedule.C:
+				log.Debug("doPriceRuleActivate start ...")
+				if err := t.doPriceRuleConfirm(); err != nil {
+					log.Error("doPriceRuleConfirm err:", err.Error())
+					notify.SendLarkErrNotify("doPriceRuleConfirm", err.Error())
+				} else if err := t.doPriceRuleActivate(); err != nil {
+					log.Error("doPriceRuleActivate err:", err.Error())
+					notify.SendLarkErrNotify("doPriceRuleActivate", err.Error())
+				}
+				log.Debug("doPriceRuleActivate end ...")
+			case <-t.Ctx.Done():
+				log.Debug("task doPriceRuleActivate done")
+				t.Wg.Done()
+				return
+			}
+		}
+	}()
+}
+
+// doPriceRuleConfirm the schedule is sent only after the config tx is committed
+func (t *PriceRuleScheduleTask) doPriceRuleConfirm() error {
+	list, err := t.DbDao.GetConfirmingScheduledRuleList()
+	if err != nil {
+		return fmt.Errorf("GetConfirmingScheduledRuleList err: %s", err.Error())
+	}
+	for _, v := range list {
+		res, err := t.DasCore.Client().GetTransaction(t.Ctx, types.HexToHash(v.TxHash))
+		if err != nil {
+			return fmt.Errorf("GetTransaction err: %s", err.Error())
+		}
+		log.Info("doPriceRuleConfirm:", v.Id, v.TxHash, res.TxStatus.Status)
+
+		status, reason := tables.ScheduledRuleStatusConfirming, ""
+		switch res.TxStatus.Status {
+		case types.TransactionStatusCommitted:
+			status = tables.ScheduledRuleStatusSent
+		case types.TransactionStatusRejected:
+			status, reason = tables.ScheduledRuleStatusFailed, "tx rejected"
+		default:
+			if v.UpdatedAt.Add(scheduleActivateTimeout).Before(time.Now()) {
+				status, reason = tables.ScheduledRuleStatusFailed, "timeout: tx not committed"
+			}
+		}
+		if status == tables.ScheduledRuleStatusConfirming {
+			continue
+		}
+		if ok, err := t.DbDao.UpdateConfirmingScheduledRuleStatus(v.Id, status, reason); err != nil {
+			return fmt.Errorf("UpdateConfirmingScheduledRuleStatus err: %s", err.Error())
+		} else if ok && status == tables.ScheduledRuleStatusFailed {
+			t.doScheduleFailed(&v, reason)
+		}
+	}
+	return nil
+}
+
+func (t *PriceRuleScheduleTask) doPriceRuleActivate() error {
+	list, err := t.DbDao.GetScheduledRuleListToActivate(100)
+	if err != nil {
+		return fmt.Errorf("GetScheduledRuleListToActivate err: %s", err.Error())
+	}
+	confirmingList, err := t.DbDao.GetConfirmingScheduledRuleList()
+	if err != nil {
+		return fmt.Errorf("GetConfirmingScheduledRuleList err: %s", err.Error())
+	}
+
+	// one config tx of a parent account each time, the later ones wait for the next round
+	mapDone := make(map[string]struct{})
+	for _, v := range confirmingList {
+		mapDone[v.ParentAccountId] = struct{}{}
+	}
+	for _, v := range list {
+		if _, ok := mapDone[v.ParentAccountId]; ok {
+			continue
+		}
+		mapDone[v.ParentAccountId] = struct{}{}
+
+		ctx := context.Background()
+		txHash, reason, err := t.activateScheduledRule(ctx, &v)
+		if err != nil {
+			log.Error(ctx, "activateScheduledRule err:", v.Id, err.Error())
+		}
+		if err != nil && reason == "" {
+			if time.UnixMilli(v.ActivateAt).Add(scheduleActivateTimeout).After(time.Now()) {
+				continue
+			}
+			reason = "timeout: " + err.Error()
+		}
+
+		status := tables.ScheduledRuleStatusConfirming
+		if reason != "" {
+			status = tables.ScheduledRuleStatusFailed
+		}
+		if ok, err := t.DbDao.UpdateScheduledRuleStatus(v.Id, status, txHash, reason); err != nil {
+			return fmt.Errorf("UpdateScheduledRuleStatus err: %s", err.Error())
+		} else if ok && status == tables.ScheduledRuleStatusFailed {
+			t.doScheduleFailed(&v, reason)
+		}
+	}
+	return nil
+}
+
+// activateScheduledRule the private key of the delegated manager is only read here, the handle gets the signing func only
+func (t *PriceRuleScheduleTask) activateScheduledRule(ctx context.Context, info *tables.TableScheduledRule) (txHash, reason string, err error) {
+	managerPrivateKey := config.Cfg.Das.PriceRuleSchedule.ManagerPrivateKey
+	if managerPrivateKey == "" {
+		return "", "", fmt.Errorf("price_rule_schedule not configured")
+	}
+	return t.Sender.SendScheduledRule(ctx, info, func(signList []txbuilder.SignData) error {
+		return DoSign(common.DasActionConfigSubAccount, signList, managerPrivateKey, false)
+	})
+}
+
+func (t *PriceRuleScheduleTask) doScheduleFailed(info *tables.TableScheduledRule, reason string) {
+	notify.SendLarkErrNotify("doPriceRuleActivate", fmt.Sprintf("%s %d: %s", info.Account, info.Id, reason))
+	if err := t.DbDao.CreateWebhookEvent(info.ParentAccountId, tables.WebhookEventPriceRuleFailed, tables.WebhookScheduledRuleData{
+		Id:         info.Id,
+		Account:    info.Account,
+		ActivateAt: info.ActivateAt,
+		Reason:     reason,
+	}); err != nil {
+		log.Error("CreateWebhookEvent err:", err.Error())
+	}
+}
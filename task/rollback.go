@@ -106,10 +106,12 @@ func (t *SmtTask) rollback(task *tables.TableTaskInfo) error {
 		if err := t.DbDao.UpdateSmtRecordToNeedToWrite(task.TaskId, task.Retry+1); err != nil {
 			return fmt.Errorf("UpdateSmtRecordToNeedToWrite err: %s", err.Error())
 		}
+		t.doStatusEvent(task.TaskId, cache.StatusEventPending, fmt.Sprintf("retry %d", task.Retry+1))
 	} else {
 		if err := t.DbDao.UpdateSmtRecordToRollbackComplete(task.TaskId, records); err != nil {
 			return fmt.Errorf("UpdateSmtRecordToRollbackComplete err: %s", err.Error())
 		}
+		t.doStatusEvent(task.TaskId, cache.StatusEventRolledBack, "rollback complete")
 	}
 
 	return nil
@@ -0,0 +1,25 @@
+package task
+
+import (
+	"das_sub_account/cache"
+)
+
+// doStatusEvent commits the status event of the latest state of the task
+func (t *SmtTask) doStatusEvent(taskId string, status cache.StatusEventStatus, reason string) {
+	if t.RC == nil || taskId == "" {
+		return
+	}
+	task, err := t.DbDao.GetTaskByTaskId(taskId)
+	if err != nil {
+		log.Error("doStatusEvent GetTaskByTaskId err:", err.Error(), taskId)
+		return
+	} else if task.Id == 0 {
+		return
+	}
+	records, err := t.DbDao.GetSmtRecordListByTaskId(taskId)
+	if err != nil {
+		log.Error("doStatusEvent GetSmtRecordListByTaskId err:", err.Error(), taskId)
+		return
+	}
+	t.RC.CommitStatusEvent(task, records, status, reason)
+}
@@ -80,17 +80,20 @@ func (t *SmtTask) doUpdateSubAccountTaskDetail(action common.DasAction, parentAc
 		return nil
 	}
 
-	if _, ok := config.Cfg.SuspendMap[parentAccountId]; ok {
-		log.Warn("SuspendMap:", parentAccountId)
-		return nil
-	}
-
 	// get smt records
 	taskMap, subAccountIds, err := t.getTaskMap(taskIdList)
 	if err != nil {
 		return fmt.Errorf("getTaskMap err: %s", err.Error())
 	}
 
+	// the tasks wait until the suspension ends or is lifted
+	if suspension, err := t.getTaskSuspension(parentAccountId, taskMap); err != nil {
+		return fmt.Errorf("getTaskSuspension err: %s", err.Error())
+	} else if suspension.Id > 0 {
+		log.Warn("doUpdateSubAccountTaskDetail suspended:", parentAccountId, suspension.Id, suspension.Scope)
+		return nil
+	}
+
 	// check nonce
 	hasDiffNonce, err := t.doCheckNonceNew(taskMap, subAccountIds)
 	if err != nil {
@@ -145,6 +148,18 @@ func (t *SmtTask) doUpdateSubAccountTaskDetail(action common.DasAction, parentAc
 	return nil
 }
 
+func (t *SmtTask) getTaskSuspension(parentAccountId string, taskMap map[string][]tables.TableSmtRecordInfo) (tables.TableSuspension, error) {
+	var scopes []tables.SuspensionScope
+	for _, records := range taskMap {
+		for _, v := range records {
+			if scope := tables.GetSuspensionScopeBySubAction(v.SubAction); scope != "" {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return t.DbDao.GetActiveSuspension(parentAccountId, scopes...)
+}
+
 // deprecated
 func (t *SmtTask) doUpdateSubAccountTask(action common.DasAction) error {
 	//list, err := t.DbDao.GetNeedToDoTaskListByAction(config.Cfg.Slb.SvrName, action)
@@ -0,0 +1,104 @@
+package task
+
+import (
+	"das_sub_account/notify"
+	"das_sub_account/tables"
+	"fmt"
+	"github.com/dotbitHQ/das-lib/http_api"
+	"time"
+)
+
+const (
+	webhookMaxRetry      = 8
+	webhookRetryBase     = time.Second * 30
+	webhookRetryMax      = time.Hour * 2
+	webhookDeliveryLease = time.Minute * 5
+)
+
+func (t *SmtTask) RunWebhookDelivery() {
+	tickerWebhook := time.NewTicker(time.Second * 10)
+	t.Wg.Add(1)
+	go func() {
+		defer http_api.RecoverPanic()
+		for {
+			select {
+			case <-tickerWebhook.C:
+				if err := t.doWebhookDelivery(); err != nil {
+					log.Error("doWebhookDelivery err:", err.Error())
+					notify.SendLarkErrNotify("doWebhookDelivery", err.Error())
+				}
+			case <-t.Ctx.Done():
+				log.Debug("task doWebhookDelivery done")
+				t.Wg.Done()
+				return
+			}
+		}
+	}()
+}
+
+func (t *SmtTask) doWebhookDelivery() error {
+	list, err := t.DbDao.GetWebhookDeliveryListToSend(100)
+	if err != nil {
+		return fmt.Errorf("GetWebhookDeliveryListToSend err: %s", err.Error())
+	} else if len(list) == 0 {
+		return nil
+	}
+
+	var webhookIds []string
+	for _, v := range list {
+		webhookIds = append(webhookIds, v.WebhookId)
+	}
+	webhookList, err := t.DbDao.GetWebhookListByWebhookIds(webhookIds)
+	if err != nil {
+		return fmt.Errorf("GetWebhookListByWebhookIds err: %s", err.Error())
+	}
+	var mapWebhook = make(map[string]tables.TableWebhook)
+	for _, v := range webhookList {
+		mapWebhook[v.WebhookId] = v
+	}
+
+	for _, v := range list {
+		if ok, err := t.DbDao.ClaimWebhookDelivery(v.Id, v.NextTime, webhookDeliveryLease.Milliseconds()); err != nil {
+			return fmt.Errorf("ClaimWebhookDelivery err: %s", err.Error())
+		} else if !ok {
+			continue
+		}
+		webhook, ok := mapWebhook[v.WebhookId]
+		if !ok || webhook.Status != tables.WebhookStatusEnable {
+			v.Status = tables.WebhookDeliveryStatusFailed
+			v.ErrMsg = "webhook disabled"
+		} else {
+			doWebhookSend(webhook, &v)
+		}
+		if err := t.DbDao.UpdateWebhookDeliveryResult(v); err != nil {
+			return fmt.Errorf("UpdateWebhookDeliveryResult err: %s", err.Error())
+		}
+	}
+	return nil
+}
+
+// doWebhookSend sends the delivery once and sets its status, retry and next_time for the result
+func doWebhookSend(webhook tables.TableWebhook, delivery *tables.TableWebhookDelivery) {
+	code, err := notify.SendWebhook(webhook.Url, webhook.Secret, string(delivery.Event), delivery.DeliveryId, delivery.Payload)
+	delivery.ResponseCode = code
+	if err == nil {
+		delivery.Status = tables.WebhookDeliveryStatusSuccess
+		delivery.ErrMsg = ""
+		return
+	}
+	log.Warn("SendWebhook err:", err.Error(), webhook.WebhookId, delivery.DeliveryId)
+	delivery.ErrMsg = err.Error()
+	if len(delivery.ErrMsg) > 255 {
+		delivery.ErrMsg = delivery.ErrMsg[:255]
+	}
+	delivery.Retry++
+	if delivery.Retry > webhookMaxRetry {
+		delivery.Status = tables.WebhookDeliveryStatusFailed
+		return
+	}
+	backoff := webhookRetryBase << (delivery.Retry - 1)
+	if backoff > webhookRetryMax {
+		backoff = webhookRetryMax
+	}
+	delivery.NextTime = time.Now().Add(backoff).UnixMilli()
+}
@@ -23,6 +23,14 @@ type CsvRecord struct {
 	FeeRate      decimal.Decimal
 	Fee          decimal.Decimal
 	Ids          []uint64
+	Referrals    map[string]*ReferralRecord // referrer account -> the share carved out of Amount
+}
+
+type ReferralRecord struct {
+	Referrer   string
+	ReferrerId string
+	Address    string
+	Amount     decimal.Decimal
 }
 
 func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string, payment bool, endTime time.Time) (map[string]map[string]*CsvRecord, error) {
@@ -55,6 +63,7 @@ func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string,
 	}
 	feeRate := decimal.NewFromInt(1).Sub(platformFeeRatio).Sub(serviceFeeRate)
 
+	referrals := make(map[string]tables.TableReferral)
 	records := make(map[string]map[string]*CsvRecord)
 	for _, v := range list {
 		token, ok := tokens[v.TokenId]
@@ -78,6 +87,7 @@ func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string,
 			csvRecord.TokenId = v.TokenId
 			csvRecord.Decimals = token.Decimals
 			csvRecord.Ids = make([]uint64, 0)
+			csvRecord.Referrals = make(map[string]*ReferralRecord)
 			records[v.ParentAccountId][v.TokenId] = csvRecord
 		}
 		couponMinPrice := minPriceFee.Div(platformFeeRatio.Add(serviceFeeRate)).Mul(decimal.NewFromInt(int64(v.Years)))
@@ -91,7 +101,7 @@ func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string,
 					amount = amount.Mul(feeRate)
 				} else {
 					var fee decimal.Decimal
-					subFee := v.USDAmount.Sub(minPriceFee)
+					subFee := v.GetUsdAmountAfterMinFee(minPriceFee)
 					if subFee.Equal(decimal.Zero) {
 						//Pricing: 0.99$ owner has no profit
 						fee = amount
@@ -100,7 +110,7 @@ func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string,
 						fee = amount.Mul(decimal.NewFromInt(1).Sub(feeRate))
 						amount = amount.Sub(fee)
 					} else {
-						subAmount := subFee.Mul(decimal.NewFromInt(int64(v.Years))).Mul(decimal.New(1, token.Decimals)).Div(token.Price).Ceil()
+						subAmount := subFee.Mul(decimal.New(1, token.Decimals)).Div(token.Price).Ceil()
 						fee = amount.Sub(subAmount)
 						amount = subAmount
 					}
@@ -125,16 +135,49 @@ func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string,
 			if err != nil {
 				return nil, err
 			}
-			if v.USDAmount.GreaterThan(couponSetInfo.Price) {
-				amount = v.USDAmount.Sub(couponSetInfo.Price).Mul(decimal.New(1, token.Decimals)).Div(token.Price).Ceil()
+			if actualUsdPrice := couponSetInfo.GetActualPrice(v.USDAmount, v.Years); actualUsdPrice.GreaterThan(decimal.Zero) {
+				amount = actualUsdPrice.Mul(decimal.New(1, token.Decimals)).Div(token.Price).Ceil()
 				csvRecord.FeeRate = decimal.NewFromInt(1).Sub(feeRate)
-				csvRecord.Fee = amount.Mul(csvRecord.FeeRate)
-				amount = amount.Mul(feeRate)
+				// the min fee of every year is charged from the price after the coupon
+				fee := amount.Mul(csvRecord.FeeRate)
+				if minFee := minPriceFee.Mul(decimal.NewFromInt(int64(v.Years))).Mul(decimal.New(1, token.Decimals)).Div(token.Price).Ceil(); fee.LessThan(minFee) {
+					fee = decimal.Min(minFee, amount)
+				}
+				csvRecord.Fee = fee
+				amount = amount.Sub(fee)
 			} else {
 				amount = decimal.Zero
 			}
 		}
 
+		// the share of the referrer is carved out of the income of the owner, paid to the referrer when ordered
+		if v.ReferralCode != "" && v.ReferralShare.GreaterThan(decimal.Zero) && amount.GreaterThan(decimal.Zero) {
+			referrer, referrerId := v.Referrer, v.ReferrerId
+			// the orders created before the referrer was kept on the order use the current one
+			if referrerId == "" {
+				key := v.ParentAccountId + v.ReferralCode
+				referral, ok := referrals[key]
+				if !ok {
+					if referral, err = s.DbDao.GetReferral(v.ParentAccountId, v.ReferralCode); err != nil {
+						return nil, err
+					}
+					referrals[key] = referral
+				}
+				referrer, referrerId = referral.Referrer, referral.ReferrerId
+			}
+			if referrerId != "" {
+				referralAmount := amount.Mul(v.ReferralShare).Floor()
+				amount = amount.Sub(referralAmount)
+				if _, ok := csvRecord.Referrals[referrer]; !ok {
+					csvRecord.Referrals[referrer] = &ReferralRecord{
+						Referrer:   referrer,
+						ReferrerId: referrerId,
+					}
+				}
+				csvRecord.Referrals[referrer].Amount = csvRecord.Referrals[referrer].Amount.Add(referralAmount)
+			}
+		}
+
 		if amount.GreaterThan(decimal.Zero) {
 			csvRecord.Amount = csvRecord.Amount.Add(amount)
 			csvRecord.Ids = append(csvRecord.Ids, v.Id)
@@ -148,6 +191,28 @@ func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string,
 				continue
 			}
 
+			recordKeys, ok := common.TokenId2RecordKeyMap[tokenId]
+			if !ok {
+				log.Warnf("token id: [%s] to record key mapping failed", tokenId)
+				continue
+			}
+
+			// the share goes back to the owner if the referrer has no address of the token
+			for referrer, referral := range record.Referrals {
+				recordInfo, err := s.DbDao.GetRecordsByAccountIdAndTypeAndLabel(referral.ReferrerId, "address", common.LabelTopDID, recordKeys)
+				if err != nil {
+					log.Error(err)
+					return nil, err
+				}
+				if recordInfo.Id == 0 || referral.Amount.LessThanOrEqual(decimal.Zero) {
+					log.Warnf("account: %s, referrer: %s, token_id: %s no address set, skip it", record.Account, referrer, tokenId)
+					record.Amount = record.Amount.Add(referral.Amount)
+					delete(record.Referrals, referrer)
+					continue
+				}
+				referral.Address = recordInfo.Value
+			}
+
 			token, err := s.DbDao.GetTokenById(tables.TokenId(tokenId))
 			if err != nil {
 				return nil, err
@@ -160,11 +225,6 @@ func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string,
 				continue
 			}
 
-			recordKeys, ok := common.TokenId2RecordKeyMap[tokenId]
-			if !ok {
-				log.Warnf("token id: [%s] to record key mapping failed", tokenId)
-				continue
-			}
 			recordInfo, err := s.DbDao.GetRecordsByAccountIdAndTypeAndLabel(record.AccountId, "address", common.LabelTopDID, recordKeys)
 			if err != nil {
 				log.Error(err)
@@ -215,6 +275,21 @@ func (s *SubAccountTxTool) StatisticsParentAccountPayment(parentAccount string,
 				if err := tx.Create(autoPaymentInfo).Error; err != nil {
 					return err
 				}
+				for _, referral := range record.Referrals {
+					if err := tx.Create(&tables.TableReferralPayment{
+						AutoPaymentId:   autoPaymentInfo.AutoPaymentId,
+						ParentAccountId: record.AccountId,
+						Account:         record.Account,
+						Referrer:        referral.Referrer,
+						ReferrerId:      referral.ReferrerId,
+						TokenId:         record.TokenId,
+						Amount:          referral.Amount,
+						Address:         referral.Address,
+						PaymentDate:     autoPaymentInfo.PaymentDate,
+					}).Error; err != nil {
+						return err
+					}
+				}
 				if len(record.Ids) > 0 {
 					if err = tx.Model(&tables.OrderInfo{}).Where("id in (?)", record.Ids).
 						Updates(map[string]interface{}{
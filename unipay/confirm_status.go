@@ -122,6 +122,12 @@ func (t *ToolUniPay) doConfirmStatus() error {
 		}
 		if err = t.DbDao.UpdateRefundStatusToRefunded(paymentInfo.PayHash, paymentInfo.OrderId, paymentInfo.RefundHash); err != nil {
 			log.Error("UpdateRefundStatusToRefunded err: ", err.Error())
+			continue
+		}
+		if order, err := t.DbDao.GetOrderByOrderID(paymentInfo.OrderId); err != nil {
+			log.Error("GetOrderByOrderID err: ", err.Error())
+		} else if order.Id > 0 {
+			doWebhookOrderEvent(t.DbDao, order, tables.WebhookEventOrderRefunded, paymentInfo.PayHash, paymentInfo.RefundHash)
 		}
 	}
 
@@ -213,6 +219,8 @@ func DoPaymentConfirm(dasCore *core.DasCore, dbDao *dao.DbDao, orderId, payHash
 		if rowsAffected == 0 {
 			log.Warnf("doUniPayNotice: %s %d", orderId, rowsAffected)
 			notify.SendLarkErrNotify("multiple orders success", orderId)
+		} else {
+			doWebhookOrderEvent(dbDao, order, tables.WebhookEventOrderPaid, payHash, "")
 		}
 	}
 
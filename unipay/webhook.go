@@ -0,0 +1,25 @@
+package unipay
+
+import (
+	"das_sub_account/dao"
+	"das_sub_account/tables"
+)
+
+// doWebhookOrderEvent queue the webhook deliveries of the auto-mint order, failures are only logged
+func doWebhookOrderEvent(dbDao *dao.DbDao, order tables.OrderInfo, event tables.WebhookEvent, payHash, refundHash string) {
+	data := tables.WebhookOrderData{
+		OrderId:    order.OrderId,
+		Account:    order.Account,
+		ActionType: order.ActionType,
+		Years:      order.Years,
+		TokenId:    order.TokenId,
+		Amount:     order.Amount.String(),
+		USDAmount:  order.USDAmount.String(),
+		CouponCode: order.CouponCode,
+		PayHash:    payHash,
+		RefundHash: refundHash,
+	}
+	if err := dbDao.CreateWebhookEvent(order.ParentAccountId, event, data); err != nil {
+		log.Error("CreateWebhookEvent err:", err.Error(), event, order.OrderId)
+	}
+}